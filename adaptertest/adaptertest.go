@@ -0,0 +1,205 @@
+// Package adaptertest provides conformance tests for geomodel storage
+// adapters. An adapter author calls Run from a regular test with a factory
+// returning a fresh, empty Index backed by their storage:
+//
+//	func TestConformance(t *testing.T) {
+//		adaptertest.Run(t, func() adaptertest.Index { return newTestIndex(t) })
+//	}
+//
+// The tests exercise the semantics ProximityFetch relies on: an entity is
+// found by any of its geocells, each entity is returned at most once per
+// search, writes and deletes are visible to subsequent searches, and the
+// adapter tolerates concurrent use.
+//
+// The adapters in this module run Run against in-memory fakes of their
+// client interfaces, so geomodel takes no dependency on a database driver or
+// container tooling. The separate integration module runs Run against real
+// Redis, MongoDB and PostgreSQL servers started with dockertest:
+//
+//	cd integration && go test -tags integration ./...
+package adaptertest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+// Index is the storage contract exercised by Run.
+type Index interface {
+	// Put stores entity under its Key, replacing any previous entity with the
+	// same key.
+	Put(entity geomodel.LocationCapable) error
+	// Delete removes the entity with the given key. Deleting a missing key is
+	// not an error.
+	Delete(key string) error
+	// Search returns every stored entity having at least one of cells among
+	// its Geocells, each at most once, in any order.
+	Search(cells []string) ([]geomodel.LocationCapable, error)
+}
+
+type place struct {
+	lat, lon float64
+	key      string
+	geocells []string
+}
+
+func (p place) Latitude() float64  { return p.lat }
+func (p place) Longitude() float64 { return p.lon }
+func (p place) Key() string        { return p.key }
+func (p place) Geocells() []string { return p.geocells }
+
+func newPlace(key string, lat, lon float64) place {
+	return place{lat, lon, key, geomodel.GeoCells(lat, lon, geomodel.MAX_GEOCELL_RESOLUTION)}
+}
+
+// Run runs the conformance suite against indexes returned by newIndex. Each
+// subtest calls newIndex once and expects an empty index.
+func Run(t *testing.T, newIndex func() Index) {
+	t.Run("PutSearch", func(t *testing.T) { testPutSearch(t, newIndex()) })
+	t.Run("Replace", func(t *testing.T) { testReplace(t, newIndex()) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newIndex()) })
+	t.Run("NoDuplicates", func(t *testing.T) { testNoDuplicates(t, newIndex()) })
+	t.Run("ProximityOrdering", func(t *testing.T) { testProximityOrdering(t, newIndex()) })
+	t.Run("Concurrent", func(t *testing.T) { testConcurrent(t, newIndex()) })
+}
+
+func mustPut(t *testing.T, index Index, entities ...geomodel.LocationCapable) {
+	t.Helper()
+	for _, e := range entities {
+		if err := index.Put(e); err != nil {
+			t.Fatalf("Put(%q): %v", e.Key(), err)
+		}
+	}
+}
+
+func mustSearch(t *testing.T, index Index, cells ...string) map[string]geomodel.LocationCapable {
+	t.Helper()
+	found, err := index.Search(cells)
+	if err != nil {
+		t.Fatalf("Search(%v): %v", cells, err)
+	}
+	keys := make(map[string]geomodel.LocationCapable, len(found))
+	for _, e := range found {
+		if _, ok := keys[e.Key()]; ok {
+			t.Fatalf("Search(%v) returned %q more than once", cells, e.Key())
+		}
+		keys[e.Key()] = e
+	}
+	return keys
+}
+
+func testPutSearch(t *testing.T, index Index) {
+	p := newPlace("berlin", 52.52, 13.405)
+	mustPut(t, index, p, newPlace("sydney", -33.87, 151.21))
+
+	for _, cell := range p.geocells {
+		found := mustSearch(t, index, cell)
+		e, ok := found[p.key]
+		if !ok {
+			t.Fatalf("Search(%q) did not return %q", cell, p.key)
+		}
+		if e.Latitude() != p.lat || e.Longitude() != p.lon {
+			t.Errorf("Search(%q) returned %q at (%v, %v), want (%v, %v)", cell, p.key, e.Latitude(), e.Longitude(), p.lat, p.lon)
+		}
+		if _, ok := found["sydney"]; ok {
+			t.Errorf("Search(%q) returned an entity outside the cell", cell)
+		}
+	}
+
+	if found := mustSearch(t, index); len(found) != 0 {
+		t.Errorf("Search with no cells returned %d entities", len(found))
+	}
+}
+
+func testReplace(t *testing.T, index Index) {
+	before := newPlace("mover", 52.52, 13.405)
+	after := newPlace("mover", 48.137, 11.575)
+	mustPut(t, index, before, after)
+
+	if found := mustSearch(t, index, before.geocells[len(before.geocells)-1]); len(found) != 0 {
+		t.Errorf("replaced entity still found at its old cell")
+	}
+	if _, ok := mustSearch(t, index, after.geocells[len(after.geocells)-1])[after.key]; !ok {
+		t.Errorf("replaced entity not found at its new cell")
+	}
+}
+
+func testDelete(t *testing.T, index Index) {
+	p := newPlace("gone", 40.7128, -74.006)
+	mustPut(t, index, p)
+	if err := index.Delete(p.key); err != nil {
+		t.Fatalf("Delete(%q): %v", p.key, err)
+	}
+	if found := mustSearch(t, index, p.geocells...); len(found) != 0 {
+		t.Errorf("deleted entity still found")
+	}
+	if err := index.Delete("missing"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func testNoDuplicates(t *testing.T, index Index) {
+	p := newPlace("dup", 35.6762, 139.6503)
+	mustPut(t, index, p)
+	// Searching several ancestors of the same cell must still yield the
+	// entity once.
+	if found := mustSearch(t, index, p.geocells...); len(found) != 1 {
+		t.Errorf("Search over all geocells returned %d entities, want 1", len(found))
+	}
+}
+
+func testProximityOrdering(t *testing.T, index Index) {
+	for i := 0; i < 5; i++ {
+		mustPut(t, index, newPlace(fmt.Sprint(i), 50+0.01*float64(i), 8))
+	}
+	search := func(cells []string) []geomodel.LocationCapable {
+		found, err := index.Search(cells)
+		if err != nil {
+			t.Errorf("Search(%v): %v", cells, err)
+		}
+		return found
+	}
+
+	result := geomodel.ProximityFetch(50, 8, 3, 0, search, geomodel.MAX_GEOCELL_RESOLUTION)
+	if len(result) != 3 {
+		t.Fatalf("ProximityFetch returned %d entities, want 3", len(result))
+	}
+	for i, e := range result {
+		if want := fmt.Sprint(i); e.Key() != want {
+			t.Errorf("result %d is %q, want %q", i, e.Key(), want)
+		}
+	}
+}
+
+func testConcurrent(t *testing.T, index Index) {
+	const workers = 8
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				p := newPlace(fmt.Sprintf("%d-%d", w, i), 10+float64(w), 10+float64(i)*0.001)
+				if err := index.Put(p); err != nil {
+					t.Errorf("Put(%q): %v", p.key, err)
+					return
+				}
+				if _, err := index.Search(p.geocells[:4]); err != nil {
+					t.Errorf("Search: %v", err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < workers; w++ {
+		p := newPlace(fmt.Sprintf("%d-%d", w, 9), 10+float64(w), 10+0.009)
+		if _, ok := mustSearch(t, index, p.geocells[len(p.geocells)-1])[p.key]; !ok {
+			t.Errorf("entity %q written concurrently was not found", p.key)
+		}
+	}
+}
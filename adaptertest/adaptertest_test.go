@@ -0,0 +1,49 @@
+package adaptertest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+type mapIndex struct {
+	mu       sync.RWMutex
+	entities map[string]geomodel.LocationCapable
+}
+
+func (m *mapIndex) Put(e geomodel.LocationCapable) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entities[e.Key()] = e
+	return nil
+}
+
+func (m *mapIndex) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entities, key)
+	return nil
+}
+
+func (m *mapIndex) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var result []geomodel.LocationCapable
+	for _, e := range m.entities {
+	match:
+		for _, c := range e.Geocells() {
+			for _, cell := range cells {
+				if c == cell {
+					result = append(result, e)
+					break match
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func TestRun(t *testing.T) {
+	Run(t, func() Index { return &mapIndex{entities: make(map[string]geomodel.LocationCapable)} })
+}
@@ -0,0 +1,71 @@
+package geomodel
+
+import (
+	"strings"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// AdaptiveCover returns a covering of bbox with cells of mixed resolutions:
+// starting from the top-level cells, a cell is split into its children only
+// while density reports more than threshold entities in it and it is coarser
+// than maxResolution. Sparse areas such as oceans are thus covered by a few
+// coarse cells and dense ones by fine cells, keeping the cells passed to a
+// repository few without fetching many entities outside bbox.
+//
+// density may come from any source of per-cell counts, such as CountDensity
+// over a sample of the entities or the Hits of a DensityStats.
+func AdaptiveCover(bbox BoundingBox, density func(cell string) int, threshold, maxResolution int) []string {
+	var cells []string
+	for _, part := range bbox.Split() {
+		cells = append(cells, adaptiveCover(curve.Geohash, part, density, threshold, maxResolution)...)
+	}
+	return cells
+}
+
+// WithAdaptiveCover makes BoundingBoxFetch and ProximityFetchAll cover the
+// searched area as AdaptiveCover does, instead of with at most 64 cells of a
+// single resolution.
+func WithAdaptiveCover(density func(cell string) int, threshold int) Option {
+	return func(o *searchOptions) {
+		o.adaptiveDensity = density
+		o.adaptiveThreshold = threshold
+	}
+}
+
+// adaptiveCover returns the adaptive covering of region by cells of c.
+func adaptiveCover(c Curve, region Region, density func(cell string) int, threshold, maxResolution int) []string {
+	var cells []string
+	var visit func(cell string)
+	visit = func(cell string) {
+		if len(cell) >= maxResolution || density(cell) <= threshold {
+			cells = append(cells, cell)
+			return
+		}
+		for _, child := range cellsInBox(c, computeBox(c, cell), len(cell)+1) {
+			// Cells along the parent's edges belong to its neighbors.
+			if strings.HasPrefix(child, cell) && region.Intersects(computeBox(c, child)) {
+				visit(child)
+			}
+		}
+	}
+	for _, cell := range cellsInBox(c, NewBoundingBox(90, 180, -90, -180), 1) {
+		if region.Intersects(computeBox(c, cell)) {
+			visit(cell)
+		}
+	}
+	return cells
+}
+
+// CountDensity returns a density function for AdaptiveCover counting the
+// entities whose location lies in each cell, up to MAX_GEOCELL_RESOLUTION.
+func CountDensity(entities []LocationCapable) func(cell string) int {
+	var counts map[string]int = make(map[string]int)
+	for _, entity := range entities {
+		var finest string = GeoCell(entity.Latitude(), entity.Longitude(), MAX_GEOCELL_RESOLUTION)
+		for resolution := 1; resolution <= len(finest); resolution++ {
+			counts[finest[:resolution]]++
+		}
+	}
+	return func(cell string) int { return counts[cell] }
+}
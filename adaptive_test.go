@@ -0,0 +1,61 @@
+package geomodel
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestAdaptiveCover(t *testing.T) {
+	var random *rand.Rand = rand.New(rand.NewSource(3))
+	var entities []LocationCapable
+	// A dense city and a sparse surrounding area.
+	for i := 0; i < 2000; i++ {
+		var lat, lon float64 = 50.1 + random.Float64()*0.05, 8.65 + random.Float64()*0.05
+		entities = append(entities, Place{lat, lon, fmt.Sprint("city", i), GeoCells(lat, lon, 10)})
+	}
+	for i := 0; i < 50; i++ {
+		var lat, lon float64 = 45 + random.Float64()*10, 2 + random.Float64()*14
+		entities = append(entities, Place{lat, lon, fmt.Sprint("rural", i), GeoCells(lat, lon, 10)})
+	}
+
+	var bbox BoundingBox = NewBoundingBox(55, 16, 45, 2)
+	var density = CountDensity(entities)
+	var cells []string = AdaptiveCover(bbox, density, 100, 8)
+
+	var resolutions map[int]int = make(map[int]int)
+	for _, cell := range cells {
+		resolutions[len(cell)]++
+		if density(cell) > 100 && len(cell) < 8 {
+			t.Errorf("cell %q holds %d entities but was not split", cell, density(cell))
+		}
+		if !bbox.Intersects(ComputeBox(cell)) {
+			t.Errorf("cell %q lies outside the box", cell)
+		}
+	}
+	if resolutions[1] == 0 || resolutions[6] == 0 || len(cells) > 200 {
+		t.Errorf("covering of %d cells with resolutions %v", len(cells), resolutions)
+	}
+	for _, entity := range entities {
+		var covered bool
+		for _, cell := range cells {
+			if strings.HasPrefix(GeoCell(entity.Latitude(), entity.Longitude(), 10), cell) {
+				covered = true
+			}
+		}
+		if !covered {
+			t.Fatalf("entity at (%v, %v) not covered", entity.Latitude(), entity.Longitude())
+		}
+	}
+
+	var want []LocationCapable = BoundingBoxFetch(bbox, searchPlaces(entities), 10)
+	var got []LocationCapable = BoundingBoxFetch(bbox, searchPlaces(entities), 10, WithAdaptiveCover(density, 100))
+	if len(got) != len(entities) || len(want) != len(entities) {
+		t.Errorf("BoundingBoxFetch found %d entities adaptively and %d otherwise, want %d", len(got), len(want), len(entities))
+	}
+	var adaptive []SearchResult = ProximityFetchAll(50.12, 8.67, 3000, searchPlaces(entities), 10, WithAdaptiveCover(density, 100))
+	if results := ProximityFetchAll(50.12, 8.67, 3000, searchPlaces(entities), 10); len(adaptive) != len(results) || len(results) < 1000 {
+		t.Errorf("ProximityFetchAll found %d entities adaptively and %d otherwise", len(adaptive), len(results))
+	}
+}
@@ -0,0 +1,89 @@
+package geomodel
+
+import (
+	"math"
+	"sort"
+)
+
+// Aggregate folds the entities of each geocell at resolution into a value,
+// starting from the zero value of A and calling reduce with the value so far
+// and each entity of the cell in turn. It returns the value of every
+// occupied cell:
+//
+//	var revenue = geomodel.Aggregate(orders, 6, func(sum float64, e geomodel.LocationCapable) float64 {
+//		return sum + e.(*Order).Total
+//	})
+//
+// CellStatsOf builds a reduce function collecting the common statistics.
+func Aggregate[A any](entities []LocationCapable, resolution int, reduce func(acc A, e LocationCapable) A) map[string]A {
+	var result map[string]A = make(map[string]A)
+	for _, entity := range entities {
+		var cell string = GeoCell(entity.Latitude(), entity.Longitude(), resolution)
+		result[cell] = reduce(result[cell], entity)
+	}
+	return result
+}
+
+// CellStats summarizes a metric over the entities of a cell.
+type CellStats struct {
+	Count int
+	Sum   float64
+	Min   float64
+	Max   float64
+}
+
+// Mean returns the mean of the metric, or NaN for an empty cell.
+func (s CellStats) Mean() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// CellStatsOf returns a reduce function for Aggregate collecting the count
+// of entities and the sum, minimum and maximum of metric over them. A nil
+// metric counts entities only, with Sum, Min and Max left zero.
+func CellStatsOf(metric func(LocationCapable) float64) func(CellStats, LocationCapable) CellStats {
+	return func(s CellStats, e LocationCapable) CellStats {
+		s.Count++
+		if metric == nil {
+			return s
+		}
+		var v float64 = metric(e)
+		if s.Count == 1 {
+			s.Min, s.Max = v, v
+		} else {
+			s.Min, s.Max = math.Min(s.Min, v), math.Max(s.Max, v)
+		}
+		s.Sum += v
+		return s
+	}
+}
+
+// CellCount is a cell returned by TopCells with its number of entities.
+type CellCount struct {
+	Cell  string
+	Count int
+	Box   BoundingBox
+}
+
+// TopCells returns the n cells at resolution holding the most entities,
+// with their counts and boxes, most entities first and ties ordered by cell.
+func TopCells(entities []LocationCapable, resolution, n int) []CellCount {
+	var counts map[string]int = Aggregate(entities, resolution, func(count int, _ LocationCapable) int { return count + 1 })
+	var top []CellCount = make([]CellCount, 0, len(counts))
+	for cell, count := range counts {
+		top = append(top, CellCount{Cell: cell, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Cell < top[j].Cell
+	})
+	top = top[:max(0, min(n, len(top)))]
+	for i := range top {
+		top[i].Box = ComputeBox(top[i].Cell)
+	}
+	return top
+}
@@ -0,0 +1,59 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	var entities = []LocationCapable{
+		Place{50.01, 8.01, "3", nil},
+		Place{50.03, 8.05, "5", nil},
+		Place{50.02, 8.03, "1", nil},
+		Place{-33.87, 151.21, "7", nil},
+	}
+	var price = func(e LocationCapable) float64 { return float64(e.Key()[0] - '0') }
+
+	var stats map[string]CellStats = Aggregate(entities, 4, CellStatsOf(price))
+	var frankfurt CellStats = stats[GeoCell(50.01, 8.01, 4)]
+	if len(stats) != 2 || frankfurt != (CellStats{Count: 3, Sum: 9, Min: 1, Max: 5}) || frankfurt.Mean() != 3 {
+		t.Errorf("got %v, want 2 cells with 3 entities priced 1 to 5 in Frankfurt", stats)
+	}
+	if sydney := stats[GeoCell(-33.87, 151.21, 4)]; sydney != (CellStats{Count: 1, Sum: 7, Min: 7, Max: 7}) {
+		t.Errorf("Sydney stats %+v", sydney)
+	}
+
+	var counts map[string]CellStats = Aggregate(entities, 1, CellStatsOf(nil))
+	if counts["u"].Count != 3 || counts["u"].Sum != 0 {
+		t.Errorf("counts %v", counts)
+	}
+	if !math.IsNaN((CellStats{}).Mean()) {
+		t.Error("mean of an empty cell is not NaN")
+	}
+
+	var keys map[string]string = Aggregate(entities, 2, func(acc string, e LocationCapable) string { return acc + e.Key() })
+	if keys[GeoCell(50, 8, 2)] != "351" {
+		t.Errorf("keys %v", keys)
+	}
+}
+
+func TestTopCells(t *testing.T) {
+	var entities []LocationCapable
+	for i, p := range []Point{{Lat: 50, Lon: 8}, {Lat: 50.001, Lon: 8}, {Lat: 50.002, Lon: 8}, {Lat: 48.85, Lon: 2.35}, {Lat: 48.86, Lon: 2.35}, {Lat: -33.87, Lon: 151.21}} {
+		entities = append(entities, Place{p.Lat, p.Lon, string(rune('a' + i)), nil})
+	}
+
+	var top []CellCount = TopCells(entities, 4, 2)
+	if len(top) != 2 || top[0].Cell != GeoCell(50, 8, 4) || top[0].Count != 3 || top[1].Cell != GeoCell(48.85, 2.35, 4) || top[1].Count != 2 {
+		t.Fatalf("TopCells = %+v", top)
+	}
+	if top[0].Box != ComputeBox(top[0].Cell) {
+		t.Errorf("box of %q = %v", top[0].Cell, top[0].Box)
+	}
+	if all := TopCells(entities, 4, 10); len(all) != 3 || all[2].Count != 1 {
+		t.Errorf("TopCells with n beyond the cell count = %+v", all)
+	}
+	if none := TopCells(entities, 4, 0); len(none) != 0 {
+		t.Errorf("TopCells with n 0 = %+v", none)
+	}
+}
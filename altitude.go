@@ -0,0 +1,39 @@
+package geomodel
+
+import "math"
+
+// AltitudeCapable is implemented by entities that know their altitude in
+// meters, such as drones or points on the floors of a building. Searches
+// configured with WithAltitude rank them by their distance in three
+// dimensions.
+type AltitudeCapable interface {
+	Altitude() float64
+}
+
+// WithAltitude sets the altitude in meters of the search center and ranks
+// AltitudeCapable entities by sqrt(d² + (weight·Δh)²), where d is their
+// distance along the surface and Δh their altitude difference. A weight of
+// 1 counts a vertical meter as a horizontal one; larger weights separate
+// stacked locations more. Entities without an altitude keep their surface
+// distance, and a weight of 0 or less disables the ranking. maxDistance
+// applies to the combined distance. As it is never below the surface
+// distance, searches still stop as soon as no cell left can hold a nearer
+// entity.
+func WithAltitude(altitude, weight float64) Option {
+	return func(o *searchOptions) {
+		o.altitude = altitude
+		o.altitudeWeight = max(weight, 0)
+	}
+}
+
+// entityDistance returns the distance of entity from (lat, lon) by which a
+// search ranks it.
+func (o *searchOptions) entityDistance(lat, lon float64, entity LocationCapable) float64 {
+	var d float64 = o.distance(lat, lon, entity.Latitude(), entity.Longitude())
+	if o.altitudeWeight > 0 {
+		if a, ok := entity.(AltitudeCapable); ok {
+			d = math.Hypot(d, o.altitudeWeight*(a.Altitude()-o.altitude))
+		}
+	}
+	return d
+}
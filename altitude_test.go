@@ -0,0 +1,47 @@
+package geomodel
+
+import (
+	"fmt"
+	"testing"
+)
+
+type floor struct {
+	Place
+	altitude float64
+}
+
+func (f floor) Altitude() float64 { return f.altitude }
+
+func TestProximityFetchWithAltitude(t *testing.T) {
+	var places []LocationCapable
+	for i := 0; i < 5; i++ {
+		places = append(places, floor{Place{50, 8, fmt.Sprint("floor", i), GeoCells(50, 8, 10)}, float64(i) * 4})
+	}
+	// Next door, on the ground.
+	places = append(places, Place{50, 8.0001, "ground", GeoCells(50, 8.0001, 10)})
+	var search = indexPlaces(places)
+
+	var results = ProximityFetchResults(50, 8, 3, 0, search, 10, WithAltitude(11, 1))
+	var keys []string
+	for _, r := range results {
+		keys = append(keys, r.Entity.Key())
+	}
+	if fmt.Sprint(keys) != "[floor3 floor2 floor4]" {
+		t.Errorf("nearest to altitude 11 are %v, want [floor3 floor2 floor4]", keys)
+	}
+	if results[1].Distance != 3 {
+		t.Errorf("floor2 at distance %v, want 3", results[1].Distance)
+	}
+
+	// Without an altitude, the stacked floors tie at distance 0.
+	results = ProximityFetchResults(50, 8, 6, 0, search, 10)
+	if results[5].Entity.Key() != "ground" {
+		t.Errorf("farthest without altitude is %v, want ground", results[5].Entity.Key())
+	}
+
+	// maxDistance applies to the combined distance.
+	results = ProximityFetchResults(50, 8, 10, 5, search, 10, WithAltitude(0, 1))
+	if len(results) != 2 {
+		t.Errorf("got %d results within 5 m of the ground floor, want 2", len(results))
+	}
+}
@@ -0,0 +1,68 @@
+package geomodel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// GeoCellBatch returns GeoCell(lats[i], lons[i], resolution) for every i.
+// All cells are encoded into one shared buffer and returned as substrings of
+// a single string, so the cost is a constant number of allocations however
+// many points are encoded. Invalid points, for which GeoCell returns "", get
+// "" too. It panics if lats and lons differ in length.
+func GeoCellBatch(lats, lons []float64, resolution int) []string {
+	if len(lats) != len(lons) {
+		panic("geomodel: GeoCellBatch called with mismatched coordinate slices")
+	}
+
+	var cells []string = make([]string, len(lats))
+	if resolution <= 0 {
+		return cells
+	}
+
+	// Cells of invalid points are empty, so each one's end is recorded.
+	var buf []byte = make([]byte, 0, len(lats)*resolution)
+	var ends []int = make([]int, len(lats))
+	for i := range lats {
+		buf = AppendGeoCell(buf, lats[i], lons[i], resolution)
+		ends[i] = len(buf)
+	}
+
+	var all string = string(buf)
+	var start int
+	for i, end := range ends {
+		cells[i] = all[start:end]
+		start = end
+	}
+	return cells
+}
+
+// GeoCellBatchParallel is GeoCellBatch spread over workers goroutines, each
+// encoding a contiguous chunk of the points. A non-positive workers uses
+// GOMAXPROCS.
+func GeoCellBatchParallel(lats, lons []float64, resolution int, workers int) []string {
+	if len(lats) != len(lons) {
+		panic("geomodel: GeoCellBatchParallel called with mismatched coordinate slices")
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var chunk int = (len(lats) + workers - 1) / workers
+	if workers == 1 || chunk == 0 {
+		return GeoCellBatch(lats, lons, resolution)
+	}
+
+	var cells []string = make([]string, len(lats))
+	var wg sync.WaitGroup
+	for start := 0; start < len(lats); start += chunk {
+		var end int = min(start+chunk, len(lats))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			copy(cells[start:end], GeoCellBatch(lats[start:end], lons[start:end], resolution))
+		}(start, end)
+	}
+	wg.Wait()
+	return cells
+}
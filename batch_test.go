@@ -0,0 +1,51 @@
+package geomodel
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestGeoCellBatch(t *testing.T) {
+	var rng = rand.New(rand.NewSource(1))
+	var lats, lons = make([]float64, 1000), make([]float64, 1000)
+	for i := range lats {
+		lats[i], lons[i] = rng.Float64()*180-90, rng.Float64()*360-180
+	}
+
+	var batch = GeoCellBatch(lats, lons, 9)
+	var parallel = GeoCellBatchParallel(lats, lons, 9, 7)
+	for i := range lats {
+		var want = GeoCell(lats[i], lons[i], 9)
+		if batch[i] != want || parallel[i] != want {
+			t.Fatalf("point %d: batch %q, parallel %q, want %q", i, batch[i], parallel[i], want)
+		}
+	}
+}
+
+func TestGeoCellBatchInvalidPoints(t *testing.T) {
+	var lats = []float64{50, math.NaN(), 51, 52}
+	var lons = []float64{8, 8, math.Inf(1), 9}
+	for _, resolution := range []int{6, MAX_GEOCELL_RESOLUTION + 3} {
+		var want = []string{GeoCell(50, 8, resolution), "", "", GeoCell(52, 9, resolution)}
+		if got := GeoCellBatch(lats, lons, resolution); !reflect.DeepEqual(got, want) {
+			t.Errorf("GeoCellBatch at resolution %d = %q, want %q", resolution, got, want)
+		}
+		if got := GeoCellBatchParallel(lats, lons, resolution, 2); !reflect.DeepEqual(got, want) {
+			t.Errorf("GeoCellBatchParallel at resolution %d = %q, want %q", resolution, got, want)
+		}
+	}
+}
+
+func BenchmarkGeoCellBatch(b *testing.B) {
+	var lats, lons = make([]float64, 10000), make([]float64, 10000)
+	for i := range lats {
+		lats[i], lons[i] = float64(i%180)-90, float64(i%360)-180
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GeoCellBatch(lats, lons, MAX_GEOCELL_RESOLUTION)
+	}
+}
@@ -1,5 +1,11 @@
 package geomodel
 
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
 type BoundingBox struct {
 	latNE float64
 	lonNE float64
@@ -7,6 +13,10 @@ type BoundingBox struct {
 	lonSW float64
 }
 
+// NewBoundingBox returns the box with the given edges in degrees, swapping
+// north and south if they are reversed. A box whose east edge lies west of
+// its west edge crosses the antimeridian. Edges are not otherwise checked;
+// NewBoundingBoxChecked and NewBoundingBoxCrossing reject invalid ones.
 func NewBoundingBox(north, east, south, west float64) BoundingBox {
 	var north_, south_ float64
 	if south > north {
@@ -19,3 +29,191 @@ func NewBoundingBox(north, east, south, west float64) BoundingBox {
 
 	return BoundingBox{north_, east, south_, west}
 }
+
+// ErrInvalidBoundingBox is wrapped by the errors of NewBoundingBoxChecked
+// and NewBoundingBoxCrossing.
+var ErrInvalidBoundingBox = errors.New("geomodel: invalid bounding box")
+
+// NewBoundingBoxChecked returns the box with the given edges in degrees, or
+// an error wrapping ErrInvalidBoundingBox if a latitude is outside
+// [-90, 90], a longitude outside [-180, 180], north lies south of south or
+// east lies west of west. Unlike NewBoundingBox it takes a box whose east
+// edge lies west of its west edge for a mistake; NewBoundingBoxCrossing
+// creates boxes crossing the antimeridian.
+func NewBoundingBoxChecked(north, east, south, west float64) (BoundingBox, error) {
+	if err := checkBoundingBox(north, east, south, west); err != nil {
+		return BoundingBox{}, err
+	}
+	if east < west {
+		return BoundingBox{}, fmt.Errorf("%w: east %v lies west of west %v", ErrInvalidBoundingBox, east, west)
+	}
+	return BoundingBox{north, east, south, west}, nil
+}
+
+// NewBoundingBoxCrossing returns the box crossing the antimeridian from west
+// eastwards to east, such as one spanning Fiji with west 177 and east -178.
+// It returns an error wrapping ErrInvalidBoundingBox if the edges are out of
+// range as for NewBoundingBoxChecked, or if east does not lie west of west.
+func NewBoundingBoxCrossing(north, east, south, west float64) (BoundingBox, error) {
+	if err := checkBoundingBox(north, east, south, west); err != nil {
+		return BoundingBox{}, err
+	}
+	if east >= west {
+		return BoundingBox{}, fmt.Errorf("%w: east %v does not lie west of west %v, so the box does not cross the antimeridian", ErrInvalidBoundingBox, east, west)
+	}
+	return BoundingBox{north, east, south, west}, nil
+}
+
+// checkBoundingBox checks the ranges of the edges of a box.
+func checkBoundingBox(north, east, south, west float64) error {
+	for _, lat := range []float64{north, south} {
+		if !(lat >= -90 && lat <= 90) {
+			return fmt.Errorf("%w: latitude %v outside [-90, 90]", ErrInvalidBoundingBox, lat)
+		}
+	}
+	for _, lon := range []float64{east, west} {
+		if !(lon >= -180 && lon <= 180) {
+			return fmt.Errorf("%w: longitude %v outside [-180, 180]", ErrInvalidBoundingBox, lon)
+		}
+	}
+	if north < south {
+		return fmt.Errorf("%w: north %v lies south of south %v", ErrInvalidBoundingBox, north, south)
+	}
+	return nil
+}
+
+// CrossesAntimeridian reports whether bbox crosses the antimeridian, that is
+// whether its east edge lies west of its west edge.
+func (bbox BoundingBox) CrossesAntimeridian() bool { return bbox.lonNE < bbox.lonSW }
+
+// North returns the latitude of the north edge of bbox.
+func (bbox BoundingBox) North() float64 { return bbox.latNE }
+
+// East returns the longitude of the east edge of bbox.
+func (bbox BoundingBox) East() float64 { return bbox.lonNE }
+
+// South returns the latitude of the south edge of bbox.
+func (bbox BoundingBox) South() float64 { return bbox.latSW }
+
+// West returns the longitude of the west edge of bbox.
+func (bbox BoundingBox) West() float64 { return bbox.lonSW }
+
+// Split returns bbox as boxes that do not cross the antimeridian: bbox itself
+// if its east edge lies east of its west edge, or otherwise its parts west
+// and east of the antimeridian, for backends unable to query wrapping ranges.
+func (bbox BoundingBox) Split() []BoundingBox {
+	if bbox.lonNE >= bbox.lonSW {
+		return []BoundingBox{bbox}
+	}
+	return []BoundingBox{
+		{bbox.latNE, 180, bbox.latSW, bbox.lonSW},
+		{bbox.latNE, bbox.lonNE, bbox.latSW, -180},
+	}
+}
+
+// Contains reports whether (lat, lon) lies inside bbox, edges included.
+func (bbox BoundingBox) Contains(lat, lon float64) bool {
+	if lat < bbox.latSW || lat > bbox.latNE {
+		return false
+	}
+	if bbox.lonNE < bbox.lonSW {
+		return lon >= bbox.lonSW || lon <= bbox.lonNE
+	}
+	return lon >= bbox.lonSW && lon <= bbox.lonNE
+}
+
+// Intersects reports whether bbox and other share any point.
+func (bbox BoundingBox) Intersects(other BoundingBox) bool {
+	if bbox.latSW > other.latNE || other.latSW > bbox.latNE {
+		return false
+	}
+	for _, a := range bbox.Split() {
+		for _, b := range other.Split() {
+			if a.lonSW <= b.lonNE && b.lonSW <= a.lonNE {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewBoundingBoxAround returns a box holding every point within radius
+// meters of center. Circles reaching a pole or spanning half the globe
+// yield boxes of every longitude.
+func NewBoundingBoxAround(center Point, radius float64) BoundingBox {
+	return circleBox(center.Lat, center.Lon, radius)
+}
+
+// lonWidth returns the extent of bbox in degrees of longitude, measured
+// eastwards from its west edge across the antimeridian if need be.
+func (bbox BoundingBox) lonWidth() float64 {
+	var width float64 = bbox.lonNE - bbox.lonSW
+	if width < 0 {
+		width += 360
+	}
+	return width
+}
+
+// Center returns the point midway between the edges of bbox, in the middle
+// of its longitudes if it crosses the antimeridian.
+func (bbox BoundingBox) Center() Point {
+	var lon float64 = bbox.lonSW + bbox.lonWidth()/2
+	if lon > 180 {
+		lon -= 360
+	}
+	return Point{Lat: (bbox.latSW + bbox.latNE) / 2, Lon: lon}
+}
+
+// Union returns the smallest box holding both bbox and other. Of the two
+// ways to join their longitudes around the globe, the narrower is taken.
+func (bbox BoundingBox) Union(other BoundingBox) BoundingBox {
+	var north float64 = math.Max(bbox.latNE, other.latNE)
+	var south float64 = math.Min(bbox.latSW, other.latSW)
+
+	var width, otherWidth float64 = bbox.lonWidth(), other.lonWidth()
+	var west float64 = bbox.lonSW
+	var extent float64 = math.Max(width, math.Mod(other.lonSW-bbox.lonSW+360, 360)+otherWidth)
+	if alternative := math.Max(otherWidth, math.Mod(bbox.lonSW-other.lonSW+360, 360)+width); alternative < extent {
+		west, extent = other.lonSW, alternative
+	}
+	if extent >= 360 {
+		return BoundingBox{north, 180, south, -180}
+	}
+	var east float64 = west + extent
+	if east > 180 {
+		east -= 360
+	}
+	return BoundingBox{north, east, south, west}
+}
+
+// Expand returns bbox grown by meters on every side. Boxes reaching a pole
+// or growing to span every longitude cover all longitudes.
+func (bbox BoundingBox) Expand(meters float64) BoundingBox {
+	var dLat float64 = RadToDeg(meters / EARTH_RADIUS)
+	var north float64 = math.Min(bbox.latNE+dLat, 90)
+	var south float64 = math.Max(bbox.latSW-dLat, -90)
+	if north == 90 || south == -90 {
+		return BoundingBox{north, 180, south, -180}
+	}
+
+	// Degrees of longitude are shortest at the latitude farthest from the
+	// equator.
+	var dLon float64 = dLat / math.Cos(DegToRad(math.Max(math.Abs(north), math.Abs(south))))
+	if bbox.lonWidth()+2*dLon >= 360 {
+		return BoundingBox{north, 180, south, -180}
+	}
+	var east, west float64 = bbox.lonNE + dLon, bbox.lonSW - dLon
+	if east > 180 {
+		east -= 360
+	}
+	if west < -180 {
+		west += 360
+	}
+	return BoundingBox{north, east, south, west}
+}
+
+// AreaM2 returns the area of bbox in square meters, on a sphere of radius
+// EARTH_RADIUS.
+func (bbox BoundingBox) AreaM2() float64 {
+	return EARTH_RADIUS * EARTH_RADIUS * DegToRad(bbox.lonWidth()) * (math.Sin(DegToRad(bbox.latNE)) - math.Sin(DegToRad(bbox.latSW)))
+}
@@ -0,0 +1,101 @@
+package geomodel
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestBoundingBoxUnion(t *testing.T) {
+	var tests = []struct {
+		a, b, want BoundingBox
+	}{
+		{NewBoundingBox(10, 10, 0, 0), NewBoundingBox(20, 20, 5, 5), NewBoundingBox(20, 20, 0, 0)},
+		{NewBoundingBox(10, 10, 0, 0), NewBoundingBox(5, -10, -5, -20), NewBoundingBox(10, 10, -5, -20)},
+		{NewBoundingBox(10, 10, 0, 0), NewBoundingBox(2, 8, 1, 2), NewBoundingBox(10, 10, 0, 0)},
+		// Joining across the antimeridian is narrower.
+		{NewBoundingBox(1, 179, 0, 170), NewBoundingBox(1, -170, 0, -179), NewBoundingBox(1, -170, 0, 170)},
+		{NewBoundingBox(1, -175, 0, 175), NewBoundingBox(1, 179, 0, 178), NewBoundingBox(1, -175, 0, 175)},
+		{NewBoundingBox(1, 100, 0, -100), NewBoundingBox(1, -110, 0, 90), NewBoundingBox(1, -110, 0, -100)},
+		{NewBoundingBox(1, 100, 0, -100), NewBoundingBox(1, -90, 0, 90), NewBoundingBox(1, 180, 0, -180)},
+	}
+	for _, test := range tests {
+		if got := test.a.Union(test.b); got != test.want {
+			t.Errorf("%v.Union(%v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+		if got := test.b.Union(test.a); got != test.want {
+			t.Errorf("%v.Union(%v) = %v, want %v", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestBoundingBoxExpand(t *testing.T) {
+	var bbox BoundingBox = NewBoundingBox(50.1, 8.1, 50, 8).Expand(1000)
+	var center Point = Point{Lat: 50.05, Lon: 8.05}
+	for _, edge := range []Point{{Lat: bbox.North(), Lon: center.Lon}, {Lat: bbox.South(), Lon: center.Lon}} {
+		if d := Distance(edge.Lat, edge.Lon, center.Lat, center.Lon); math.Abs(d-1000-Distance(50.1, 8.05, center.Lat, center.Lon)) > 1e-6 {
+			t.Errorf("edge %v is %f m from the center", edge, d)
+		}
+	}
+	if d := Distance(50.1, bbox.East(), 50.1, 8.1); d < 1000 {
+		t.Errorf("east edge grew by %f m, want at least 1000", d)
+	}
+
+	if got := NewBoundingBox(1, 179.999, 0, 179).Expand(1000); got.East() > -179 || got.West() > 179 {
+		t.Errorf("box expanded across the antimeridian = %v", got)
+	}
+	if got := NewBoundingBox(89.99, 10, 89, 0).Expand(5000); got != NewBoundingBox(90, 180, got.South(), -180) {
+		t.Errorf("box expanded over the pole = %v", got)
+	}
+}
+
+func TestBoundingBoxCenterArea(t *testing.T) {
+	if c := NewBoundingBox(10, -170, 0, 170).Center(); c != (Point{Lat: 5, Lon: 180}) {
+		t.Errorf("center across the antimeridian = %v", c)
+	}
+	if c := NewBoundingBox(10, 20, -10, 0).Center(); c != (Point{Lat: 0, Lon: 10}) {
+		t.Errorf("center = %v", c)
+	}
+
+	var sphere float64 = 4 * math.Pi * EARTH_RADIUS * EARTH_RADIUS
+	if a := NewBoundingBox(90, 180, -90, -180).AreaM2(); math.Abs(a-sphere) > sphere*1e-12 {
+		t.Errorf("area of the globe = %g, want %g", a, sphere)
+	}
+	if a, b := NewBoundingBox(10, -170, 0, 170).AreaM2(), NewBoundingBox(10, 20, 0, 0).AreaM2(); math.Abs(a-b) > 1e-3 {
+		t.Errorf("boxes of equal size have areas %g and %g", a, b)
+	}
+}
+
+func TestNewBoundingBoxAround(t *testing.T) {
+	var bbox BoundingBox = NewBoundingBoxAround(Point{Lat: 50, Lon: 8}, 2000)
+	for bearing := 0.0; bearing < 360; bearing += 15 {
+		var p Point = Destination(Point{Lat: 50, Lon: 8}, bearing, 1999)
+		if !bbox.Contains(p.Lat, p.Lon) {
+			t.Errorf("point %v at bearing %v outside %v", p, bearing, bbox)
+		}
+	}
+}
+
+func TestNewBoundingBoxChecked(t *testing.T) {
+	if bbox, err := NewBoundingBoxChecked(10, 20, 0, 5); err != nil || bbox != NewBoundingBox(10, 20, 0, 5) || bbox.CrossesAntimeridian() {
+		t.Errorf("NewBoundingBoxChecked = %v, %v", bbox, err)
+	}
+	if bbox, err := NewBoundingBoxCrossing(-15, -178, -20, 177); err != nil || !bbox.CrossesAntimeridian() || !bbox.Contains(-17, 179) {
+		t.Errorf("NewBoundingBoxCrossing = %v, %v", bbox, err)
+	}
+
+	for _, edges := range [][4]float64{
+		{0, 10, 10, 0},
+		{95, 10, 0, 0},
+		{10, 190, 0, 0},
+		{10, 10, 0, math.NaN()},
+		{10, -178, 0, 177},
+	} {
+		if _, err := NewBoundingBoxChecked(edges[0], edges[1], edges[2], edges[3]); !errors.Is(err, ErrInvalidBoundingBox) {
+			t.Errorf("NewBoundingBoxChecked%v returned %v, want ErrInvalidBoundingBox", edges, err)
+		}
+	}
+	if _, err := NewBoundingBoxCrossing(10, 20, 0, 5); !errors.Is(err, ErrInvalidBoundingBox) {
+		t.Errorf("NewBoundingBoxCrossing of a box not crossing returned %v", err)
+	}
+}
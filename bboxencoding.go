@@ -0,0 +1,68 @@
+package geomodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// boundingBoxJSON is the JSON representation of a BoundingBox.
+type boundingBoxJSON struct {
+	North *float64 `json:"north"`
+	East  *float64 `json:"east"`
+	South *float64 `json:"south"`
+	West  *float64 `json:"west"`
+}
+
+// MarshalJSON encodes bbox as an object with the members "north", "east",
+// "south" and "west", in degrees.
+func (bbox BoundingBox) MarshalJSON() ([]byte, error) {
+	return json.Marshal(boundingBoxJSON{&bbox.latNE, &bbox.lonNE, &bbox.latSW, &bbox.lonSW})
+}
+
+// UnmarshalJSON decodes the object MarshalJSON produces. All four members
+// are required.
+func (bbox *BoundingBox) UnmarshalJSON(data []byte) error {
+	var v boundingBoxJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.North == nil || v.East == nil || v.South == nil || v.West == nil {
+		return errors.New("geomodel: bounding box needs north, east, south and west")
+	}
+	*bbox = NewBoundingBox(*v.North, *v.East, *v.South, *v.West)
+	return nil
+}
+
+// MarshalText encodes bbox as "west,south,east,north" in degrees, the order
+// of GeoJSON bounding boxes, for use in configuration files and query
+// parameters.
+func (bbox BoundingBox) MarshalText() ([]byte, error) {
+	var text []byte
+	for i, v := range []float64{bbox.lonSW, bbox.latSW, bbox.lonNE, bbox.latNE} {
+		if i > 0 {
+			text = append(text, ',')
+		}
+		text = strconv.AppendFloat(text, v, 'f', -1, 64)
+	}
+	return text, nil
+}
+
+// UnmarshalText decodes the text MarshalText produces.
+func (bbox *BoundingBox) UnmarshalText(text []byte) error {
+	var parts []string = strings.Split(string(text), ",")
+	if len(parts) != 4 {
+		return fmt.Errorf("geomodel: bounding box %q is not west,south,east,north", text)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		var err error
+		if v[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64); err != nil {
+			return fmt.Errorf("geomodel: bounding box %q: %w", text, err)
+		}
+	}
+	*bbox = NewBoundingBox(v[3], v[2], v[1], v[0])
+	return nil
+}
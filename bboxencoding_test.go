@@ -0,0 +1,47 @@
+package geomodel
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBoundingBoxJSON(t *testing.T) {
+	var bbox = NewBoundingBox(52.6, 13.8, 52.3, 13.1)
+	data, err := json.Marshal(bbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"north":52.6,"east":13.8,"south":52.3,"west":13.1}`; string(data) != want {
+		t.Errorf("json.Marshal = %s, want %s", data, want)
+	}
+
+	var decoded BoundingBox
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != bbox {
+		t.Errorf("round trip = %+v, %v, want %+v", decoded, err, bbox)
+	}
+	if err := json.Unmarshal([]byte(`{"north":1,"east":2,"south":0}`), &decoded); err == nil {
+		t.Error("decoding a box without west succeeded")
+	}
+}
+
+func TestBoundingBoxText(t *testing.T) {
+	var area BoundingBox
+	// Text marshaling applies to map keys; plain fields use MarshalJSON.
+	var byArea = map[BoundingBox]int{NewBoundingBox(10, -170, 0, 170): 1}
+	data, err := json.Marshal(byArea)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"170,0,-170,10":1}`; string(data) != want {
+		t.Errorf("json.Marshal of a map = %s, want %s", data, want)
+	}
+
+	if err := area.UnmarshalText([]byte("13.1, 52.3, 13.8, 52.6")); err != nil || area != NewBoundingBox(52.6, 13.8, 52.3, 13.1) {
+		t.Errorf("UnmarshalText = %+v, %v", area, err)
+	}
+	for _, bad := range []string{"1,2,3", "a,b,c,d"} {
+		if err := area.UnmarshalText([]byte(bad)); err == nil {
+			t.Errorf("UnmarshalText(%q) succeeded", bad)
+		}
+	}
+}
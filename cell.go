@@ -0,0 +1,212 @@
+package geomodel
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidResolution is wrapped by the errors of GeoCellChecked and
+// GeoCellsChecked for resolutions below 1.
+var ErrInvalidResolution = errors.New("geomodel: invalid resolution")
+
+// Cell is a geocell, as returned by GeoCell, typed so that arbitrary strings
+// are not passed where cells are expected. Functions taking cells as
+// strings, such as ComputeBox, remain available; Cell values convert to
+// and from them freely.
+type Cell string
+
+// CellAt returns the cell of p at resolution.
+func CellAt(p Point, resolution int) Cell {
+	return Cell(GeoCell(p.Lat, p.Lon, resolution))
+}
+
+// ParseCell returns s as a Cell, or an error if it is empty or holds
+// characters outside GEOCELL_ALPHABET.
+func ParseCell(s string) (Cell, error) {
+	var c Cell = Cell(s)
+	if !c.Valid() {
+		return "", fmt.Errorf("geomodel: invalid geocell %q", s)
+	}
+	return c, nil
+}
+
+// GeoCellChecked is like GeoCell but returns an error wrapping
+// ErrInvalidPoint if (lat, lon) is out of range or NaN, as Point.Validate
+// does, or one wrapping ErrInvalidResolution if resolution is below 1.
+func GeoCellChecked(lat, lon float64, resolution int) (string, error) {
+	if err := checkCellArgs(lat, lon, resolution); err != nil {
+		return "", err
+	}
+	return GeoCell(lat, lon, resolution), nil
+}
+
+// GeoCellsChecked is like GeoCells but rejects its arguments as
+// GeoCellChecked does.
+func GeoCellsChecked(lat, lon float64, resolution int) ([]string, error) {
+	if err := checkCellArgs(lat, lon, resolution); err != nil {
+		return nil, err
+	}
+	return GeoCells(lat, lon, resolution), nil
+}
+
+func checkCellArgs(lat, lon float64, resolution int) error {
+	if err := (Point{lat, lon}).Validate(); err != nil {
+		return err
+	}
+	if resolution < 1 {
+		return fmt.Errorf("%w: %d is below 1", ErrInvalidResolution, resolution)
+	}
+	return nil
+}
+
+// DecodeCell returns the bounding box of cell, or an error if cell is not a
+// valid geocell. Decoding inverts GeoCell: for cells up to resolution
+// MAX_GEOCELL_RESOLUTION, the center of the box encodes back to cell at its
+// resolution, as FuzzCellRoundTrip checks.
+func DecodeCell(cell string) (BoundingBox, error) {
+	if _, err := ParseCell(cell); err != nil {
+		return BoundingBox{}, err
+	}
+	return ComputeBox(cell), nil
+}
+
+// Valid reports whether c is a non-empty string of GEOCELL_ALPHABET
+// characters.
+func (c Cell) Valid() bool {
+	if c == "" {
+		return false
+	}
+	for i := 0; i < len(c); i++ {
+		if strings.IndexByte(GEOCELL_ALPHABET, c[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns c as a string.
+func (c Cell) String() string { return string(c) }
+
+// Resolution returns the resolution of c, its length.
+func (c Cell) Resolution() int { return len(c) }
+
+// Parent returns the cell one resolution coarser holding c, or "" for a
+// top-level cell.
+func (c Cell) Parent() Cell {
+	if c == "" {
+		return ""
+	}
+	return c[:len(c)-1]
+}
+
+// Children returns the cells one resolution finer making up c, in alphabet
+// order.
+func (c Cell) Children() []Cell {
+	var children []Cell = make([]Cell, len(GEOCELL_ALPHABET))
+	for i := range children {
+		children[i] = c + Cell(GEOCELL_ALPHABET[i:i+1])
+	}
+	return children
+}
+
+// Box returns the bounding box of c, as ComputeBox does.
+func (c Cell) Box() BoundingBox { return ComputeBox(string(c)) }
+
+// Center returns the center of the box of c.
+func (c Cell) Center() Point { return c.Box().Center() }
+
+// Corners returns the corners of the box of c, as CellCorners does.
+func (c Cell) Corners() [4]Point { return CellCorners(string(c)) }
+
+// Contains reports whether p is encoded into c, which for points on the edge
+// between two cells holds for one of them only.
+func (c Cell) Contains(p Point) bool {
+	return c != "" && GeoCell(p.Lat, p.Lon, len(c)) == string(c)
+}
+
+// MarshalText implements encoding.TextMarshaler, so that cells encode as
+// plain strings in JSON and configuration files.
+func (c Cell) MarshalText() ([]byte, error) { return []byte(c), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler, rejecting invalid
+// cells as ParseCell does.
+func (c *Cell) UnmarshalText(text []byte) error {
+	var parsed, err = ParseCell(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// CellRange returns the range [start, end) of strings, in byte order, that
+// holds cell and every cell within it, so that ordered stores such as SQL
+// indexes or Bigtable can find the descendants of cell with a single range
+// scan, WHERE geocell >= start AND geocell < end, rather than listing them.
+// start is cell itself and end the next cell of the same resolution in
+// GEOCELL_ALPHABET order, which is byte order. The range is half-open, so
+// BETWEEN, which includes end, does not suit it. end is "" if cell is the
+// last cell of its resolution and the range has no upper bound; it is also
+// "" for the empty cell, whose range holds every cell.
+func CellRange(cell string) (start, end string) {
+	var buf []byte = []byte(cell)
+	for i := len(buf) - 1; i >= 0; i-- {
+		var index int = strings.IndexByte(GEOCELL_ALPHABET, buf[i])
+		if index+1 < len(GEOCELL_ALPHABET) {
+			buf[i] = GEOCELL_ALPHABET[index+1]
+			return cell, string(buf[:i+1])
+		}
+		// The last character carries into the one before it.
+	}
+	return cell, ""
+}
+
+// Range returns the range of c and its descendants, as CellRange does.
+func (c Cell) Range() (start, end Cell) {
+	s, e := CellRange(string(c))
+	return Cell(s), Cell(e)
+}
+
+// MatchCells returns the cells among candidates, of any mix of
+// resolutions, that contain (lat, lon), in the order of candidates. The
+// point is encoded once, at the finest resolution among candidates, and
+// matched against each candidate as a prefix, so that finding which service
+// areas given as cells cover an address costs a single encoding. Empty
+// candidates match nothing. For large, fixed candidate sets a CellTrie
+// avoids scanning every candidate.
+func MatchCells(lat, lon float64, candidateCells []string) []string {
+	var resolution int
+	for _, cell := range candidateCells {
+		resolution = max(resolution, len(cell))
+	}
+	var buf [MAX_GEOCELL_RESOLUTION]byte
+	var encoded []byte = AppendGeoCell(buf[:0], lat, lon, resolution)
+
+	var matches []string
+	for _, cell := range candidateCells {
+		if cell != "" && len(cell) <= len(encoded) && string(encoded[:len(cell)]) == cell {
+			matches = append(matches, cell)
+		}
+	}
+	return matches
+}
+
+// CellCorners returns the corners of the bounding box of cell, starting at the
+// south-west corner and running counter-clockwise, as needed to draw the cell
+// as a polygon in a grid overlay.
+func CellCorners(cell string) [4]Point {
+	var bbox BoundingBox = ComputeBox(cell)
+	return [4]Point{
+		{Lat: bbox.latSW, Lon: bbox.lonSW},
+		{Lat: bbox.latSW, Lon: bbox.lonNE},
+		{Lat: bbox.latNE, Lon: bbox.lonNE},
+		{Lat: bbox.latNE, Lon: bbox.lonSW},
+	}
+}
+
+// SnapToCellCenter returns the center of the cell of (lat, lon) at
+// resolution, so that all points of a cell map to the same coarse location.
+func SnapToCellCenter(lat, lon float64, resolution int) Point {
+	return ComputeBox(GeoCell(lat, lon, resolution)).Center()
+}
@@ -0,0 +1,242 @@
+package geomodel
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCell(t *testing.T) {
+	var c Cell = CellAt(Point{Lat: 53.12869, Lon: 8.18976}, 6)
+	if c != "u1my4r" || c.Resolution() != 6 || c.Parent() != "u1my4" || Cell("u").Parent() != "" {
+		t.Errorf("CellAt = %q with parent %q", c, c.Parent())
+	}
+	if c.Box() != ComputeBox("u1my4r") || !c.Box().Contains(c.Center().Lat, c.Center().Lon) {
+		t.Errorf("box %v and center %v", c.Box(), c.Center())
+	}
+	if !c.Contains(Point{Lat: 53.12869, Lon: 8.18976}) || c.Contains(Point{Lat: 50, Lon: 8}) {
+		t.Error("Contains misclassifies points")
+	}
+
+	var children []Cell = c.Parent().Children()
+	var found bool
+	for _, child := range children {
+		if child.Parent() != c.Parent() {
+			t.Errorf("child %q has parent %q", child, child.Parent())
+		}
+		found = found || child == c
+	}
+	if len(children) != 32 || !found {
+		t.Errorf("children of %q = %v", c.Parent(), children)
+	}
+
+	for _, s := range []string{"", "u1a", "U1", "u1 "} {
+		if _, err := ParseCell(s); err == nil {
+			t.Errorf("ParseCell(%q) succeeded", s)
+		}
+	}
+
+	var payload struct{ Cell Cell }
+	if err := json.Unmarshal([]byte(`{"Cell": "u1my"}`), &payload); err != nil || payload.Cell != "u1my" {
+		t.Errorf("decoded %q, %v", payload.Cell, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Cell": "ail"}`), &payload); err == nil {
+		t.Error("decoding an invalid cell succeeded")
+	}
+	if data, err := json.Marshal(payload); err != nil || string(data) != `{"Cell":"u1my"}` {
+		t.Errorf("encoded %s, %v", data, err)
+	}
+}
+
+func TestCellCorners(t *testing.T) {
+	var bbox BoundingBox = ComputeBox("u1my4r")
+	var corners [4]Point = Cell("u1my4r").Corners()
+	if corners != CellCorners("u1my4r") || corners[0] != (Point{bbox.South(), bbox.West()}) || corners[2] != (Point{bbox.North(), bbox.East()}) {
+		t.Errorf("corners %v of box %v", corners, bbox)
+	}
+
+	var center Point = SnapToCellCenter(53.12869, 8.18976, 6)
+	if center != Cell("u1my4r").Center() || SnapToCellCenter(center.Lat, center.Lon, 6) != center {
+		t.Errorf("SnapToCellCenter = %v, want %v", center, Cell("u1my4r").Center())
+	}
+	if other := SnapToCellCenter(bbox.South()+1e-9, bbox.West()+1e-9, 6); other != center {
+		t.Errorf("points of one cell snap to %v and %v", center, other)
+	}
+}
+
+func TestDecodeCell(t *testing.T) {
+	for _, cell := range []string{"", "u1m!", "aaa"} {
+		if _, err := DecodeCell(cell); err == nil {
+			t.Errorf("DecodeCell(%q) succeeded", cell)
+		}
+	}
+	var box, err = DecodeCell("u1my4r")
+	if err != nil || box != ComputeBox("u1my4r") {
+		t.Errorf("DecodeCell(u1my4r) = %v, %v, want %v", box, err, ComputeBox("u1my4r"))
+	}
+
+	// Every cell of the two coarsest resolutions round-trips.
+	var cells = []string{""}
+	for resolution := 1; resolution <= 2; resolution++ {
+		var next []string
+		for _, cell := range cells {
+			for i := 0; i < len(GEOCELL_ALPHABET); i++ {
+				next = append(next, cell+GEOCELL_ALPHABET[i:i+1])
+			}
+		}
+		cells = next
+		for _, cell := range cells {
+			checkRoundTrip(t, cell)
+		}
+	}
+}
+
+// checkRoundTrip checks that the center of cell encodes back to cell.
+func checkRoundTrip(t *testing.T, cell string) {
+	t.Helper()
+	box, err := DecodeCell(cell)
+	if err != nil {
+		t.Fatalf("DecodeCell(%q): %v", cell, err)
+	}
+	var center Point = box.Center()
+	if got := GeoCell(center.Lat, center.Lon, len(cell)); got != cell {
+		t.Fatalf("GeoCell(%v, %v, %d) = %q, want %q", center.Lat, center.Lon, len(cell), got, cell)
+	}
+}
+
+func FuzzCellRoundTrip(f *testing.F) {
+	f.Add(53.12869, 8.18976, 6)
+	f.Add(0.0, 0.0, 1)
+	f.Add(90.0, 180.0, MAX_GEOCELL_RESOLUTION)
+	f.Add(-90.0, -180.0, 3)
+	f.Fuzz(func(t *testing.T, lat, lon float64, resolution int) {
+		if !(lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180) {
+			t.Skip()
+		}
+		resolution = 1 + (resolution%MAX_GEOCELL_RESOLUTION+MAX_GEOCELL_RESOLUTION)%MAX_GEOCELL_RESOLUTION
+		var cell string = GeoCell(lat, lon, resolution)
+		var box BoundingBox = ComputeBox(cell)
+		if lon == 180 {
+			// Encoded as -180.
+			lon = -180
+		}
+		if lat < box.South() || lat > box.North() || lon < box.West() || lon > box.East() {
+			t.Fatalf("box %v of cell %q does not contain (%v, %v)", box, cell, lat, lon)
+		}
+		checkRoundTrip(t, cell)
+	})
+}
+
+func TestGeoCellChecked(t *testing.T) {
+	var cases = []struct {
+		lat, lon   float64
+		resolution int
+		err        error
+	}{
+		{50, 8, 6, nil},
+		{90, 180, 1, nil},
+		{91, 8, 6, ErrInvalidPoint},
+		{50, -180.5, 6, ErrInvalidPoint},
+		{math.NaN(), 8, 6, ErrInvalidPoint},
+		{50, math.NaN(), 6, ErrInvalidPoint},
+		{50, 8, 0, ErrInvalidResolution},
+		{50, 8, -1, ErrInvalidResolution},
+	}
+	for _, c := range cases {
+		cell, err := GeoCellChecked(c.lat, c.lon, c.resolution)
+		if !errors.Is(err, c.err) || (err == nil && cell != GeoCell(c.lat, c.lon, c.resolution)) {
+			t.Errorf("GeoCellChecked(%v, %v, %d) = %q, %v, want error %v", c.lat, c.lon, c.resolution, cell, err, c.err)
+		}
+		cells, err := GeoCellsChecked(c.lat, c.lon, c.resolution)
+		if !errors.Is(err, c.err) || (err == nil && len(cells) != c.resolution) {
+			t.Errorf("GeoCellsChecked(%v, %v, %d) = %v, %v, want error %v", c.lat, c.lon, c.resolution, cells, err, c.err)
+		}
+	}
+}
+
+func TestGeoCellClamps(t *testing.T) {
+	if got, want := GeoCell(120, 8, 6), GeoCell(90, 8, 6); got != want {
+		t.Errorf("GeoCell(120, 8) = %q, want %q", got, want)
+	}
+	if got, want := GeoCell(-95, 200, 6), GeoCell(-90, -160, 6); got != want {
+		t.Errorf("GeoCell(-95, 200) = %q, want %q", got, want)
+	}
+	for _, p := range [][2]float64{{math.NaN(), 8}, {50, math.NaN()}, {50, math.Inf(1)}} {
+		if got := GeoCell(p[0], p[1], 6); got != "" {
+			t.Errorf("GeoCell(%v, %v) = %q, want \"\"", p[0], p[1], got)
+		}
+		if got := GeoCells(p[0], p[1], 6); len(got) != 0 {
+			t.Errorf("GeoCells(%v, %v) = %v, want none", p[0], p[1], got)
+		}
+	}
+	if got := GeoCell(50, 8, 0); got != "" {
+		t.Errorf("GeoCell at resolution 0 = %q, want \"\"", got)
+	}
+}
+
+func TestGeoCellWrapsLongitude(t *testing.T) {
+	for _, lon := range []float64{0, 8, 179.9, 180, 190, 359.9, 360, 725, -190, -540} {
+		var want string = GeoCell(50, wrapLon(lon), 8)
+		if got := GeoCell(50, lon, 8); got != want {
+			t.Errorf("GeoCell(50, %v) = %q, want %q", lon, got, want)
+		}
+	}
+
+	var search = indexPlaces(randomPlaces(2000))
+	var want = ProximityFetch(50.5, 8.5, 5, 0, search, 10)
+	if got := ProximityFetch(50.5, 368.5, 5, 0, search, 10); !reflect.DeepEqual(got, want) {
+		t.Errorf("ProximityFetch at longitude 368.5 = %v, want %v", got, want)
+	}
+}
+
+func TestMatchCells(t *testing.T) {
+	var cell = GeoCell(53.12869, 8.18976, 9)
+	var candidates = []string{cell[:3], "s0", cell, "", cell[:5] + "0", cell[:1], GeoCell(-33.87, 151.21, 6)}
+	var got = MatchCells(53.12869, 8.18976, candidates)
+	if want := []string{cell[:3], cell, cell[:1]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MatchCells = %v, want %v", got, want)
+	}
+	if got := MatchCells(53.12869, 8.18976, nil); got != nil {
+		t.Errorf("MatchCells without candidates = %v", got)
+	}
+	if got := MatchCells(math.NaN(), 8, []string{"u"}); got != nil {
+		t.Errorf("MatchCells at NaN = %v", got)
+	}
+}
+
+func TestCellRange(t *testing.T) {
+	var cases = []struct{ cell, end string }{
+		{"u1m", "u1n"},
+		{"u19", "u1b"},
+		{"u1z", "u2"},
+		{"bzz", "c"},
+		{"zzz", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		start, end := CellRange(c.cell)
+		if start != c.cell || end != c.end {
+			t.Errorf("CellRange(%q) = %q, %q, want %q, %q", c.cell, start, end, c.cell, c.end)
+		}
+	}
+	if start, end := Cell("u1m").Range(); start != "u1m" || end != "u1n" {
+		t.Errorf("Cell.Range() = %q, %q", start, end)
+	}
+
+	// The range holds exactly the descendants among cells of other
+	// resolutions.
+	var cell = GeoCell(53.12869, 8.18976, 3)
+	start, end := CellRange(cell)
+	for _, p := range []Point{{53.12869, 8.18976}, {53.2, 8.1}, {52, 7}, {60, 10}, {-33.87, 151.21}} {
+		for resolution := 1; resolution <= 6; resolution++ {
+			var other string = GeoCell(p.Lat, p.Lon, resolution)
+			var inRange bool = other >= start && (end == "" || other < end)
+			if inRange != strings.HasPrefix(other, cell) {
+				t.Errorf("%q in range of %q: %v, want %v", other, cell, inRange, !inRange)
+			}
+		}
+	}
+}
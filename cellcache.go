@@ -0,0 +1,114 @@
+package geomodel
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// CellCache is a bounded cache of cell bounding boxes, evicting the least
+// recently used cell when full. Searches configured with WithCellCache look
+// boxes up in it instead of recomputing them, which pays off for servers
+// whose queries revisit the same cells, such as the cells around a city
+// center. A CellCache is safe for concurrent use and may be shared by any
+// number of searches, also of different curves.
+type CellCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[cellCacheKey]*list.Element
+	// Entries from the most to the least recently used.
+	order *list.List
+}
+
+// cellCacheKey identifies a cell of a curve.
+type cellCacheKey struct {
+	curve string
+	cell  string
+}
+
+// cellCacheEntry is an element of CellCache.order.
+type cellCacheEntry struct {
+	key cellCacheKey
+	box BoundingBox
+}
+
+// NewCellCache returns a cache holding the boxes of up to size cells; sizes
+// below 1 are taken as 1.
+func NewCellCache(size int) *CellCache {
+	return &CellCache{
+		size:    max(size, 1),
+		entries: make(map[cellCacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// WithCellCache makes a search take the bounding boxes of cells from cache,
+// computing and adding those missing.
+func WithCellCache(cache *CellCache) Option {
+	return func(o *searchOptions) {
+		o.cellCache = cache
+	}
+}
+
+// Box returns the bounding box of cell, as ComputeBox does.
+func (c *CellCache) Box(cell string) BoundingBox {
+	return c.box(curve.Geohash, cell)
+}
+
+// Center returns the center of the bounding box of cell.
+func (c *CellCache) Center(cell string) Point {
+	return c.box(curve.Geohash, cell).Center()
+}
+
+// Len returns the number of cells in the cache.
+func (c *CellCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// box returns the bounding box of cell of curve cv, from the cache if
+// present.
+func (c *CellCache) box(cv Curve, cell string) BoundingBox {
+	var key cellCacheKey = cellCacheKey{cv.Name(), cell}
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		var box BoundingBox = element.Value.(*cellCacheEntry).box
+		c.mu.Unlock()
+		return box
+	}
+	c.mu.Unlock()
+
+	// Computed outside the lock; concurrent misses of one cell compute it
+	// twice, which is harmless.
+	var box BoundingBox = computeBox(cv, cell)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return box
+	}
+	if c.order.Len() >= c.size {
+		// Reuse the least recently used entry.
+		var element *list.Element = c.order.Back()
+		var entry *cellCacheEntry = element.Value.(*cellCacheEntry)
+		delete(c.entries, entry.key)
+		entry.key, entry.box = key, box
+		c.entries[key] = element
+		c.order.MoveToFront(element)
+		return box
+	}
+	c.entries[key] = c.order.PushFront(&cellCacheEntry{key, box})
+	return box
+}
+
+// box returns the bounding box of cell of the search's curve, from the cell
+// cache if one is configured.
+func (o *searchOptions) box(cell string) BoundingBox {
+	if o.cellCache != nil {
+		return o.cellCache.box(o.curve, cell)
+	}
+	return computeBox(o.curve, cell)
+}
@@ -0,0 +1,70 @@
+package geomodel
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCellCache(t *testing.T) {
+	var cache = NewCellCache(2)
+	for _, cell := range []string{"u0", "u1", "u0", "u2"} {
+		if got, want := cache.Box(cell), ComputeBox(cell); got != want {
+			t.Errorf("Box(%q) = %v, want %v", cell, got, want)
+		}
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", cache.Len())
+	}
+	// u1 was the least recently used cell when u2 was added.
+	var key = func(cell string) cellCacheKey { return cellCacheKey{"geohash", cell} }
+	if _, ok := cache.entries[key("u1")]; ok {
+		t.Error("u1 not evicted")
+	}
+	for _, cell := range []string{"u0", "u2"} {
+		if _, ok := cache.entries[key(cell)]; !ok {
+			t.Errorf("%s evicted", cell)
+		}
+	}
+	if got, want := cache.Center("u0"), ComputeBox("u0").Center(); got != want {
+		t.Errorf("Center(u0) = %v, want %v", got, want)
+	}
+}
+
+func TestCellCacheConcurrent(t *testing.T) {
+	var cache = NewCellCache(16)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				var cell = GeoCell(50+float64(i%40)/40, 8+float64(g)/8, 6)
+				if got, want := cache.Box(cell), ComputeBox(cell); got != want {
+					t.Errorf("Box(%q) = %v, want %v", cell, got, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if cache.Len() > 16 {
+		t.Errorf("Len() = %d, want at most 16", cache.Len())
+	}
+}
+
+func TestProximityFetchWithCellCache(t *testing.T) {
+	var search = indexPlaces(randomPlaces(5000))
+	var cache = NewCellCache(64)
+	for i := 0; i < 5; i++ {
+		var lat, lon = 50.1 + float64(i)/10, 8.2 + float64(i)/10
+		var want = ProximityFetchResults(lat, lon, 10, 5000, search, 10)
+		var got = ProximityFetchResults(lat, lon, 10, 5000, search, 10, WithCellCache(cache))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("at (%v, %v): got %v with cell cache, want %v", lat, lon, got, want)
+		}
+	}
+	if cache.Len() == 0 {
+		t.Error("cell cache unused")
+	}
+}
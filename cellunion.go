@@ -0,0 +1,66 @@
+package geomodel
+
+import "iter"
+
+// CellUnion is a region made of cells of any mix of resolutions, such as the
+// precomputed covering of a country loaded with LoadCoverings. It
+// implements Region, so that a search is restricted to it with
+// WithinRegion(union). Points and boxes are tested against its cells in
+// time proportional to their resolution, however many cells it holds. A
+// CellUnion is not safe for concurrent use while cells are added.
+type CellUnion struct {
+	trie CellTrie
+}
+
+// NewCellUnion returns the union of cells.
+func NewCellUnion(cells ...string) *CellUnion {
+	var u *CellUnion = &CellUnion{}
+	for _, cell := range cells {
+		u.trie.Insert(cell)
+	}
+	return u
+}
+
+// Add adds cell to the union.
+func (u *CellUnion) Add(cell string) {
+	u.trie.Insert(cell)
+}
+
+// Len returns the number of cells in the union, counting cells lying
+// within other cells of it.
+func (u *CellUnion) Len() int {
+	return u.trie.Len()
+}
+
+// Cells returns the cells of the union in lexicographic order.
+func (u *CellUnion) Cells() iter.Seq[string] {
+	return u.trie.All()
+}
+
+// Contains reports whether (lat, lon) lies in a cell of the union.
+func (u *CellUnion) Contains(lat, lon float64) bool {
+	return u.trie.Covers(lat, lon)
+}
+
+// Intersects reports whether a cell of the union shares any point with
+// bbox.
+func (u *CellUnion) Intersects(bbox BoundingBox) bool {
+	return u.trie.root.intersectsBox(make([]byte, 0, MAX_GEOCELL_RESOLUTION), bbox)
+}
+
+// intersectsBox reports whether a cell of the subtree of n, the node of cell
+// prefix, intersects bbox, descending only into cells that do.
+func (n *cellTrieNode) intersectsBox(prefix []byte, bbox BoundingBox) bool {
+	if len(prefix) > 0 && !bbox.Intersects(ComputeBox(string(prefix))) {
+		return false
+	}
+	if n.present {
+		return true
+	}
+	for k, child := range n.children {
+		if child.intersectsBox(append(prefix, k), bbox) {
+			return true
+		}
+	}
+	return false
+}
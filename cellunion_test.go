@@ -0,0 +1,36 @@
+package geomodel
+
+import "testing"
+
+func TestCellUnion(t *testing.T) {
+	var cell = GeoCell(53.12869, 8.18976, 5)
+	var union = NewCellUnion(cell, "s0")
+	if !union.Contains(53.12869, 8.18976) || !union.Contains(1, 1) || union.Contains(53.5, 9) {
+		t.Error("Contains disagrees with the cells of the union")
+	}
+
+	var cases = []struct {
+		bbox BoundingBox
+		want bool
+	}{
+		{ComputeBox(cell).Expand(-10), true},
+		{NewBoundingBox(54, 9, 53, 8), true},
+		{NewBoundingBox(1, 1, 0.5, 0.5), true},
+		{NewBoundingBox(-10, -10, -20, -20), false},
+		{ComputeBox(GeoCell(53.5, 9, 5)), false},
+	}
+	for _, c := range cases {
+		if got := union.Intersects(c.bbox); got != c.want {
+			t.Errorf("Intersects(%v) = %v, want %v", c.bbox, got, c.want)
+		}
+	}
+
+	var places []LocationCapable
+	for _, p := range []Point{{53.12869, 8.18976}, {53.2, 8.3}, {1, 1}} {
+		places = append(places, Place{p.Lat, p.Lon, GeoCell(p.Lat, p.Lon, 8), GeoCells(p.Lat, p.Lon, 10)})
+	}
+	var results = ProximityFetch(53.13, 8.19, 10, 0, indexPlaces(places), 10, WithinRegion(union))
+	if len(results) != 2 {
+		t.Errorf("got %d results within the union, want 2", len(results))
+	}
+}
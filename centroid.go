@@ -0,0 +1,61 @@
+package geomodel
+
+import "math"
+
+// Centroid returns the spherical mean of the locations of entities: the
+// point on the sphere below the mean of their unit vectors. Unlike averaging
+// latitudes and longitudes it is correct for entities on both sides of the
+// antimeridian or around a pole, and serves to place a marker for a group of
+// entities. It returns the zero Point if entities is empty or their vectors
+// cancel out, as for two antipodal entities.
+func Centroid(entities []LocationCapable) Point {
+	return WeightedCentroid(entities, func(LocationCapable) float64 { return 1 })
+}
+
+// WeightedCentroid is like Centroid but weights each entity by weight, such
+// as the number of customers at a location. Entities with a weight of 0 or
+// less are ignored.
+func WeightedCentroid(entities []LocationCapable, weight func(entity LocationCapable) float64) Point {
+	var x, y, z float64
+	for _, entity := range entities {
+		var w float64 = weight(entity)
+		if w <= 0 {
+			continue
+		}
+		var sinLat, cosLat = math.Sincos(DegToRad(entity.Latitude()))
+		var sinLon, cosLon = math.Sincos(DegToRad(entity.Longitude()))
+		x += w * cosLat * cosLon
+		y += w * cosLat * sinLon
+		z += w * sinLat
+	}
+
+	var r float64 = math.Hypot(math.Hypot(x, y), z)
+	if r < 1e-12 {
+		return Point{}
+	}
+	return Point{Lat: RadToDeg(math.Asin(z / r)), Lon: wrapLon(RadToDeg(math.Atan2(y, x)))}
+}
+
+// Medoid returns the entity with the least total Distance to all others,
+// the member of the group best placed to serve it, such as a depot among
+// delivery addresses. Unlike Centroid it is always one of the entities. Of
+// entities with equal totals the first is returned, and nil if entities is
+// empty. It computes the distances between all pairs, so it suits the
+// results of a search rather than whole repositories.
+func Medoid(entities []LocationCapable) LocationCapable {
+	var best LocationCapable
+	var bestTotal float64 = math.Inf(1)
+	for _, candidate := range entities {
+		var total float64
+		for _, other := range entities {
+			total += Distance(candidate.Latitude(), candidate.Longitude(), other.Latitude(), other.Longitude())
+			if total >= bestTotal {
+				break
+			}
+		}
+		if total < bestTotal {
+			best, bestTotal = candidate, total
+		}
+	}
+	return best
+}
@@ -0,0 +1,56 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCentroid(t *testing.T) {
+	var cases = []struct {
+		places []LocationCapable
+		want   Point
+	}{
+		{[]LocationCapable{Place{lat: 10, lon: 20}}, Point{10, 20}},
+		{[]LocationCapable{Place{lat: 0, lon: 10}, Place{lat: 0, lon: 20}}, Point{0, 15}},
+		// Across the antimeridian, where averaging longitudes gives 0.
+		{[]LocationCapable{Place{lat: 0, lon: 170}, Place{lat: 0, lon: -170}}, Point{0, -180}},
+		{[]LocationCapable{Place{lat: 80, lon: 0}, Place{lat: 80, lon: 180}}, Point{90, 0}},
+		{[]LocationCapable{Place{lat: 0, lon: 0}, Place{lat: 0, lon: 180}}, Point{}},
+		{nil, Point{}},
+	}
+	for _, c := range cases {
+		var got = Centroid(c.places)
+		if math.Abs(got.Lat-c.want.Lat) > 1e-9 || (math.Abs(got.Lat) < 90-1e-9 && math.Abs(wrapLon(got.Lon-c.want.Lon)) > 1e-9) {
+			t.Errorf("Centroid(%v) = %v, want %v", c.places, got, c.want)
+		}
+	}
+
+	var places = []LocationCapable{Place{lat: 0, lon: 0, key: "light"}, Place{lat: 0, lon: 10, key: "heavy"}}
+	var got = WeightedCentroid(places, func(e LocationCapable) float64 {
+		if e.Key() == "heavy" {
+			return 1000
+		}
+		return 0
+	})
+	if math.Abs(got.Lon-10) > 1e-9 {
+		t.Errorf("WeightedCentroid = %v, want the heavy place at longitude 10", got)
+	}
+}
+
+func TestMedoid(t *testing.T) {
+	if Medoid(nil) != nil {
+		t.Error("Medoid(nil) != nil")
+	}
+	var places = []LocationCapable{
+		Place{50, 8, "west", nil},
+		Place{50, 8.1, "middle", nil},
+		Place{50, 8.12, "east", nil},
+		Place{50, 8.3, "far", nil},
+	}
+	if got := Medoid(places); got.Key() != "middle" {
+		t.Errorf("Medoid = %v, want middle", got.Key())
+	}
+	if got := Medoid(places[:1]); got.Key() != "west" {
+		t.Errorf("Medoid of one = %v, want west", got.Key())
+	}
+}
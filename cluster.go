@@ -0,0 +1,55 @@
+package geomodel
+
+import (
+	"math"
+	"sort"
+)
+
+// Cluster is a group of entities sharing a geocell, as returned by
+// ClusterEntities, to be shown as a single pin on a map.
+type Cluster struct {
+	// Cell is the geocell shared by the entities.
+	Cell string
+	// Count is the number of entities in the cluster.
+	Count int
+	// Centroid is the mean location of the entities.
+	Centroid Point
+	// Bounds is the smallest box holding the entities, within the box of
+	// Cell.
+	Bounds BoundingBox
+	// Entities are the members of the cluster, in input order.
+	Entities []LocationCapable
+}
+
+// ClusterEntities groups entities by their geocell at resolution, computed
+// from their location, and returns one Cluster per occupied cell, ordered by
+// cell. Coarser resolutions give fewer, larger clusters; ResolutionForZoom
+// picks one for a map's zoom level.
+func ClusterEntities(entities []LocationCapable, resolution int) []Cluster {
+	var byCell map[string]*Cluster = make(map[string]*Cluster)
+	for _, entity := range entities {
+		var lat, lon float64 = entity.Latitude(), entity.Longitude()
+		var cell string = GeoCell(lat, lon, resolution)
+		var cluster *Cluster = byCell[cell]
+		if cluster == nil {
+			cluster = &Cluster{Cell: cell, Bounds: NewBoundingBox(lat, lon, lat, lon)}
+			byCell[cell] = cluster
+		}
+		cluster.Count++
+		cluster.Centroid.Lat += lat
+		cluster.Centroid.Lon += lon
+		cluster.Entities = append(cluster.Entities, entity)
+		cluster.Bounds.latNE, cluster.Bounds.lonNE = math.Max(cluster.Bounds.latNE, lat), math.Max(cluster.Bounds.lonNE, lon)
+		cluster.Bounds.latSW, cluster.Bounds.lonSW = math.Min(cluster.Bounds.latSW, lat), math.Min(cluster.Bounds.lonSW, lon)
+	}
+
+	var clusters []Cluster = make([]Cluster, 0, len(byCell))
+	for _, cluster := range byCell {
+		// Cells do not cross the antimeridian, so longitudes average plainly.
+		cluster.Centroid.Lat /= float64(cluster.Count)
+		cluster.Centroid.Lon /= float64(cluster.Count)
+		clusters = append(clusters, *cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Cell < clusters[j].Cell })
+	return clusters
+}
@@ -0,0 +1,40 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClusterEntities(t *testing.T) {
+	var entities = []LocationCapable{
+		Place{50.01, 8.01, "a", nil},
+		Place{50.03, 8.05, "b", nil},
+		Place{50.02, 8.03, "c", nil},
+		Place{-33.87, 151.21, "d", nil},
+	}
+	var clusters []Cluster = ClusterEntities(entities, 4)
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2", len(clusters))
+	}
+
+	var frankfurt Cluster = clusters[1]
+	if frankfurt.Cell != GeoCell(50.01, 8.01, 4) || frankfurt.Count != 3 || len(frankfurt.Entities) != 3 || frankfurt.Entities[1].Key() != "b" {
+		t.Errorf("unexpected cluster %+v", frankfurt)
+	}
+	if math.Abs(frankfurt.Centroid.Lat-50.02) > 1e-9 || math.Abs(frankfurt.Centroid.Lon-8.03) > 1e-9 {
+		t.Errorf("centroid %v, want (50.02, 8.03)", frankfurt.Centroid)
+	}
+	if frankfurt.Bounds != NewBoundingBox(50.03, 8.05, 50.01, 8.01) {
+		t.Errorf("bounds %v", frankfurt.Bounds)
+	}
+	if clusters[0].Count != 1 || clusters[0].Centroid != (Point{Lat: -33.87, Lon: 151.21}) {
+		t.Errorf("unexpected cluster %+v", clusters[0])
+	}
+
+	if clusters := ClusterEntities(entities, 1); len(clusters) != 2 {
+		t.Errorf("got %d top-level clusters, want 2", len(clusters))
+	}
+	if clusters := ClusterEntities(nil, 4); len(clusters) != 0 {
+		t.Errorf("got %d clusters of nothing", len(clusters))
+	}
+}
@@ -0,0 +1,178 @@
+// Command geocell encodes, decodes and covers geocells, for debugging
+// indexes and for shell pipelines:
+//
+//	geocell encode [-resolution 13] lat lon
+//	geocell decode [-geojson] cell...
+//	geocell neighbors [-geojson] cell
+//	geocell children [-geojson] cell
+//	geocell cover [-resolution 13] [-text] (-circle lat,lon,meters | -bbox west,south,east,north)
+//
+// encode prints the cell of a point. decode prints, for each cell, a
+// tab-separated line of the cell, its center latitude and longitude and its
+// south, west, north and east edges. neighbors prints the eight cells around
+// a cell, starting north and going clockwise, skipping those past a pole;
+// children prints the 32 cells one resolution finer. cover prints the cells
+// a search of the circle or box covers, as geomodel.CoverCircle and
+// geomodel.CoverBoundingBox compute them, as a GeoJSON FeatureCollection or,
+// with -text, one per line. With -geojson, the other commands print their
+// cells as a GeoJSON FeatureCollection too. Negative coordinates must follow
+// "--", lest they be taken for flags.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alternaDev/geomodel"
+)
+
+// errUsage is returned for invalid command lines, after printing the usage.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "geocell:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// run runs the command line args, writing results to stdout and usage to
+// stderr.
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: geocell encode|decode|neighbors|children|cover [flags] args...")
+		return errUsage
+	}
+	var flags *flag.FlagSet = flag.NewFlagSet("geocell "+args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var resolution *int = flags.Int("resolution", geomodel.MAX_GEOCELL_RESOLUTION, "finest `resolution` of cells")
+	var asGeoJSON *bool = flags.Bool("geojson", false, "print cells as a GeoJSON FeatureCollection")
+	var asText *bool = flags.Bool("text", false, "print the covering one cell per line")
+	var circle *string = flags.String("circle", "", "circle to cover, as `lat,lon,meters`")
+	var bbox *string = flags.String("bbox", "", "box to cover, as `west,south,east,north`")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if *resolution < 1 || *resolution > geomodel.MAX_GEOCELL_RESOLUTION {
+		return fmt.Errorf("resolution must be between 1 and %d", geomodel.MAX_GEOCELL_RESOLUTION)
+	}
+
+	var cells []string
+	switch args[0] {
+	case "encode":
+		if flags.NArg() != 2 {
+			return usage(flags, "lat lon")
+		}
+		var p, err = parsePoint(flags.Arg(0), flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, geomodel.GeoCell(p.Lat, p.Lon, *resolution))
+		return err
+	case "decode":
+		if flags.NArg() == 0 {
+			return usage(flags, "cell...")
+		}
+		for _, arg := range flags.Args() {
+			var cell, err = geomodel.ParseCell(arg)
+			if err != nil {
+				return err
+			}
+			cells = append(cells, string(cell))
+		}
+		if !*asGeoJSON {
+			for _, cell := range cells {
+				var box geomodel.BoundingBox = geomodel.ComputeBox(cell)
+				var center geomodel.Point = box.Center()
+				fmt.Fprintf(stdout, "%s\t%v\t%v\t%v\t%v\t%v\t%v\n", cell, center.Lat, center.Lon, box.South(), box.West(), box.North(), box.East())
+			}
+			return nil
+		}
+	case "neighbors", "children":
+		if flags.NArg() != 1 {
+			return usage(flags, "cell")
+		}
+		var cell, err = geomodel.ParseCell(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		if args[0] == "children" {
+			for _, child := range cell.Children() {
+				cells = append(cells, string(child))
+			}
+		} else {
+			for _, dir := range [][]int{{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}} {
+				if neighbor := geomodel.Adjacent(string(cell), dir); neighbor != "" {
+					cells = append(cells, neighbor)
+				}
+			}
+		}
+	case "cover":
+		if flags.NArg() != 0 || (*circle == "") == (*bbox == "") {
+			return usage(flags, "-circle lat,lon,meters | -bbox west,south,east,north")
+		}
+		if *circle != "" {
+			var parts []string = strings.Split(*circle, ",")
+			if len(parts) != 3 {
+				return fmt.Errorf("circle %q is not lat,lon,meters", *circle)
+			}
+			var center, err = parsePoint(parts[0], parts[1])
+			if err != nil {
+				return err
+			}
+			var radius float64
+			if radius, err = strconv.ParseFloat(parts[2], 64); err != nil || !(radius > 0) {
+				return fmt.Errorf("invalid radius %q", parts[2])
+			}
+			cells = geomodel.CoverCircle(center, radius, *resolution)
+		} else {
+			var box geomodel.BoundingBox
+			if err := box.UnmarshalText([]byte(*bbox)); err != nil {
+				return err
+			}
+			cells = geomodel.CoverBoundingBox(box, *resolution)
+		}
+		*asGeoJSON = !*asText
+	default:
+		fmt.Fprintf(stderr, "geocell: unknown command %q\n", args[0])
+		return errUsage
+	}
+
+	if *asGeoJSON {
+		var data, err = geomodel.CellsToFeatureCollection(cells)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(stdout, "%s\n", data)
+		return err
+	}
+	_, err := fmt.Fprintln(stdout, strings.Join(cells, "\n"))
+	return err
+}
+
+// usage prints the usage of the command of flags, with its arguments.
+func usage(flags *flag.FlagSet, arguments string) error {
+	fmt.Fprintf(flags.Output(), "usage: %s [flags] %s\n", flags.Name(), arguments)
+	flags.PrintDefaults()
+	return errUsage
+}
+
+// parsePoint parses and validates a point given in degrees.
+func parsePoint(lat, lon string) (geomodel.Point, error) {
+	var p geomodel.Point
+	var err error
+	if p.Lat, err = strconv.ParseFloat(lat, 64); err != nil {
+		return p, fmt.Errorf("invalid latitude %q", lat)
+	}
+	if p.Lon, err = strconv.ParseFloat(lon, 64); err != nil {
+		return p, fmt.Errorf("invalid longitude %q", lon)
+	}
+	return p, p.Validate()
+}
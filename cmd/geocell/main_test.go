@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func runArgs(t *testing.T, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	var err = run(args, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestRun(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"encode", "-resolution", "6", "53.12869", "8.18976"}, "u1my4r\n"},
+		{[]string{"encode", "-resolution", "4", "--", "-33.87", "151.21"}, "r3gx\n"},
+		{[]string{"decode", "s"}, "s\t22.5\t22.5\t0\t0\t45\t45\n"},
+		{[]string{"neighbors", "s"}, "u\nv\nt\nm\nk\n7\ne\ng\n"},
+	} {
+		var got, err = runArgs(t, c.args...)
+		if err != nil || got != c.want {
+			t.Errorf("geocell %v = %q, %v, want %q", c.args, got, err, c.want)
+		}
+	}
+
+	if got, err := runArgs(t, "children", "u1"); err != nil || len(strings.Fields(got)) != 32 || !strings.HasPrefix(got, "u10\n") {
+		t.Errorf("children = %q, %v", got, err)
+	}
+	// No neighbors across the pole.
+	if got, _ := runArgs(t, "neighbors", "b"); len(strings.Fields(got)) != 5 {
+		t.Errorf("neighbors of a polar cell = %q", got)
+	}
+
+	for _, args := range [][]string{
+		{"cover", "-resolution", "6", "-circle", "53.08,8.80,1000"},
+		{"decode", "-geojson", "u1", "u2"},
+		{"cover", "-bbox", "179,-1,-179,1"},
+	} {
+		var got, err = runArgs(t, args...)
+		var collection struct {
+			Type     string
+			Features []json.RawMessage
+		}
+		if err != nil || json.Unmarshal([]byte(got), &collection) != nil || collection.Type != "FeatureCollection" || len(collection.Features) == 0 {
+			t.Errorf("geocell %v = %q, %v", args, got, err)
+		}
+	}
+	if got, err := runArgs(t, "cover", "-text", "-resolution", "2", "-bbox", "0,0,10,10"); err != nil || strings.Contains(got, "{") {
+		t.Errorf("cover -text = %q, %v", got, err)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"frobnicate"},
+		{"encode", "91", "0"},
+		{"encode", "1"},
+		{"decode", "u1a"},
+		{"cover", "-circle", "53,8"},
+		{"cover", "-circle", "53,8,1", "-bbox", "0,0,1,1"},
+		{"encode", "-resolution", "0", "1", "2"},
+	} {
+		if _, err := runArgs(t, args...); err == nil {
+			t.Errorf("geocell %v succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package geomodel
+
+import (
+	"iter"
+	"math"
+	"slices"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// maxCoveringCells bounds the size of coverings computed to drive a query.
+const maxCoveringCells = 64
+
+// wrapLon maps lon into [-180, 180).
+func wrapLon(lon float64) float64 {
+	return curve.WrapLon(lon)
+}
+
+// gridRange returns the rows and columns of the resolution's cell grid that
+// intersect bbox. Columns may extend past the eastern edge of the grid when
+// the box crosses the antimeridian.
+func gridRange(c Curve, bbox BoundingBox, resolution int) (int, int, int, int) {
+	var latSpan, lonSpan = c.Span(resolution)
+	var east float64 = bbox.lonNE
+	if east < bbox.lonSW {
+		east += 360
+	}
+
+	var latCount int = int(math.Round(180 / latSpan))
+	var lonCount int = int(math.Round(360 / lonSpan))
+
+	var firstRow int = int(math.Floor((bbox.latSW + 90) / latSpan))
+	var lastRow int = int(math.Min(math.Floor((bbox.latNE+90)/latSpan), float64(latCount-1)))
+	var firstCol int = int(math.Floor((bbox.lonSW + 180) / lonSpan))
+	var lastCol int = int(math.Floor((east + 180) / lonSpan))
+	if lastCol-firstCol >= lonCount {
+		firstCol, lastCol = 0, lonCount-1
+	}
+	return firstRow, lastRow, firstCol, lastCol
+}
+
+// cellsInBox returns every cell at resolution intersecting bbox. A box whose
+// east edge lies west of its west edge is taken to cross the antimeridian.
+func cellsInBox(c Curve, bbox BoundingBox, resolution int) []string {
+	var firstRow, lastRow, firstCol, lastCol = gridRange(c, bbox, resolution)
+	var cells []string = make([]string, 0, max(lastRow-firstRow+1, 0)*(lastCol-firstCol+1))
+	return slices.AppendSeq(cells, iterateCells(c, bbox, resolution))
+}
+
+// IterateCells yields every cell at resolution intersecting bbox, row by
+// row from the south-west, encoding each only when it is reached. Batch jobs
+// walking a whole country at a fine resolution thus never hold millions of
+// cells at once, and can stop early by breaking out of the loop:
+//
+//	for cell := range IterateCells(bbox, 8) {
+//		process(cell)
+//	}
+//
+// A box whose east edge lies west of its west edge is taken to cross the
+// antimeridian. Each cell is yielded once.
+func IterateCells(bbox BoundingBox, resolution int) iter.Seq[string] {
+	return iterateCells(curve.Geohash, bbox, resolution)
+}
+
+// iterateCells implements IterateCells for the curve c. The cells are
+// distinct since gridRange never spans more columns than the grid has.
+func iterateCells(c Curve, bbox BoundingBox, resolution int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		var latSpan, lonSpan = c.Span(resolution)
+		var firstRow, lastRow, firstCol, lastCol = gridRange(c, bbox, resolution)
+		for row := firstRow; row <= lastRow; row++ {
+			var lat float64 = -90 + (float64(row)+0.5)*latSpan
+			for col := firstCol; col <= lastCol; col++ {
+				var lon float64 = wrapLon(-180 + (float64(col)+0.5)*lonSpan)
+				if !yield(c.Encode(lat, lon, resolution)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// coverBox returns the cells of the finest resolution, up to maxResolution,
+// covering bbox with at most maxCoveringCells cells.
+func coverBox(c Curve, bbox BoundingBox, maxResolution int) []string {
+	var resolution int = 1
+	for resolution < maxResolution {
+		var firstRow, lastRow, firstCol, lastCol = gridRange(c, bbox, resolution+1)
+		if (lastRow-firstRow+1)*(lastCol-firstCol+1) > maxCoveringCells {
+			break
+		}
+		resolution++
+	}
+	return cellsInBox(c, bbox, resolution)
+}
+
+// circleBox returns a box enclosing every point within radius meters of
+// (lat, lon).
+func circleBox(lat, lon, radius float64) BoundingBox {
+	var dLat float64 = radius / EARTH_RADIUS * 180 / math.Pi
+	var north float64 = math.Min(lat+dLat, 90)
+	var south float64 = math.Max(lat-dLat, -90)
+	if north == 90 || south == -90 {
+		return NewBoundingBox(north, 180, south, -180)
+	}
+
+	var maxAbsLat float64 = math.Max(math.Abs(north), math.Abs(south))
+	var dLon float64 = dLat / math.Cos(DegToRad(maxAbsLat))
+	if dLon >= 180 {
+		return NewBoundingBox(north, 180, south, -180)
+	}
+	return NewBoundingBox(north, wrapLon(lon+dLon), south, wrapLon(lon-dLon))
+}
+
+// coverCircle returns the cells at resolution that come within radius meters
+// of (lat, lon).
+func coverCircle(c Curve, lat, lon, radius float64, resolution int) []string {
+	var candidates []string = cellsInBox(c, circleBox(lat, lon, radius), resolution)
+	var cells []string = candidates[:0]
+	for _, cell := range candidates {
+		if distanceToBox(lat, lon, computeBox(c, cell)) <= radius {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+// coverCircleBounded returns the covering of the circle at the finest
+// resolution, up to maxResolution, needing at most maxCoveringCells cells.
+func coverCircleBounded(c Curve, lat, lon, radius float64, maxResolution int) []string {
+	var cells []string
+	for resolution := 1; resolution <= maxResolution; resolution++ {
+		var covering []string = coverCircle(c, lat, lon, radius, resolution)
+		if cells != nil && len(covering) > maxCoveringCells {
+			break
+		}
+		cells = covering
+	}
+	return cells
+}
+
+// distanceToBox returns the great-circle distance in meters from (lat, lon)
+// to the nearest point of bbox, or 0 if the point lies inside it.
+func distanceToBox(lat, lon float64, bbox BoundingBox) float64 {
+	var width float64 = bbox.lonNE - bbox.lonSW
+	if width < 0 {
+		width += 360
+	}
+	var offset float64 = math.Mod(lon-bbox.lonSW+360, 360)
+	if offset <= width {
+		// Within the box's longitudes the nearest point lies on the same
+		// meridian.
+		var nearestLat float64 = math.Max(bbox.latSW, math.Min(lat, bbox.latNE))
+		if nearestLat == lat {
+			return 0
+		}
+		return Distance(lat, lon, nearestLat, lon)
+	}
+
+	// Otherwise it lies on one of the bounding meridians, corners included.
+	return math.Min(distanceToMeridian(lat, lon, bbox.lonSW, bbox.latSW, bbox.latNE),
+		distanceToMeridian(lat, lon, bbox.lonNE, bbox.latSW, bbox.latNE))
+}
+
+// distanceToMeridian returns the distance in meters from (lat, lon) to the
+// nearest point of the meridian at meridianLon between latitudes south and
+// north.
+func distanceToMeridian(lat, lon, meridianLon, south, north float64) float64 {
+	// The cosine of the angular distance to the meridian point at latitude t
+	// is sin(lat) sin(t) + cos(lat) cos(t) cos(dLon), which peaks at
+	// t = atan2(sin(lat), cos(lat) cos(dLon)) and falls off on either side.
+	var phi float64 = DegToRad(lat)
+	var peak float64 = RadToDeg(math.Atan2(math.Sin(phi), math.Cos(phi)*math.Cos(DegToRad(lon-meridianLon))))
+	var d float64 = math.Min(Distance(lat, lon, south, meridianLon), Distance(lat, lon, north, meridianLon))
+	if peak > south && peak < north {
+		d = math.Min(d, Distance(lat, lon, peak, meridianLon))
+	}
+	return d
+}
@@ -0,0 +1,49 @@
+package geomodel
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIterateCells(t *testing.T) {
+	var boxes = []BoundingBox{
+		NewBoundingBox(50.2, 8.3, 50, 8),
+		// Across the antimeridian.
+		{-16, -179, -18, 177},
+		NewBoundingBox(90, 180, -90, -180),
+	}
+	for _, bbox := range boxes {
+		for resolution := 1; resolution <= 4; resolution++ {
+			var cells []string = slices.Collect(IterateCells(bbox, resolution))
+			var seen = make(map[string]bool)
+			for _, cell := range cells {
+				if seen[cell] {
+					t.Fatalf("IterateCells(%v, %d) yielded %q twice", bbox, resolution, cell)
+				}
+				seen[cell] = true
+				if !bbox.Intersects(ComputeBox(cell)) {
+					t.Errorf("IterateCells(%v, %d) yielded %q outside the box", bbox, resolution, cell)
+				}
+			}
+			// Every cell intersecting the box is yielded.
+			for _, p := range []Point{{bbox.South(), bbox.West()}, {bbox.North(), bbox.East()}, bbox.Center()} {
+				if cell := GeoCell(p.Lat, p.Lon, resolution); !seen[cell] {
+					t.Errorf("IterateCells(%v, %d) missed %q at %v", bbox, resolution, cell, p)
+				}
+			}
+		}
+	}
+	if n := len(slices.Collect(IterateCells(NewBoundingBox(90, 180, -90, -180), 2))); n != 32*32 {
+		t.Errorf("IterateCells over the globe yielded %d cells, want %d", n, 32*32)
+	}
+
+	var n int
+	for range IterateCells(NewBoundingBox(90, 180, -90, -180), 6) {
+		if n++; n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("stopped after %d cells, want 10", n)
+	}
+}
@@ -0,0 +1,105 @@
+package geomodel
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// LoadCoverings reads precomputed coverings of regions, such as countries
+// or delivery areas, keyed by region code. The format is line-based text, so
+// that coverings are easily generated, reviewed and diffed: each line holds
+// a region code followed by cells separated by spaces, a region may span
+// several lines, and blank lines and lines starting with # are ignored:
+//
+//	# Coverings at resolution 4 and coarser.
+//	LU u0u6 u0u7 u0uk u0um
+//	LU u0us
+//
+// Restricting a search to a region is then a one-liner:
+//
+//	ProximityFetch(lat, lon, 10, 0, search, 13, WithinRegion(coverings["LU"]))
+//
+// Cells are validated as by ParseCell; the error names the offending line.
+func LoadCoverings(r io.Reader) (map[string]*CellUnion, error) {
+	var coverings map[string]*CellUnion = make(map[string]*CellUnion)
+	var scanner *bufio.Scanner = bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var fields []string = strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var union *CellUnion = coverings[fields[0]]
+		if union == nil {
+			union = NewCellUnion()
+			coverings[fields[0]] = union
+		}
+		for _, cell := range fields[1:] {
+			if _, err := ParseCell(cell); err != nil {
+				return nil, fmt.Errorf("geomodel: coverings line %d: %w", line, err)
+			}
+			union.Add(cell)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return coverings, nil
+}
+
+// LoadCoveringsFS reads the coverings file name from fsys, as LoadCoverings
+// does. Coverings shipped with a program are embedded with go:embed:
+//
+//	//go:embed coverings.txt
+//	var coveringsFS embed.FS
+//
+//	coverings, err := geomodel.LoadCoveringsFS(coveringsFS, "coverings.txt")
+//
+// and files on disk are read with os.DirFS.
+func LoadCoveringsFS(fsys fs.FS, name string) (map[string]*CellUnion, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadCoverings(f)
+}
+
+// coveringsLineCells bounds the cells written per line by WriteCoverings.
+const coveringsLineCells = 16
+
+// WriteCoverings writes coverings in the format read by LoadCoverings,
+// ordered by region code.
+func WriteCoverings(w io.Writer, coverings map[string]*CellUnion) error {
+	var bw *bufio.Writer = bufio.NewWriter(w)
+	var codes []string = make([]string, 0, len(coverings))
+	for code := range coverings {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	for _, code := range codes {
+		var n int
+		for cell := range coverings[code].Cells() {
+			if n%coveringsLineCells == 0 {
+				if n > 0 {
+					bw.WriteByte('\n')
+				}
+				bw.WriteString(code)
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(cell)
+			n++
+		}
+		if n == 0 {
+			// An empty covering, kept as a line of its own.
+			bw.WriteString(code)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,47 @@
+package geomodel
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadCoverings(t *testing.T) {
+	var input = `# Test coverings.
+LU u0u6 u0u7
+DE u1 u0z
+
+LU u0uk
+EMPTY
+`
+	var fsys = fstest.MapFS{"coverings.txt": {Data: []byte(input)}}
+	coverings, err := LoadCoveringsFS(fsys, "coverings.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Collect(coverings["LU"].Cells()); !slices.Equal(got, []string{"u0u6", "u0u7", "u0uk"}) {
+		t.Errorf("LU = %v", got)
+	}
+	if coverings["DE"].Len() != 2 || coverings["EMPTY"].Len() != 0 {
+		t.Errorf("DE has %d cells, EMPTY %d", coverings["DE"].Len(), coverings["EMPTY"].Len())
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCoverings(&buf, coverings); err != nil {
+		t.Fatal(err)
+	}
+	if want := "DE u0z u1\nEMPTY\nLU u0u6 u0u7 u0uk\n"; buf.String() != want {
+		t.Errorf("WriteCoverings wrote %q, want %q", buf.String(), want)
+	}
+
+	if _, err := LoadCoverings(strings.NewReader("DE u1\nFR u0a\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("LoadCoverings with an invalid cell returned %v, want an error at line 2", err)
+	}
+	if _, err := LoadCoveringsFS(fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadCoveringsFS of a missing file returned %v", err)
+	}
+}
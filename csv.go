@@ -0,0 +1,101 @@
+package geomodel
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// ErrInvalidColumn is wrapped by the error LoadCSV yields for a negative
+// column index.
+var ErrInvalidColumn = errors.New("geomodel: invalid CSV column")
+
+// CSVRecord is a row read by LoadCSV: an IndexRecord carrying all fields of
+// the row.
+type CSVRecord struct {
+	IndexRecord
+	Fields []string
+}
+
+// Relocate implements Relocatable, keeping the fields.
+func (r *CSVRecord) Relocate(lat, lon float64, geocells []string) LocationCapable {
+	return &CSVRecord{IndexRecord{r.ID, lat, lon, geocells}, r.Fields}
+}
+
+// LoadCSV streams the rows of CSV data from r as records keyed by column
+// keyCol and located by columns latCol and lonCol, in decimal degrees, with
+// geocells computed up to resolution. Columns count from 0. Rows are read
+// as the sequence is consumed, so files of any size are loaded in constant
+// memory. A first row whose coordinates do not parse is taken for a header
+// and skipped; any later unparsable row yields an error naming its line and
+// ends the sequence. A negative column yields an error wrapping
+// ErrInvalidColumn before any row is read.
+func LoadCSV(r io.Reader, latCol, lonCol, keyCol int, resolution int) iter.Seq2[*CSVRecord, error] {
+	return func(yield func(*CSVRecord, error) bool) {
+		if col := min(latCol, lonCol, keyCol); col < 0 {
+			yield(nil, fmt.Errorf("%w: %d", ErrInvalidColumn, col))
+			return
+		}
+		var reader *csv.Reader = csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		for first := true; ; first = false {
+			var fields, err = reader.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			var record *CSVRecord
+			record, err = parseCSVRecord(fields, latCol, lonCol, keyCol, resolution)
+			if err != nil {
+				if first {
+					continue
+				}
+				var line, _ = reader.FieldPos(0)
+				yield(nil, fmt.Errorf("geomodel: CSV line %d: %w", line, err))
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+func parseCSVRecord(fields []string, latCol, lonCol, keyCol int, resolution int) (*CSVRecord, error) {
+	if latCol >= len(fields) || lonCol >= len(fields) || keyCol >= len(fields) {
+		return nil, fmt.Errorf("%d fields, too few for the configured columns", len(fields))
+	}
+	var lat, err = strconv.ParseFloat(fields[latCol], 64)
+	if err != nil {
+		return nil, err
+	}
+	var lon float64
+	if lon, err = strconv.ParseFloat(fields[lonCol], 64); err != nil {
+		return nil, err
+	}
+	if err = (Point{Lat: lat, Lon: lon}).Validate(); err != nil {
+		return nil, err
+	}
+	return &CSVRecord{IndexRecord{fields[keyCol], lat, lon, GeoCells(lat, lon, resolution)}, fields}, nil
+}
+
+// LoadCSVInto streams rows as LoadCSV does and inserts them into index,
+// returning how many it inserted. Rows before an error remain inserted.
+func LoadCSVInto(index *GeoIndex, r io.Reader, latCol, lonCol, keyCol int, resolution int) (int, error) {
+	var inserted int
+	for record, err := range LoadCSV(r, latCol, lonCol, keyCol, resolution) {
+		if err != nil {
+			return inserted, err
+		}
+		index.Insert(record)
+		inserted++
+	}
+	return inserted, nil
+}
@@ -0,0 +1,41 @@
+package geomodel
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadCSV(t *testing.T) {
+	const input = "id,name,lat,lon\n1,depot,50,8\n2,\"shop, north\",50.001,8.001\n"
+
+	var records []*CSVRecord
+	for record, err := range LoadCSV(strings.NewReader(input), 2, 3, 0, 8) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 || records[1].Key() != "2" || records[1].Fields[1] != "shop, north" || records[1].Longitude() != 8.001 {
+		t.Fatalf("LoadCSV returned %+v", records)
+	}
+	if !reflect.DeepEqual(records[0].Geocells(), GeoCells(50, 8, 8)) {
+		t.Errorf("geocells %v, want %v", records[0].Geocells(), GeoCells(50, 8, 8))
+	}
+
+	var index = NewGeoIndex()
+	n, err := LoadCSVInto(index, strings.NewReader(input+"3,broken,north,8\n4,after,0,0\n"), 2, 3, 0, 8)
+	if err == nil || !strings.Contains(err.Error(), "line 4") || n != 2 || index.Len() != 2 {
+		t.Errorf("LoadCSVInto over a bad row = %d, %v with %d entities", n, err, index.Len())
+	}
+}
+
+func TestLoadCSVNegativeColumn(t *testing.T) {
+	for _, cols := range [][3]int{{-1, 1, 2}, {0, -2, 2}, {0, 1, -1}} {
+		var n, err = LoadCSVInto(NewGeoIndex(), strings.NewReader("a,50,8\n"), cols[0], cols[1], cols[2], 8)
+		if n != 0 || !errors.Is(err, ErrInvalidColumn) {
+			t.Errorf("LoadCSVInto with columns %v = %d, %v, want ErrInvalidColumn", cols, n, err)
+		}
+	}
+}
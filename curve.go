@@ -0,0 +1,29 @@
+package geomodel
+
+import "github.com/alternaDev/geomodel/internal/curve"
+
+// Curve maps coordinates to geocells: it encodes points, bounds cells and
+// finds their neighbors. The package functions such as GeoCell use the
+// geohash curve; other curves are selected per search with WithCurve.
+type Curve = curve.Curve
+
+// RegisterCurve makes an experimental curve available to LookupCurve under
+// c.Name(). It fails if a curve with that name is already registered.
+func RegisterCurve(c Curve) error {
+	return curve.Register(c)
+}
+
+// LookupCurve returns the curve registered under name. The default curve is
+// registered as "geohash".
+func LookupCurve(name string) (Curve, bool) {
+	return curve.Lookup(name)
+}
+
+// WithCurve makes a search encode, bound and expand cells with c instead of
+// the geohash curve. The repository must index entities with cells of the
+// same curve.
+func WithCurve(c Curve) Option {
+	return func(o *searchOptions) {
+		o.curve = c
+	}
+}
@@ -0,0 +1,69 @@
+package geomodel
+
+import "strconv"
+
+// DBSCAN_NOISE is the label ClusterDBSCAN gives entities in no cluster.
+const DBSCAN_NOISE = -1
+
+// ClusterDBSCAN groups entities by density, for finding hotspots and the
+// outliers around them: entities with at least minPts entities, themselves
+// included, within epsMeters are core entities, core entities within
+// epsMeters of each other share a cluster, and the remaining entities join
+// the cluster of a core entity within epsMeters if there is one.
+//
+// It returns the cluster of each entity, numbered from 0 in the order their
+// first entity appears, or DBSCAN_NOISE, and the number of clusters. Entities
+// with an invalid location are noise. The neighborhoods are found through a
+// GeoIndex of the entities' cells, visiting only the cells within epsMeters.
+func ClusterDBSCAN(entities []LocationCapable, epsMeters float64, minPts int) (labels []int, clusters int) {
+	labels = make([]int, len(entities))
+	var visited []bool = make([]bool, len(entities))
+	for i := range labels {
+		labels[i] = DBSCAN_NOISE
+	}
+
+	var index *GeoIndex = indexEntities(entities)
+	var neighborhood = func(i int) []int {
+		var neighbors []int
+		index.scan(entities[i].Latitude(), entities[i].Longitude(), func(r SearchResult) bool {
+			if r.Distance > epsMeters {
+				return false
+			}
+			neighbors = append(neighbors, r.Entity.(*indexedEntity).index)
+			return true
+		})
+		return neighbors
+	}
+
+	for i := range entities {
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		if _, ok := index.Get(strconv.Itoa(i)); !ok {
+			continue
+		}
+		var queue []int = neighborhood(i)
+		if len(queue) < minPts {
+			continue
+		}
+
+		labels[i] = clusters
+		for len(queue) > 0 {
+			var j int = queue[0]
+			queue = queue[1:]
+			if labels[j] == DBSCAN_NOISE {
+				labels[j] = clusters
+			}
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if neighbors := neighborhood(j); len(neighbors) >= minPts {
+				queue = append(queue, neighbors...)
+			}
+		}
+		clusters++
+	}
+	return labels, clusters
+}
@@ -0,0 +1,79 @@
+package geomodel
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestClusterDBSCAN(t *testing.T) {
+	var origin = Point{50, 8}
+	var at = func(key string, bearing, meters float64) LocationCapable {
+		var p Point = Destination(origin, bearing, meters)
+		return Place{p.Lat, p.Lon, key, nil}
+	}
+	var entities = []LocationCapable{
+		// A chain of entities 80m apart, each but the ends with two
+		// neighbors within 100m.
+		at("a0", 90, 0), at("a1", 90, 80), at("a2", 90, 160), at("a3", 90, 240),
+		// An outlier.
+		at("x", 0, 5000),
+		// A tight group across town.
+		at("b0", 180, 3000), at("b1", 180, 3010), at("b2", 180, 3020),
+		// A border entity 90m beyond the end of the chain.
+		at("a4", 90, 330),
+		Place{math.NaN(), 8, "nowhere", nil},
+	}
+
+	var labels, clusters = ClusterDBSCAN(entities, 100, 3)
+	var want = []int{0, 0, 0, 0, DBSCAN_NOISE, 1, 1, 1, 0, DBSCAN_NOISE}
+	if clusters != 2 || !reflect.DeepEqual(labels, want) {
+		t.Errorf("ClusterDBSCAN = %v, %d clusters; want %v, 2 clusters", labels, clusters, want)
+	}
+
+	// With a larger minimum the chain ends are no longer core entities, but
+	// a1 and a2 still each have a0..a3 within reach.
+	labels, clusters = ClusterDBSCAN(entities, 175, 4)
+	want = []int{0, 0, 0, 0, DBSCAN_NOISE, DBSCAN_NOISE, DBSCAN_NOISE, DBSCAN_NOISE, 0, DBSCAN_NOISE}
+	if clusters != 1 || !reflect.DeepEqual(labels, want) {
+		t.Errorf("ClusterDBSCAN = %v, %d clusters; want %v, 1 cluster", labels, clusters, want)
+	}
+}
+
+func TestClusterDBSCANMatchesBruteForce(t *testing.T) {
+	var entities []LocationCapable = randomPlaces(400)
+	var labels, _ = ClusterDBSCAN(entities, 3000, 4)
+
+	var near [][]int = make([][]int, len(entities))
+	for i, a := range entities {
+		for j, b := range entities {
+			if Distance(a.Latitude(), a.Longitude(), b.Latitude(), b.Longitude()) <= 3000 {
+				near[i] = append(near[i], j)
+			}
+		}
+	}
+
+	// Core entities share a label with the core entities within reach, and
+	// every other entity within reach of one is in some cluster.
+	for i := range entities {
+		if len(near[i]) < 4 {
+			continue
+		}
+		for _, j := range near[i] {
+			if labels[i] == DBSCAN_NOISE || labels[j] == DBSCAN_NOISE || len(near[j]) >= 4 && labels[j] != labels[i] {
+				t.Fatalf("core entity %d labelled %d, its neighbor %d labelled %d", i, labels[i], j, labels[j])
+			}
+		}
+	}
+	for i, label := range labels {
+		if label != DBSCAN_NOISE && len(near[i]) < 4 {
+			var reached bool
+			for _, j := range near[i] {
+				reached = reached || len(near[j]) >= 4 && labels[j] == label
+			}
+			if !reached {
+				t.Errorf("border entity %d labelled %d with no core entity of it within reach", i, label)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package geomodel
+
+import "sync"
+
+// DensityStats collects how many entities searches find per cell, letting
+// later searches in the same area start at a resolution likely to hold
+// enough results. See WithDensityStats.
+type DensityStats interface {
+	// Observe records that searching cell returned hits entities.
+	Observe(cell string, hits int)
+	// Hits returns the typical number of entities found in cell, and false
+	// if cell has never been observed.
+	Hits(cell string) (float64, bool)
+}
+
+// MemoryDensityStats is an in-process DensityStats keeping the mean number
+// of hits observed per cell. It is safe for concurrent use.
+type MemoryDensityStats struct {
+	mu    sync.RWMutex
+	cells map[string]densityCounter
+}
+
+type densityCounter struct {
+	observations int
+	hits         int
+}
+
+// NewMemoryDensityStats returns an empty MemoryDensityStats.
+func NewMemoryDensityStats() *MemoryDensityStats {
+	return &MemoryDensityStats{cells: make(map[string]densityCounter)}
+}
+
+func (s *MemoryDensityStats) Observe(cell string, hits int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var c densityCounter = s.cells[cell]
+	c.observations++
+	c.hits += hits
+	s.cells[cell] = c
+}
+
+func (s *MemoryDensityStats) Hits(cell string) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var c, ok = s.cells[cell]
+	if !ok {
+		return 0, false
+	}
+	return float64(c.hits) / float64(c.observations), true
+}
+
+// densityResolution returns the finest resolution, up to maxResolution, whose
+// cell containing (lat, lon) has typically held at least want entities, or
+// maxResolution if no such cell has been observed.
+func densityResolution(stats DensityStats, c Curve, lat, lon float64, want, maxResolution int) int {
+	var cell string = c.Encode(lat, lon, maxResolution)
+	for resolution := maxResolution; resolution >= 1; resolution-- {
+		if hits, ok := stats.Hits(cell[:resolution]); ok && hits >= float64(want) {
+			return resolution
+		}
+	}
+	return maxResolution
+}
+
+// observeDensity records, for each searched cell, how many of the entities
+// found have it among their geocells.
+func observeDensity(stats DensityStats, cells []string, entities []LocationCapable) {
+	var hits map[string]int = make(map[string]int, len(cells))
+	for _, cell := range cells {
+		hits[cell] = 0
+	}
+	for _, entity := range entities {
+		for _, cell := range entity.Geocells() {
+			if _, ok := hits[cell]; ok {
+				hits[cell]++
+			}
+		}
+	}
+	for cell, n := range hits {
+		stats.Observe(cell, n)
+	}
+}
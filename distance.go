@@ -0,0 +1,147 @@
+package geomodel
+
+import "math"
+
+// DistanceFunc returns the distance in meters between two points given in
+// degrees. Distance, DistanceHaversine, DistanceVincenty and
+// DistanceEquirectangular are DistanceFuncs; WithDistanceFunc selects one for
+// a search.
+type DistanceFunc func(lat1, lon1, lat2, lon2 float64) float64
+
+// The WGS84 ellipsoid used by DistanceVincenty.
+const (
+	WGS84_SEMI_MAJOR_AXIS = 6378137.0
+	WGS84_FLATTENING      = 1 / 298.257223563
+)
+
+// vincentyMaxIterations bounds the iteration of DistanceVincenty, which
+// converges within a few steps except for nearly antipodal points.
+const vincentyMaxIterations = 200
+
+// DistanceHaversine returns the great-circle distance in meters between two
+// points on a sphere of radius EARTH_RADIUS, computed with the haversine
+// formula, which stays accurate for points close together. Distance uses it.
+func DistanceHaversine(lat1, lon1, lat2, lon2 float64) float64 {
+	var sinLat float64 = math.Sin(DegToRad(lat2-lat1) / 2)
+	var sinLon float64 = math.Sin(DegToRad(lon2-lon1) / 2)
+	var h float64 = sinLat*sinLat + math.Cos(DegToRad(lat1))*math.Cos(DegToRad(lat2))*sinLon*sinLon
+	return 2 * EARTH_RADIUS * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// DistanceVincenty returns the geodesic distance in meters between two points
+// on the WGS84 ellipsoid, computed with Vincenty's inverse formula, which is
+// accurate to well below a millimeter. For nearly antipodal points, where the
+// formula fails to converge, it falls back to DistanceHaversine.
+func DistanceVincenty(lat1, lon1, lat2, lon2 float64) float64 {
+	var a float64 = WGS84_SEMI_MAJOR_AXIS
+	var f float64 = WGS84_FLATTENING
+	var b float64 = a * (1 - f)
+
+	var L float64 = DegToRad(lon2 - lon1)
+	var U1 float64 = math.Atan((1 - f) * math.Tan(DegToRad(lat1)))
+	var U2 float64 = math.Atan((1 - f) * math.Tan(DegToRad(lat2)))
+	var sinU1, cosU1 = math.Sincos(U1)
+	var sinU2, cosU2 = math.Sincos(U2)
+
+	var lambda float64 = L
+	var sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	for i := 0; ; i++ {
+		if i == vincentyMaxIterations {
+			return DistanceHaversine(lat1, lon1, lat2, lon2)
+		}
+		var sinLambda, cosLambda = math.Sincos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			// Coincident points.
+			return 0
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		var sinAlpha float64 = cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0
+		if cosSqAlpha != 0 {
+			// Both points on the equator otherwise.
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		var C float64 = f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+		var previous float64 = lambda
+		lambda = L + (1-C)*f*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) < 1e-12 {
+			break
+		}
+	}
+
+	var uSq float64 = cosSqAlpha * (a*a - b*b) / (b * b)
+	var A float64 = 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	var B float64 = uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	var deltaSigma float64 = B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+	return b * A * (sigma - deltaSigma)
+}
+
+// DistanceEquirectangular returns an approximate distance in meters between
+// two points, treating the sphere of radius EARTH_RADIUS as flat around them:
+// the longitude difference is scaled by the cosine of the mean latitude. It
+// is the cheapest of the DistanceFuncs and is accurate for points a few
+// kilometers apart, away from the poles, which suffices to rank nearby
+// entities.
+func DistanceEquirectangular(lat1, lon1, lat2, lon2 float64) float64 {
+	var dLon float64 = math.Remainder(lon2-lon1, 360)
+	var x float64 = DegToRad(dLon) * math.Cos(DegToRad((lat1+lat2)/2))
+	var y float64 = DegToRad(lat2 - lat1)
+	return EARTH_RADIUS * math.Hypot(x, y)
+}
+
+// DistanceToCell returns the great-circle distance in meters from (lat, lon)
+// to the nearest point of the bounding box of cell, or 0 if the point lies
+// inside it. No entity of the cell is closer, which makes it a lower bound
+// for pruning cells from a search.
+func DistanceToCell(lat, lon float64, cell string) float64 {
+	return distanceToBox(lat, lon, ComputeBox(cell))
+}
+
+// CellDistance returns the smallest and largest great-circle distance in
+// meters between a point of the bounding box of cellA and a point of that of
+// cellB, so that entities in the two cells are provably at least min and at
+// most max apart. min is 0 for cells sharing a point.
+func CellDistance(cellA, cellB string) (min, max float64) {
+	var a, b BoundingBox = ComputeBox(cellA), ComputeBox(cellB)
+	// The point of b farthest from a point p is the one nearest to the
+	// antipode of p.
+	return boxDistance(a, b), math.Pi*EARTH_RADIUS - boxDistance(antipodalBox(a), b)
+}
+
+// boxDistance returns the smallest distance in meters between a point of a
+// and a point of b. For disjoint boxes it is reached at a corner of one of
+// them: along bounding meridians the distance shrinks towards the poles,
+// and along bounding parallels towards the other box.
+func boxDistance(a, b BoundingBox) float64 {
+	if a.Intersects(b) {
+		return 0
+	}
+	var d float64 = math.Inf(1)
+	for _, boxes := range [2][2]BoundingBox{{a, b}, {b, a}} {
+		var from, to BoundingBox = boxes[0], boxes[1]
+		for _, lat := range [2]float64{from.latSW, from.latNE} {
+			for _, lon := range [2]float64{from.lonSW, from.lonNE} {
+				d = math.Min(d, distanceToBox(lat, lon, to))
+			}
+		}
+	}
+	return d
+}
+
+// antipodalBox returns the box holding the antipodes of the points of bbox.
+func antipodalBox(bbox BoundingBox) BoundingBox {
+	var width float64 = bbox.lonNE - bbox.lonSW
+	if width < 0 {
+		width += 360
+	}
+	var west float64 = wrapLon(bbox.lonSW + 180)
+	var east float64 = west + width
+	if east > 180 {
+		east -= 360
+	}
+	return BoundingBox{-bbox.latSW, east, -bbox.latNE, west}
+}
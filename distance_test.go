@@ -0,0 +1,155 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceFuncs(t *testing.T) {
+	// Flinders Peak to Buninyong, the classic test case of Vincenty's paper.
+	var lat1, lon1 = -(37 + 57.0/60 + 3.72030/3600), 144 + 25.0/60 + 29.52440/3600
+	var lat2, lon2 = -(37 + 39.0/60 + 10.15610/3600), 143 + 55.0/60 + 35.38390/3600
+	if d := DistanceVincenty(lat1, lon1, lat2, lon2); math.Abs(d-54972.271) > 0.001 {
+		t.Errorf("DistanceVincenty = %.4f, want 54972.271", d)
+	}
+
+	var tests = []struct {
+		lat1, lon1, lat2, lon2 float64
+	}{
+		{50, 8, 50.01, 8.01},
+		{53.12869, 8.18976, 52.52, 13.405},
+		{-33.87, 151.21, -33.8, 151.3},
+		{10, 179.99, 10.01, -179.99},
+	}
+	for _, test := range tests {
+		var reference float64 = Distance(test.lat1, test.lon1, test.lat2, test.lon2)
+		for name, f := range map[string]DistanceFunc{
+			"DistanceHaversine":       DistanceHaversine,
+			"DistanceVincenty":        DistanceVincenty,
+			"DistanceEquirectangular": DistanceEquirectangular,
+		} {
+			// The ellipsoid differs from the sphere by well under one percent.
+			if d := f(test.lat1, test.lon1, test.lat2, test.lon2); math.Abs(d-reference) > reference*0.01 {
+				t.Errorf("%s(%v) = %f, want about %f", name, test, d, reference)
+			}
+		}
+	}
+
+	if d := DistanceVincenty(50, 8, 50, 8); d != 0 {
+		t.Errorf("DistanceVincenty of coincident points = %f", d)
+	}
+	if d := DistanceVincenty(0, 0, 0.5, 179.7); math.IsNaN(d) || d < 19e6 {
+		t.Errorf("DistanceVincenty of nearly antipodal points = %f", d)
+	}
+}
+
+func TestProximityFetchDistanceFunc(t *testing.T) {
+	var places = []LocationCapable{Place{50.002, 8, "north", GeoCells(50.002, 8, 10)}, Place{50, 8.002, "east", GeoCells(50, 8.002, 10)}}
+
+	// A metric weighting longitude differences ten times more ranks the place
+	// to the north first, which is farther on the globe.
+	var skewed DistanceFunc = func(lat1, lon1, lat2, lon2 float64) float64 {
+		return Distance(lat1, lon1, lat2, lon1) + 10*Distance(lat1, lon1, lat1, lon2)
+	}
+	var results = ProximityFetchResults(50, 8, 2, 0, searchPlaces(places), 10, WithDistanceFunc(skewed))
+	if len(results) != 2 || results[0].Entity.Key() != "north" || results[0].Distance != skewed(50, 8, 50.002, 8) {
+		t.Errorf("got %v, want north first with its skewed distance", results)
+	}
+
+	results = ProximityFetchResults(50, 8, 2, 0, searchPlaces(places), 10, WithDistanceFunc(DistanceVincenty))
+	if len(results) != 2 || results[0].Entity.Key() != "east" || results[0].Distance != DistanceVincenty(50, 8, 50, 8.002) {
+		t.Errorf("got %v, want east first with its geodesic distance", results)
+	}
+}
+
+func TestDistanceShortSeparations(t *testing.T) {
+	// At these separations the spherical law of cosines takes the arc cosine
+	// of values rounding to 1 and is off by centimeters or returns zero.
+	for _, meters := range []float64{0.01, 0.1, 0.5, 1, 10} {
+		var dLat float64 = meters / 111200
+		for _, p := range []Point{{Lat: 0, Lon: 0}, {Lat: 50, Lon: 8}, {Lat: -33.87, Lon: 151.21}} {
+			var reference float64 = DistanceVincenty(p.Lat, p.Lon, p.Lat+dLat, p.Lon)
+			if d := Distance(p.Lat, p.Lon, p.Lat+dLat, p.Lon); math.Abs(d-reference) > reference*0.01 {
+				t.Errorf("Distance over %v m north of %v = %g, want about %g", meters, p, d, reference)
+			}
+			reference = DistanceVincenty(p.Lat, p.Lon, p.Lat, p.Lon+dLat)
+			if d := Distance(p.Lat, p.Lon, p.Lat, p.Lon+dLat); math.Abs(d-reference) > reference*0.01 {
+				t.Errorf("Distance over %v m of longitude at %v = %g, want about %g", meters, p, d, reference)
+			}
+		}
+	}
+}
+
+func TestDistanceToCell(t *testing.T) {
+	if d := DistanceToCell(50, 8, GeoCell(50, 8, 5)); d != 0 {
+		t.Errorf("DistanceToCell of a point inside = %f", d)
+	}
+
+	var tests = []struct {
+		lat, lon float64
+		cell     string
+	}{
+		{50, 8, "u1"},
+		{50, 8, "gc"},
+		{50, 8, "9"},
+		{80, 0, "f"},
+		{-70, 100, "0"},
+		{10, 179.9, "2"},
+		{0, 0, "s0"},
+	}
+	for _, test := range tests {
+		// The minimum over densely sampled boundary points.
+		var bbox BoundingBox = ComputeBox(test.cell)
+		var want float64 = math.Inf(1)
+		for i := 0; i <= 2000; i++ {
+			var f float64 = float64(i) / 2000
+			var lat float64 = bbox.latSW + f*(bbox.latNE-bbox.latSW)
+			var lon float64 = bbox.lonSW + f*(bbox.lonNE-bbox.lonSW)
+			want = math.Min(want, math.Min(Distance(test.lat, test.lon, lat, bbox.lonSW), Distance(test.lat, test.lon, lat, bbox.lonNE)))
+			want = math.Min(want, math.Min(Distance(test.lat, test.lon, bbox.latSW, lon), Distance(test.lat, test.lon, bbox.latNE, lon)))
+		}
+		if got := DistanceToCell(test.lat, test.lon, test.cell); got > want || got < want*0.9999 {
+			t.Errorf("DistanceToCell(%v, %v, %q) = %f, want %f", test.lat, test.lon, test.cell, got, want)
+		}
+	}
+}
+
+func TestCellDistance(t *testing.T) {
+	var cells = []string{"u0", "u1", "u1m", "gc", "s", "6", "0", "z", "2", "r", "kz", "t5"}
+	var grid = func(bbox BoundingBox, n int) []Point {
+		var points []Point
+		for i := 0; i <= n; i++ {
+			for j := 0; j <= n; j++ {
+				points = append(points, Point{
+					Lat: bbox.latSW + float64(i)/float64(n)*(bbox.latNE-bbox.latSW),
+					Lon: bbox.lonSW + float64(j)/float64(n)*(bbox.lonNE-bbox.lonSW),
+				})
+			}
+		}
+		return points
+	}
+	for _, a := range cells {
+		for _, b := range cells {
+			// The nearest distance from points sampled in a to the box of b,
+			// and the largest distance between points sampled in both.
+			var boxB BoundingBox = ComputeBox(b)
+			var lo, hi float64 = math.Inf(1), 0
+			for _, p := range grid(ComputeBox(a), 40) {
+				lo = math.Min(lo, distanceToBox(p.Lat, p.Lon, boxB))
+			}
+			for _, p := range grid(ComputeBox(a), 16) {
+				for _, q := range grid(boxB, 16) {
+					hi = math.Max(hi, Distance(p.Lat, p.Lon, q.Lat, q.Lon))
+				}
+			}
+
+			var min, max = CellDistance(a, b)
+			if min > lo+1e-6 || max < hi-1e-6 {
+				t.Errorf("CellDistance(%q, %q) = %f, %f, but sampled points are %f to %f apart", a, b, min, max, lo, hi)
+			}
+			if min < lo-lo*0.01-1 || max > hi+hi*0.01+1 {
+				t.Errorf("CellDistance(%q, %q) = %f, %f, loose against sampled %f to %f", a, b, min, max, lo, hi)
+			}
+		}
+	}
+}
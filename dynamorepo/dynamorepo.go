@@ -0,0 +1,273 @@
+// Package dynamorepo implements geomodel searches over DynamoDB-style tables
+// keyed by geocell: the first PartitionResolution characters of an entity's
+// geocell form the partition key, and the rest of the geocell followed by
+// the entity key forms the sort key. A search cell then maps to a single
+// Query on one partition with a begins_with condition on the sort key, and
+// cells are queried in parallel.
+//
+// The package does not import the AWS SDK. Callers supply as Fetch a
+// QueryFunc running a query such as
+//
+//	KeyConditionExpression: "pk = :pk AND begins_with(sk, :prefix)"
+//
+// (omitting the sort key condition when the prefix is empty) and decoding
+// the items, and compute the keys of written items with Keys.
+//
+// Tables shared by several partitions, such as tenants, prefix the
+// partition key with the partition, so that the items of each partition
+// live in partition keys of their own: write items under KeysIn and search
+// them with SearchPartition.
+package dynamorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/internal/firsterr"
+)
+
+// ErrTooCoarse is returned for searches of cells so much coarser than the
+// partition resolution that they would need more than MaxFanout queries.
+var ErrTooCoarse = errors.New("dynamorepo: cell too coarse for the partition resolution")
+
+// SORT_KEY_SEPARATOR separates the geocell suffix from the entity key in
+// sort keys, and the partition from the geocell prefix in the partition keys
+// of KeysIn. It is outside the geocell alphabet.
+const SORT_KEY_SEPARATOR = "#"
+
+// Defaults for the Repository fields left zero.
+const (
+	DEFAULT_PARTITION_RESOLUTION = 4
+	DEFAULT_RESOLUTION           = geomodel.MAX_GEOCELL_RESOLUTION
+	DEFAULT_PARALLELISM          = 8
+	DEFAULT_MAX_FANOUT           = 1024
+)
+
+// QueryInput selects the items of one partition whose sort key starts with
+// SortKeyPrefix. An empty prefix selects the whole partition.
+type QueryInput struct {
+	PartitionKey  string
+	SortKeyPrefix string
+}
+
+// QueryFunc runs a query, following pagination, and returns the entities
+// of the items found.
+type QueryFunc func(ctx context.Context, input QueryInput) ([]geomodel.LocationCapable, error)
+
+// Repository searches a table keyed by geocell. Its fields are read-only
+// once searches start.
+type Repository struct {
+	Fetch QueryFunc
+	// PartitionResolution is the number of geocell characters in the
+	// partition key. Finer partitions spread load better; coarser ones let
+	// coarse searches run fewer queries. It defaults to
+	// DEFAULT_PARTITION_RESOLUTION.
+	PartitionResolution int
+	// Resolution is the geocell resolution of the keys computed by Keys. It
+	// defaults to DEFAULT_RESOLUTION.
+	Resolution int
+	// Parallelism bounds the queries run at once by a search. It defaults to
+	// DEFAULT_PARALLELISM.
+	Parallelism int
+	// MaxFanout bounds the partitions queried for a single cell coarser than
+	// the partition resolution. It defaults to DEFAULT_MAX_FANOUT.
+	MaxFanout int
+}
+
+// Keys returns the partition and sort keys of the item for the entity with
+// the given key at (lat, lon), or the error of geomodel.Point.Validate for
+// invalid coordinates. An entity that moves must be deleted under its old
+// keys and written under the new ones when they differ.
+func (r *Repository) Keys(key string, lat, lon float64) (partition, sort string, err error) {
+	if err = (geomodel.Point{Lat: lat, Lon: lon}).Validate(); err != nil {
+		return "", "", err
+	}
+	var resolution int = r.Resolution
+	if resolution <= 0 {
+		resolution = DEFAULT_RESOLUTION
+	}
+	var partitionResolution int = r.partitionResolution()
+	var cell string = geomodel.GeoCell(lat, lon, max(resolution, partitionResolution))
+	return cell[:partitionResolution], cell[partitionResolution:] + SORT_KEY_SEPARATOR + key, nil
+}
+
+// KeysIn is Keys for an entity of the given partition, whose partition key
+// starts with the partition and SORT_KEY_SEPARATOR.
+func (r *Repository) KeysIn(partition, key string, lat, lon float64) (partitionKey, sort string, err error) {
+	if partitionKey, sort, err = r.Keys(key, lat, lon); err != nil {
+		return "", "", err
+	}
+	return partitionKeyIn(partition, partitionKey), sort, nil
+}
+
+func (r *Repository) partitionResolution() int {
+	if r.PartitionResolution <= 0 {
+		return DEFAULT_PARTITION_RESOLUTION
+	}
+	return r.PartitionResolution
+}
+
+// partitionKeyIn returns the partition key of cell prefix p in partition.
+// The empty partition is that of Keys, without a prefix.
+func partitionKeyIn(partition, p string) string {
+	if partition == "" {
+		return p
+	}
+	return partition + SORT_KEY_SEPARATOR + p
+}
+
+// Inputs returns the queries covering cell.
+func (r *Repository) Inputs(cell string) ([]QueryInput, error) {
+	return r.inputs("", cell)
+}
+
+// inputs returns the queries covering cell in partition.
+func (r *Repository) inputs(partition, cell string) ([]QueryInput, error) {
+	var partitionResolution int = r.partitionResolution()
+	if len(cell) >= partitionResolution {
+		return []QueryInput{{partitionKeyIn(partition, cell[:partitionResolution]), cell[partitionResolution:]}}, nil
+	}
+
+	var maxFanout int = r.MaxFanout
+	if maxFanout <= 0 {
+		maxFanout = DEFAULT_MAX_FANOUT
+	}
+	var partitions []string = []string{cell}
+	for i := len(cell); i < partitionResolution; i++ {
+		if len(partitions)*len(geomodel.GEOCELL_ALPHABET) > maxFanout {
+			return nil, fmt.Errorf("%w: %q", ErrTooCoarse, cell)
+		}
+		var next []string = make([]string, 0, len(partitions)*len(geomodel.GEOCELL_ALPHABET))
+		for _, p := range partitions {
+			for j := 0; j < len(geomodel.GEOCELL_ALPHABET); j++ {
+				next = append(next, p+geomodel.GEOCELL_ALPHABET[j:j+1])
+			}
+		}
+		partitions = next
+	}
+
+	var inputs []QueryInput = make([]QueryInput, len(partitions))
+	for i, p := range partitions {
+		inputs[i] = QueryInput{PartitionKey: partitionKeyIn(partition, p)}
+	}
+	return inputs, nil
+}
+
+// Search runs the queries covering cells in parallel and returns the
+// entities found, each once. The first query to fail cancels the others,
+// and its error is returned; a canceled ctx stops further queries and
+// returns ctx.Err().
+func (r *Repository) Search(ctx context.Context, cells []string) ([]geomodel.LocationCapable, error) {
+	return r.SearchPartition(ctx, "", cells)
+}
+
+// SearchPartition is Search over the items written under KeysIn with
+// partition. Only the partition keys of that partition are queried.
+func (r *Repository) SearchPartition(ctx context.Context, partition string, cells []string) ([]geomodel.LocationCapable, error) {
+	var inputs []QueryInput
+	for _, cell := range cells {
+		var cellInputs, err = r.inputs(partition, cell)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, cellInputs...)
+	}
+
+	var parallelism int = r.Parallelism
+	if parallelism <= 0 {
+		parallelism = DEFAULT_PARALLELISM
+	}
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// As with errgroup, the first query to fail cancels the others and its
+	// error is returned, and no queries start after that.
+	var found [][]geomodel.LocationCapable = make([][]geomodel.LocationCapable, len(inputs))
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	var workers chan struct{} = make(chan struct{}, parallelism)
+dispatch:
+	for i, input := range inputs {
+		select {
+		case workers <- struct{}{}:
+		case <-queryCtx.Done():
+			break dispatch
+		}
+		if queryCtx.Err() != nil {
+			<-workers
+			break
+		}
+		wg.Add(1)
+		go func(i int, input QueryInput) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			var err error
+			if found[i], err = r.Fetch(queryCtx, input); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}(i, input)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var results []geomodel.LocationCapable
+	var seen map[string]struct{} = make(map[string]struct{})
+	for _, entities := range found {
+		for _, entity := range entities {
+			if _, ok := seen[entity.Key()]; !ok {
+				seen[entity.Key()] = struct{}{}
+				results = append(results, entity)
+			}
+		}
+	}
+	return results, nil
+}
+
+// Query binds a context to the repository for use as a
+// geomodel.RepositorySearch, which cannot return errors: the first error is
+// kept for Err and makes later searches return no entities.
+type Query struct {
+	repo  *Repository
+	ctx   context.Context
+	first firsterr.Keeper
+}
+
+// Query returns a Query running searches with ctx.
+func (r *Repository) Query(ctx context.Context) *Query {
+	return &Query{repo: r, ctx: ctx}
+}
+
+// Search implements geomodel.RepositorySearch. It is safe for concurrent
+// use, as with geomodel.WithParallelism.
+func (q *Query) Search(cells []string) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.Search(q.ctx, cells)
+	})
+}
+
+// SearchPartition implements geomodel.PartitionSearch, searching as
+// Repository.SearchPartition does.
+func (q *Query) SearchPartition(partition string, cells []string) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.SearchPartition(q.ctx, partition, cells)
+	})
+}
+
+// Err returns the first error of the searches run so far.
+func (q *Query) Err() error {
+	return q.first.Err()
+}
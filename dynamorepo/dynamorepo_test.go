@@ -0,0 +1,185 @@
+package dynamorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/adaptertest"
+)
+
+type item struct {
+	pk, sk string
+	entity geomodel.LocationCapable
+}
+
+// fakeTable answers queries over items like a DynamoDB Query with a
+// begins_with sort key condition, counting the queries run.
+type fakeTable struct {
+	mu      sync.Mutex
+	items   []item
+	queries int
+}
+
+func (f *fakeTable) query(ctx context.Context, input QueryInput) ([]geomodel.LocationCapable, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+	var found []geomodel.LocationCapable
+	for _, it := range f.items {
+		if it.pk == input.PartitionKey && strings.HasPrefix(it.sk, input.SortKeyPrefix) {
+			found = append(found, it.entity)
+		}
+	}
+	return found, nil
+}
+
+// tableIndex writes entities into a fakeTable under the keys of
+// Repository.Keys.
+type tableIndex struct {
+	table *fakeTable
+	repo  *Repository
+}
+
+func (x *tableIndex) Put(entity geomodel.LocationCapable) error {
+	var pk, sk, err = x.repo.Keys(entity.Key(), entity.Latitude(), entity.Longitude())
+	if err != nil {
+		return err
+	}
+	var record = &geomodel.IndexRecord{ID: entity.Key(), Lat: entity.Latitude(), Lon: entity.Longitude()}
+	x.table.mu.Lock()
+	defer x.table.mu.Unlock()
+	x.table.items = slices.DeleteFunc(x.table.items, func(it item) bool { return it.entity.Key() == entity.Key() })
+	x.table.items = append(x.table.items, item{pk, sk, record})
+	return nil
+}
+
+func (x *tableIndex) Delete(key string) error {
+	x.table.mu.Lock()
+	defer x.table.mu.Unlock()
+	x.table.items = slices.DeleteFunc(x.table.items, func(it item) bool { return it.entity.Key() == key })
+	return nil
+}
+
+func (x *tableIndex) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	return x.repo.Search(context.Background(), cells)
+}
+
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func() adaptertest.Index {
+		// Partitions of single characters, so that every cell maps to one
+		// query.
+		var table = &fakeTable{}
+		return &tableIndex{table, &Repository{Fetch: table.query, PartitionResolution: 1}}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	var table = &fakeTable{}
+	var repo = &Repository{PartitionResolution: 4, Resolution: 9}
+	repo.Fetch = table.query
+	for i, p := range []geomodel.Point{{Lat: 50, Lon: 8}, {Lat: 50.001, Lon: 8.001}, {Lat: -33.87, Lon: 151.21}} {
+		var key = fmt.Sprint(i)
+		var pk, sk, _ = repo.Keys(key, p.Lat, p.Lon)
+		table.items = append(table.items, item{pk, sk, &geomodel.IndexRecord{ID: key, Lat: p.Lat, Lon: p.Lon}})
+	}
+
+	if pk, sk, err := repo.Keys("0", 50, 8); pk+strings.TrimSuffix(sk, "#0") != geomodel.GeoCell(50, 8, 9) || err != nil {
+		t.Errorf("Keys = %q, %q, %v", pk, sk, err)
+	}
+	if _, _, err := repo.Keys("nan", math.NaN(), 8); !errors.Is(err, geomodel.ErrInvalidPoint) {
+		t.Errorf("Keys at NaN returned %v, want ErrInvalidPoint", err)
+	}
+	if pk, _, _ := (&Repository{}).Keys("0", 50, 8); pk != geomodel.GeoCell(50, 8, DEFAULT_PARTITION_RESOLUTION) {
+		t.Errorf("Keys without a partition resolution = %q, want the default", pk)
+	}
+
+	var query = repo.Query(context.Background())
+	var results = geomodel.ProximityFetch(50, 8, 5, 1000, query.Search, 9)
+	if err := query.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Key() != "0" {
+		t.Errorf("ProximityFetch returned %v, want entities 0 and 1", results)
+	}
+
+	table.queries = 0
+	var found, err = repo.Search(context.Background(), []string{geomodel.GeoCell(50, 8, 3)})
+	if err != nil || len(found) != 2 || table.queries != 32 {
+		t.Errorf("Search of a cell one level above the partitions returned %d entities in %d queries (err %v), want 2 in 32", len(found), table.queries, err)
+	}
+
+	repo.MaxFanout = 100
+	if _, err := repo.Search(context.Background(), []string{"u"}); !errors.Is(err, ErrTooCoarse) {
+		t.Errorf("Search of a top-level cell returned %v, want ErrTooCoarse", err)
+	}
+}
+
+func TestQueryErr(t *testing.T) {
+	var failure = errors.New("throttled")
+	var repo = &Repository{PartitionResolution: 2, Fetch: func(context.Context, QueryInput) ([]geomodel.LocationCapable, error) {
+		return nil, failure
+	}}
+	var query = repo.Query(context.Background())
+	geomodel.ProximityFetch(50, 8, 5, 1000, query.Search, 8)
+	if err := query.Err(); !errors.Is(err, failure) {
+		t.Errorf("Err() = %v, want %v", err, failure)
+	}
+}
+
+func TestSearchFirstError(t *testing.T) {
+	// The first query fails only once the second has failed and canceled
+	// it, so its error comes second although its input comes first.
+	var slow, fast = errors.New("slow"), errors.New("fast")
+	var calls atomic.Int32
+	var repo = &Repository{PartitionResolution: 4, Parallelism: 2, Fetch: func(ctx context.Context, input QueryInput) ([]geomodel.LocationCapable, error) {
+		calls.Add(1)
+		if strings.HasSuffix(input.PartitionKey, "0") {
+			<-ctx.Done()
+			return nil, slow
+		}
+		return nil, fast
+	}}
+	if _, err := repo.Search(context.Background(), []string{geomodel.GeoCell(50, 8, 3)}); err != fast {
+		t.Errorf("Search returned %v, want the first error in time, %v", err, fast)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Search ran %d queries, want none after the failure", n)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	calls.Store(0)
+	if _, err := repo.Search(ctx, []string{geomodel.GeoCell(50, 8, 3)}); !errors.Is(err, context.Canceled) || calls.Load() != 0 {
+		t.Errorf("Search with a canceled context returned %v after %d queries", err, calls.Load())
+	}
+}
+
+func TestRepositoryPartition(t *testing.T) {
+	var table = &fakeTable{}
+	var repo = &Repository{PartitionResolution: 4, Resolution: 9}
+	repo.Fetch = table.query
+	for _, tenant := range []string{"acme", "globex"} {
+		var pk, sk, _ = repo.KeysIn(tenant, tenant, 50, 8)
+		table.items = append(table.items, item{pk, sk, &geomodel.IndexRecord{ID: tenant, Lat: 50, Lon: 8}})
+	}
+	if pk, _, _ := repo.KeysIn("acme", "acme", 50, 8); pk != "acme#"+geomodel.GeoCell(50, 8, 4) {
+		t.Errorf("KeysIn partition key = %q", pk)
+	}
+
+	var search, scope = geomodel.PartitionSearch(repo.Query(context.Background()).SearchPartition).In("globex")
+	if found := geomodel.ProximityFetch(50, 8, 5, 1000, search, 9, scope); len(found) != 1 || found[0].Key() != "globex" {
+		t.Errorf("ProximityFetch in globex returned %v, want globex", found)
+	}
+	// Unpartitioned searches do not see partitioned items.
+	if found, err := repo.Search(context.Background(), []string{geomodel.GeoCell(50, 8, 5)}); err != nil || len(found) != 0 {
+		t.Errorf("Search returned %v (err %v), want nothing", found, err)
+	}
+}
@@ -0,0 +1,68 @@
+package geomodel
+
+import (
+	"math"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// Boundary cells are sampled on an estimateSamples*estimateSamples grid.
+const estimateSamples = 4
+
+// CellCounter returns the number of indexed entities having cell among their
+// geocells. Backends typically maintain one counter per geocell prefix,
+// incremented for every prefix of an entity's cell on insert.
+type CellCounter func(cell string) int
+
+// EstimateResultCount estimates how many entities lie within radius meters of
+// (lat, lon) without querying them, returning the estimate together with an
+// absolute error bound.
+//
+// The circle is covered with the finest resolution needing at most 64 cells.
+// Cells lying entirely inside the circle contribute their exact count; cells
+// on its boundary are weighted by the fraction of a grid of sample points
+// falling inside the circle, and contribute their worst-case deviation from
+// that weighting to the error bound.
+func EstimateResultCount(lat, lon, radius float64, counter CellCounter) (int, float64) {
+	var cells []string = coverCircleBounded(curve.Geohash, lat, lon, radius, MAX_GEOCELL_RESOLUTION)
+
+	var estimate, errorBound float64
+	for _, cell := range cells {
+		var count int = counter(cell)
+		if count == 0 {
+			continue
+		}
+
+		var fraction float64 = insideFraction(lat, lon, radius, ComputeBox(cell))
+		estimate += float64(count) * fraction
+		if fraction < 1 {
+			errorBound += float64(count) * math.Max(fraction, 1-fraction)
+		}
+	}
+
+	return int(math.Round(estimate)), errorBound
+}
+
+// insideFraction returns the fraction of bbox lying within radius meters of
+// (lat, lon), sampled on an estimateSamples*estimateSamples grid. A box whose
+// corners all lie within the circle is reported as fully inside.
+func insideFraction(lat, lon, radius float64, bbox BoundingBox) float64 {
+	if Distance(lat, lon, bbox.latNE, bbox.lonNE) <= radius &&
+		Distance(lat, lon, bbox.latNE, bbox.lonSW) <= radius &&
+		Distance(lat, lon, bbox.latSW, bbox.lonNE) <= radius &&
+		Distance(lat, lon, bbox.latSW, bbox.lonSW) <= radius {
+		return 1
+	}
+
+	var inside int
+	for i := 0; i < estimateSamples; i++ {
+		var sampleLat float64 = bbox.latSW + (bbox.latNE-bbox.latSW)*(float64(i)+0.5)/estimateSamples
+		for j := 0; j < estimateSamples; j++ {
+			var sampleLon float64 = bbox.lonSW + (bbox.lonNE-bbox.lonSW)*(float64(j)+0.5)/estimateSamples
+			if Distance(lat, lon, sampleLat, sampleLon) <= radius {
+				inside++
+			}
+		}
+	}
+	return float64(inside) / (estimateSamples * estimateSamples)
+}
@@ -0,0 +1,65 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+func TestEstimateResultCount(t *testing.T) {
+	var counts = make(map[string]int)
+	var points [][2]float64
+	for i := 0; i < 40; i++ {
+		for j := 0; j < 40; j++ {
+			var lat, lon = 49.9 + 0.005*float64(i), 7.9 + 0.005*float64(j)
+			points = append(points, [2]float64{lat, lon})
+			for _, cell := range GeoCells(lat, lon, MAX_GEOCELL_RESOLUTION) {
+				counts[cell]++
+			}
+		}
+	}
+	var counter CellCounter = func(cell string) int { return counts[cell] }
+
+	for _, radius := range []float64{500, 2000, 5000} {
+		var actual int
+		for _, p := range points {
+			if Distance(50, 8, p[0], p[1]) <= radius {
+				actual++
+			}
+		}
+
+		estimate, errorBound := EstimateResultCount(50, 8, radius, counter)
+		if math.Abs(float64(estimate-actual)) > errorBound {
+			t.Errorf("radius %v: estimate %d ± %v does not contain actual count %d", radius, estimate, errorBound, actual)
+		}
+	}
+}
+
+func TestCoverCircle(t *testing.T) {
+	var cells = coverCircle(curve.Geohash, 50, 8, 1000, 6)
+	var containing = GeoCell(50, 8, 6)
+	var found bool
+	for _, cell := range cells {
+		if cell == containing {
+			found = true
+		}
+		if distanceToBox(50, 8, ComputeBox(cell)) > 1000 {
+			t.Errorf("cell %s lies outside the circle", cell)
+		}
+	}
+	if !found {
+		t.Errorf("covering %v does not contain %s", cells, containing)
+	}
+
+	// Crossing the antimeridian must pick up cells on both sides.
+	var east, west bool
+	for _, cell := range coverCircle(curve.Geohash, 0, 179.999, 2000, 5) {
+		var bbox = ComputeBox(cell)
+		east = east || bbox.lonNE > 0
+		west = west || bbox.lonSW < 0
+	}
+	if !east || !west {
+		t.Errorf("antimeridian covering missing a side: east=%v west=%v", east, west)
+	}
+}
@@ -0,0 +1,56 @@
+package geomodel_test
+
+import (
+	"fmt"
+
+	"github.com/alternaDev/geomodel"
+)
+
+type shop struct {
+	tenant   string
+	name     string
+	lat, lon float64
+	geocells []string
+}
+
+func (s shop) Latitude() float64  { return s.lat }
+func (s shop) Longitude() float64 { return s.lon }
+func (s shop) Key() string        { return s.tenant + "/" + s.name }
+func (s shop) Geocells() []string { return s.geocells }
+
+func newShop(tenant, name string, lat, lon float64) shop {
+	return shop{tenant, name, lat, lon, geomodel.GeoCells(lat, lon, geomodel.MAX_GEOCELL_RESOLUTION)}
+}
+
+// Finding nearby entities of a single tenant: the repository keeps one
+// cell index per tenant, and the partition selects which one is read.
+func ExamplePartitionSearch() {
+	var byTenant = map[string]map[string][]geomodel.LocationCapable{}
+	for _, s := range []shop{
+		newShop("acme", "central", 52.5200, 13.4050),
+		newShop("globex", "next-door", 52.5201, 13.4051),
+	} {
+		if byTenant[s.tenant] == nil {
+			byTenant[s.tenant] = map[string][]geomodel.LocationCapable{}
+		}
+		for _, cell := range s.geocells {
+			byTenant[s.tenant][cell] = append(byTenant[s.tenant][cell], s)
+		}
+	}
+
+	var search geomodel.PartitionSearch = func(partition string, cells []string) []geomodel.LocationCapable {
+		var found []geomodel.LocationCapable
+		for _, cell := range cells {
+			found = append(found, byTenant[partition][cell]...)
+		}
+		return found
+	}
+
+	// The globex shop is closer, but belongs to another partition.
+	var acme, scope = search.In("acme")
+	for _, entity := range geomodel.ProximityFetch(52.5201, 13.4051, 1, 1000, acme, geomodel.MAX_GEOCELL_RESOLUTION, scope) {
+		fmt.Println(entity.Key())
+	}
+	// Output:
+	// acme/central
+}
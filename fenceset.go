@@ -0,0 +1,140 @@
+package geomodel
+
+import (
+	"sort"
+	"sync"
+)
+
+// FenceSet is a collection of named geofences indexed by the cells covering
+// them, so that finding the fences containing a point only tests the fences
+// whose cells are prefixes of the point's geocell, however many fences the
+// set holds.
+//
+// A FenceSet is safe for concurrent use.
+type FenceSet struct {
+	mu     sync.RWMutex
+	fences map[string]*Geofence
+	// The fences covering each cell, with whether the cell is interior to
+	// them.
+	byCell map[string][]fenceCell
+	// The finest resolution of any fence's covering.
+	resolution int
+}
+
+// fenceCell is a fence covering a cell of FenceSet.byCell.
+type fenceCell struct {
+	name     string
+	interior bool
+}
+
+// NewFenceSet returns a set holding fences, by name.
+func NewFenceSet(fences map[string]*Geofence) *FenceSet {
+	var s *FenceSet = &FenceSet{
+		fences: make(map[string]*Geofence, len(fences)),
+		byCell: make(map[string][]fenceCell),
+	}
+	for name, fence := range fences {
+		s.Add(name, fence)
+	}
+	return s
+}
+
+// Add stores fence under name, replacing any fence with the same name.
+func (s *FenceSet) Add(name string, fence *Geofence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(name)
+	s.fences[name] = fence
+	for cell := range fence.InteriorCells() {
+		s.byCell[cell] = append(s.byCell[cell], fenceCell{name, true})
+	}
+	for cell := range fence.BoundaryCells() {
+		s.byCell[cell] = append(s.byCell[cell], fenceCell{name, false})
+	}
+	s.resolution = max(s.resolution, fence.Resolution())
+}
+
+// Remove deletes the fence with the given name and reports whether there was
+// one.
+func (s *FenceSet) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remove(name)
+}
+
+// remove deletes the fence with the given name with s.mu held.
+func (s *FenceSet) remove(name string) bool {
+	var fence, ok = s.fences[name]
+	if !ok {
+		return false
+	}
+	var unindex = func(cell string) {
+		var entries []fenceCell = s.byCell[cell]
+		for i, entry := range entries {
+			if entry.name == name {
+				entries = append(entries[:i], entries[i+1:]...)
+				break
+			}
+		}
+		if len(entries) == 0 {
+			delete(s.byCell, cell)
+		} else {
+			s.byCell[cell] = entries
+		}
+	}
+	for cell := range fence.InteriorCells() {
+		unindex(cell)
+	}
+	for cell := range fence.BoundaryCells() {
+		unindex(cell)
+	}
+	delete(s.fences, name)
+	return true
+}
+
+// Get returns the fence stored under name.
+func (s *FenceSet) Get(name string) (*Geofence, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var fence, ok = s.fences[name]
+	return fence, ok
+}
+
+// Len returns the number of fences in the set.
+func (s *FenceSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.fences)
+}
+
+// Names returns the names of the fences in the set, in order.
+func (s *FenceSet) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var names []string = make([]string, 0, len(s.fences))
+	for name := range s.fences {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Containing returns the names of the fences containing (lat, lon), in
+// order. Each prefix of the point's geocell is looked up once; fences
+// covering it with an interior cell contain the point, and those covering it
+// with a boundary cell are checked against their exact geometry.
+func (s *FenceSet) Containing(lat, lon float64) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var names []string
+	var cell string = GeoCell(lat, lon, s.resolution)
+	for resolution := 1; resolution <= len(cell); resolution++ {
+		for _, entry := range s.byCell[cell[:resolution]] {
+			if entry.interior || s.fences[entry.name].region.Contains(lat, lon) {
+				names = append(names, entry.name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,63 @@
+package geomodel
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFenceSet(t *testing.T) {
+	var random *rand.Rand = rand.New(rand.NewSource(2))
+	var fences map[string]*Geofence = make(map[string]*Geofence)
+	for i := 0; i < 200; i++ {
+		var center = Point{Lat: 50 + random.Float64()*0.2, Lon: 8 + random.Float64()*0.3}
+		if i%2 == 0 {
+			fences[fmt.Sprintf("circle%d", i)] = NewGeofence(Circle{Center: center, Radius: 500 + random.Float64()*3000}, 6+i%3)
+		} else {
+			fences[fmt.Sprintf("box%d", i)] = NewGeofence(NewBoundingBox(center.Lat+0.01, center.Lon+0.02, center.Lat-0.01, center.Lon-0.01), 7)
+		}
+	}
+	var set *FenceSet = NewFenceSet(fences)
+	set.Remove("circle0")
+	set.Add("box1", NewGeofence(NewBoundingBox(51, 9, 50.9, 8.9), 7))
+	delete(fences, "circle0")
+	fences["box1"], _ = set.Get("box1")
+
+	if set.Len() != len(fences) || len(set.Names()) != len(fences) {
+		t.Fatalf("set holds %d fences, want %d", set.Len(), len(fences))
+	}
+	for i := 0; i < 2000; i++ {
+		var lat, lon float64 = 49.95 + random.Float64()*1.1, 7.95 + random.Float64()*1.1
+		var want []string
+		for name, fence := range fences {
+			if fence.Contains(lat, lon) {
+				want = append(want, name)
+			}
+		}
+		sort.Strings(want)
+		if got := set.Containing(lat, lon); len(got) != len(want) || len(want) > 0 && !reflect.DeepEqual(got, want) {
+			t.Fatalf("Containing(%v, %v) = %v, want %v", lat, lon, got, want)
+		}
+	}
+
+	for name := range fences {
+		set.Remove(name)
+	}
+	if len(set.byCell) != 0 {
+		t.Errorf("empty set still indexes %d cells", len(set.byCell))
+	}
+}
+
+func BenchmarkFenceSetContaining(b *testing.B) {
+	var set *FenceSet = NewFenceSet(nil)
+	for i := 0; i < 500; i++ {
+		var lat, lon float64 = 50 + float64(i%25)*0.01, 8 + float64(i/25)*0.01
+		set.Add(fmt.Sprint(i), NewGeofence(Circle{Center: Point{Lat: lat, Lon: lon}, Radius: 800}, 7))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set.Containing(50+float64(i%100)*0.0025, 8+float64(i%77)*0.0026)
+	}
+}
@@ -0,0 +1,112 @@
+package geomodel
+
+import "iter"
+
+// ProximityFetchAll returns every entity within maxDistance meters of
+// (lat, lon), ordered by distance as configured by WithResultOrder. Unlike
+// ProximityFetch it has no result cap: the circle is covered up front with
+// at most 64 cells no finer than maxResolution, or as configured by
+// WithAdaptiveCover, and all of them are searched. maxDistance must be
+// positive: Unlimited, which would enumerate every entity, yields no
+// results.
+func ProximityFetchAll(lat, lon, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) []SearchResult {
+	var options = newSearchOptions(opts)
+	defer options.finish()
+	var result []SearchResult = make([]SearchResult, 0)
+	if maxDistance <= 0 {
+		return result
+	}
+
+	var cells []string = options.coverCircle(lat, lon, maxDistance, maxResolution)
+	if options.debug {
+		options.logger.Debug("geomodel: searching circle covering", "cells", cells)
+	}
+	options.stats.Iterations++
+
+	var seen map[string]struct{} = make(map[string]struct{})
+	for _, entity := range runSearch(search, cells, options) {
+		if _, ok := seen[entity.Key()]; ok {
+			continue
+		}
+		seen[entity.Key()] = struct{}{}
+		if d := options.entityDistance(lat, lon, entity); d <= maxDistance {
+			result = append(result, SearchResult{entity, d})
+		}
+	}
+
+	sortResults(result, options)
+	return result
+}
+
+// ProximityScan streams the entities ProximityFetchAll would return, without
+// holding them in memory or sorting them. The covering is split into batches
+// as for ProximityFetchAll, but the batches are searched one at a time as the
+// sequence is consumed. Entities are filtered like those of ProximityFetchAll,
+// by WithinRegion, WithExcludeKeys and WithTimeWindow.
+func ProximityScan(lat, lon, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) iter.Seq[SearchResult] {
+	return func(yield func(SearchResult) bool) {
+		var options = newSearchOptions(opts)
+		defer options.finish()
+		if maxDistance <= 0 {
+			return
+		}
+
+		var cells []string = options.coverCircle(lat, lon, maxDistance, maxResolution)
+		var seen map[string]struct{} = make(map[string]struct{})
+		for _, batch := range splitCells(cells, options) {
+			if options.debug {
+				options.logger.Debug("geomodel: searching circle covering", "cells", batch)
+			}
+			options.stats.Iterations++
+			for _, entity := range runSearch(search, batch, options) {
+				if _, ok := seen[entity.Key()]; ok {
+					options.stats.DuplicatesDropped++
+					continue
+				}
+				seen[entity.Key()] = struct{}{}
+				if d := options.entityDistance(lat, lon, entity); d <= maxDistance {
+					if !yield(SearchResult{entity, d}) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// CoverCircle returns the cells ProximityFetchAll searches for the circle of
+// radius meters around center: at most 64 cells no finer than maxResolution,
+// or as configured by WithAdaptiveCover.
+func CoverCircle(center Point, radius float64, maxResolution int, opts ...Option) []string {
+	return newSearchOptions(opts).coverCircle(center.Lat, center.Lon, radius, maxResolution)
+}
+
+// CoverBoundingBox returns the cells BoundingBoxFetch searches for bbox: for
+// each of its parts on either side of the antimeridian, at most 64 cells no
+// finer than maxResolution, or as configured by WithAdaptiveCover.
+func CoverBoundingBox(bbox BoundingBox, maxResolution int, opts ...Option) []string {
+	var options = newSearchOptions(opts)
+	var cells []string
+	for _, part := range bbox.Split() {
+		cells = append(cells, options.coverBox(part, maxResolution)...)
+	}
+	return cells
+}
+
+// coverBox returns the cells searched for bbox, which must not cross the
+// antimeridian, as configured by options.
+func (o *searchOptions) coverBox(bbox BoundingBox, maxResolution int) []string {
+	if o.adaptiveDensity != nil {
+		return adaptiveCover(o.curve, bbox, o.adaptiveDensity, o.adaptiveThreshold, maxResolution)
+	}
+	return coverBox(o.curve, bbox, maxResolution)
+}
+
+// coverCircle returns the cells searched for the circle of radius meters
+// around (lat, lon), as configured by options.
+func (o *searchOptions) coverCircle(lat, lon, radius float64, maxResolution int) []string {
+	if o.adaptiveDensity != nil {
+		return adaptiveCover(o.curve, Circle{Center: Point{Lat: lat, Lon: lon}, Radius: radius}, o.adaptiveDensity, o.adaptiveThreshold, maxResolution)
+	}
+	return coverCircleBounded(o.curve, lat, lon, radius, maxResolution)
+}
@@ -0,0 +1,101 @@
+package geomodel
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestProximityFetchAll(t *testing.T) {
+	var places []LocationCapable
+	for i := 0; i < 50; i++ {
+		var lat = 50 + 0.001*float64(i)
+		places = append(places, Place{lat, 8, fmt.Sprint(i), GeoCells(lat, 8, 10)})
+	}
+
+	// 0.001 degrees of latitude is about 111 meters.
+	var all = ProximityFetchAll(50, 8, 2000, searchPlaces(places), 10)
+	var want int
+	for _, p := range places {
+		if Distance(50, 8, p.Latitude(), p.Longitude()) <= 2000 {
+			want++
+		}
+	}
+	if len(all) != want || want == 0 {
+		t.Fatalf("got %d results, want %d", len(all), want)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Distance < all[i-1].Distance {
+			t.Fatal("results not sorted by distance")
+		}
+	}
+
+	var streamed int
+	for r := range ProximityScan(50, 8, 2000, searchPlaces(places), 10, WithMaxCellsPerQuery(2)) {
+		if r.Distance > 2000 {
+			t.Errorf("streamed %q at %v meters", r.Entity.Key(), r.Distance)
+		}
+		streamed++
+	}
+	if streamed != want {
+		t.Errorf("streamed %d results, want %d", streamed, want)
+	}
+}
+
+func TestProximityScanFilters(t *testing.T) {
+	var places []LocationCapable
+	for i := 0; i < 50; i++ {
+		var lat = 50 + 0.001*float64(i)
+		places = append(places, Place{lat, 8, fmt.Sprint(i), GeoCells(lat, 8, 10)})
+	}
+
+	// The region holds the places from about 1100 meters north of the
+	// center on.
+	var region = NewBoundingBox(51, 9, 50.0105, 7)
+	var streamed []string
+	for r := range ProximityScan(50, 8, 2000, searchPlaces(places), 10, WithMaxCellsPerQuery(2), WithinRegion(region), WithExcludeKeys([]string{"15"})) {
+		if !region.Contains(r.Entity.Latitude(), r.Entity.Longitude()) || r.Entity.Key() == "15" {
+			t.Errorf("streamed %q at (%v, %v), outside the filters", r.Entity.Key(), r.Entity.Latitude(), r.Entity.Longitude())
+		}
+		streamed = append(streamed, r.Entity.Key())
+	}
+	var all = ProximityFetchAll(50, 8, 2000, searchPlaces(places), 10, WithinRegion(region), WithExcludeKeys([]string{"15"}))
+	if len(streamed) != len(all) || len(all) == 0 {
+		t.Errorf("streamed %v, want the %d results of ProximityFetchAll", streamed, len(all))
+	}
+}
+
+func TestCoverCircleBounded(t *testing.T) {
+	var cells []string = CoverCircle(Point{Lat: 50, Lon: 8}, 2000, 8)
+	if len(cells) == 0 || len(cells) > maxCoveringCells {
+		t.Fatalf("covering has %d cells", len(cells))
+	}
+	for _, lat := range []float64{49.985, 50, 50.015} {
+		var covered bool
+		for _, cell := range cells {
+			covered = covered || ComputeBox(cell).Contains(lat, 8)
+		}
+		if !covered {
+			t.Errorf("(%v, 8) is within the circle but not covered", lat)
+		}
+	}
+}
+
+func TestCoverBoundingBox(t *testing.T) {
+	// Each side of the antimeridian is covered separately.
+	var bbox BoundingBox = NewBoundingBox(1, -179, -1, 179)
+	var cells []string = CoverBoundingBox(bbox, 8)
+	if len(cells) == 0 || len(cells) > 2*maxCoveringCells {
+		t.Fatalf("covering has %d cells", len(cells))
+	}
+	var east, west bool
+	for _, cell := range cells {
+		var box BoundingBox = ComputeBox(cell)
+		if !box.Intersects(bbox) {
+			t.Errorf("cell %s outside %v", cell, bbox)
+		}
+		east, west = east || box.East() > 0, west || box.West() < 0
+	}
+	if !east || !west {
+		t.Errorf("covering %v misses a side of the antimeridian", cells)
+	}
+}
@@ -0,0 +1,24 @@
+package geomodel
+
+// FilterSearcher is implemented by repositories that can filter entities by
+// their attributes in storage, such as category=restaurant, instead of
+// returning every entity in the searched cells for the caller to discard.
+// The meaning of filter is up to the repository; see mongorepo.Query for
+// one that takes it as a MongoDB query filter.
+type FilterSearcher interface {
+	SearchWithFilter(cells []string, filter map[string]any) []LocationCapable
+}
+
+// Filtered returns a RepositorySearch forwarding filter to r with every
+// search, for use with ProximityFetch and the other fetch functions:
+//
+//	ProximityFetch(lat, lon, 10, 1000, Filtered(query, map[string]any{"category": "restaurant"}), 13)
+//
+// Searches stop as soon as they have found enough nearby entities, which
+// with a selective filter happens in far fewer cells than when filtering
+// the results afterwards.
+func Filtered(r FilterSearcher, filter map[string]any) RepositorySearch {
+	return func(cells []string) []LocationCapable {
+		return r.SearchWithFilter(cells, filter)
+	}
+}
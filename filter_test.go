@@ -0,0 +1,35 @@
+package geomodel
+
+import (
+	"fmt"
+	"testing"
+)
+
+// categories is a FilterSearcher over places whose keys are their category.
+type categories []LocationCapable
+
+func (c categories) SearchWithFilter(cells []string, filter map[string]any) []LocationCapable {
+	var found []LocationCapable
+	for _, entity := range indexPlaces(c)(cells) {
+		if category, ok := filter["category"]; !ok || entity.Key()[:1] == category {
+			found = append(found, entity)
+		}
+	}
+	return found
+}
+
+func TestFiltered(t *testing.T) {
+	var places categories
+	for i := 0; i < 20; i++ {
+		var lat = 50 + float64(i)/1000
+		places = append(places, Place{lat, 8, fmt.Sprint("ab"[i%2:i%2+1], i), GeoCells(lat, 8, 10)})
+	}
+	var results = ProximityFetch(50, 8, 3, 0, Filtered(places, map[string]any{"category": "b"}), 10)
+	if fmt.Sprint(keysOf(results)) != "[b1 b3 b5]" {
+		t.Errorf("got %v, want [b1 b3 b5]", keysOf(results))
+	}
+	results = ProximityFetch(50, 8, 3, 0, Filtered(places, nil), 10)
+	if fmt.Sprint(keysOf(results)) != "[a0 b1 a2]" {
+		t.Errorf("got %v without a filter, want [a0 b1 a2]", keysOf(results))
+	}
+}
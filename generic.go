@@ -0,0 +1,55 @@
+package geomodel
+
+// Located adapts a value of any type to LocationCapable for the generic
+// search functions. Options receiving entities, such as WithScorer and
+// WithTiebreaker, see the values of ProximityFetchT as Located values and
+// can recover them with Value:
+//
+//	WithScorer(func(entity LocationCapable, distance float64) float64 {
+//		return distance / entity.(Located[Shop]).Value().Rating
+//	})
+type Located[T any] struct {
+	value    T
+	lat, lon float64
+	key      string
+	// The curve and finest resolution of the geocells, computed on demand
+	// as few searches read them.
+	curve      Curve
+	resolution int
+}
+
+// Value returns the adapted value.
+func (l Located[T]) Value() T { return l.value }
+
+func (l Located[T]) Latitude() float64  { return l.lat }
+func (l Located[T]) Longitude() float64 { return l.lon }
+func (l Located[T]) Key() string        { return l.key }
+
+// Geocells returns the cells of the value's location on the search's curve,
+// from resolution 1 to the search's maximum resolution.
+func (l Located[T]) Geocells() []string {
+	return (&Indexer{Resolution: l.resolution, Curve: l.curve}).ComputeEntityCells(l)
+}
+
+// ProximityFetchT is ProximityFetch over values of any type T, so callers do
+// not need wrapper types implementing LocationCapable. loc returns the
+// latitude and longitude of a value and key its unique key.
+func ProximityFetchT[T any](lat, lon float64, maxResults int, maxDistance float64, search func([]string) []T, loc func(T) (float64, float64), key func(T) string, maxResolution int, opts ...Option) []T {
+	var curve Curve = newSearchOptions(opts).curve
+	var wrapped RepositorySearch = func(cells []string) []LocationCapable {
+		var values []T = search(cells)
+		var entities []LocationCapable = make([]LocationCapable, 0, len(values))
+		for _, v := range values {
+			var vLat, vLon = loc(v)
+			entities = append(entities, Located[T]{v, vLat, vLon, key(v), curve, maxResolution})
+		}
+		return entities
+	}
+
+	var results []LocationCapable = ProximityFetch(lat, lon, maxResults, maxDistance, wrapped, maxResolution, opts...)
+	var values []T = make([]T, 0, len(results))
+	for _, entity := range results {
+		values = append(values, entity.(Located[T]).value)
+	}
+	return values
+}
@@ -0,0 +1,24 @@
+package geomodel
+
+import "errors"
+
+// ErrAddressNotFound is returned by geocoders for addresses they cannot
+// resolve.
+var ErrAddressNotFound = errors.New("geomodel: address not found")
+
+// Geocoder resolves free-form addresses to coordinates. Implementations wrap
+// services such as Nominatim or the Google Geocoding API; the package itself
+// ships none, to stay free of external dependencies.
+type Geocoder interface {
+	Geocode(address string) (Point, error)
+}
+
+// ProximityFetchByAddress geocodes address and runs ProximityFetch around the
+// resulting point. Geocoding errors are returned unchanged.
+func ProximityFetchByAddress(address string, geocoder Geocoder, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) ([]LocationCapable, error) {
+	var p, err = geocoder.Geocode(address)
+	if err != nil {
+		return nil, err
+	}
+	return ProximityFetch(p.Lat, p.Lon, maxResults, maxDistance, search, maxResolution, opts...), nil
+}
@@ -0,0 +1,43 @@
+package geomodel
+
+import "math"
+
+// InitialBearing returns the initial bearing in degrees clockwise from north,
+// in [0, 360), of the great circle from p1 to p2. The bearing changes along
+// the way unless the path follows a meridian or the equator. It is 0 for
+// coincident points.
+func InitialBearing(p1, p2 Point) float64 {
+	var lat1, lat2 float64 = DegToRad(p1.Lat), DegToRad(p2.Lat)
+	var dLon float64 = DegToRad(p2.Lon - p1.Lon)
+	var y float64 = math.Sin(dLon) * math.Cos(lat2)
+	var x float64 = math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(RadToDeg(math.Atan2(y, x))+360, 360)
+}
+
+// Destination returns the point reached by travelling meters from p along
+// the great circle starting at bearingDeg degrees clockwise from north, on a
+// sphere of radius EARTH_RADIUS. Its longitude is wrapped into [-180, 180).
+func Destination(p Point, bearingDeg, meters float64) Point {
+	var lat1, lon1 float64 = DegToRad(p.Lat), DegToRad(p.Lon)
+	var bearing float64 = DegToRad(bearingDeg)
+	var delta float64 = meters / EARTH_RADIUS
+
+	var sinLat2 float64 = math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(bearing)
+	var lat2 float64 = math.Asin(math.Max(-1, math.Min(1, sinLat2)))
+	var lon2 float64 = lon1 + math.Atan2(math.Sin(bearing)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*sinLat2)
+	return Point{Lat: RadToDeg(lat2), Lon: wrapLon(RadToDeg(lon2))}
+}
+
+// Midpoint returns the point halfway between p1 and p2 along the great
+// circle joining them. Its longitude is wrapped into [-180, 180).
+func Midpoint(p1, p2 Point) Point {
+	var lat1, lon1 float64 = DegToRad(p1.Lat), DegToRad(p1.Lon)
+	var lat2 float64 = DegToRad(p2.Lat)
+	var dLon float64 = DegToRad(p2.Lon - p1.Lon)
+
+	var bx float64 = math.Cos(lat2) * math.Cos(dLon)
+	var by float64 = math.Cos(lat2) * math.Sin(dLon)
+	var lat float64 = math.Atan2(math.Sin(lat1)+math.Sin(lat2), math.Hypot(math.Cos(lat1)+bx, by))
+	var lon float64 = lon1 + math.Atan2(by, math.Cos(lat1)+bx)
+	return Point{Lat: RadToDeg(lat), Lon: wrapLon(RadToDeg(lon))}
+}
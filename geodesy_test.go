@@ -0,0 +1,56 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInitialBearing(t *testing.T) {
+	var tests = []struct {
+		p1, p2 Point
+		want   float64
+	}{
+		{Point{Lat: 0, Lon: 0}, Point{Lat: 1, Lon: 0}, 0},
+		{Point{Lat: 0, Lon: 0}, Point{Lat: 0, Lon: 1}, 90},
+		{Point{Lat: 0, Lon: 0}, Point{Lat: -1, Lon: 0}, 180},
+		{Point{Lat: 0, Lon: 0}, Point{Lat: 0, Lon: -1}, 270},
+		{Point{Lat: 0, Lon: 179.5}, Point{Lat: 0, Lon: -179.5}, 90},
+		// Paris to London is about 330 degrees.
+		{Point{Lat: 48.8566, Lon: 2.3522}, Point{Lat: 51.5074, Lon: -0.1278}, 330.0},
+	}
+	for _, test := range tests {
+		if got := InitialBearing(test.p1, test.p2); math.Abs(got-test.want) > 0.5 {
+			t.Errorf("InitialBearing(%v, %v) = %f, want %f", test.p1, test.p2, got, test.want)
+		}
+	}
+}
+
+func TestDestination(t *testing.T) {
+	var origin = Point{Lat: 50, Lon: 8}
+	for _, bearing := range []float64{0, 45, 90, 135, 180, 270, 359} {
+		var p Point = Destination(origin, bearing, 2000)
+		if d := Distance(origin.Lat, origin.Lon, p.Lat, p.Lon); math.Abs(d-2000) > 1e-6 {
+			t.Errorf("Destination at %v degrees is %f m away, want 2000", bearing, d)
+		}
+		if b := InitialBearing(origin, p); math.Abs(math.Remainder(b-bearing, 360)) > 1e-6 {
+			t.Errorf("Destination at %v degrees has bearing %f", bearing, b)
+		}
+	}
+
+	if p := Destination(Point{Lat: 0, Lon: 179.99}, 90, 5000); p.Lon > -179 || p.Lon < -180 {
+		t.Errorf("Destination across the antimeridian = %v", p)
+	}
+}
+
+func TestMidpoint(t *testing.T) {
+	var p1, p2 = Point{Lat: 50, Lon: 8}, Point{Lat: 52.52, Lon: 13.405}
+	var m Point = Midpoint(p1, p2)
+	var d1, d2 = Distance(p1.Lat, p1.Lon, m.Lat, m.Lon), Distance(m.Lat, m.Lon, p2.Lat, p2.Lon)
+	if math.Abs(d1-d2) > 1e-6 || math.Abs(d1+d2-Distance(p1.Lat, p1.Lon, p2.Lat, p2.Lon)) > 1e-6 {
+		t.Errorf("Midpoint(%v, %v) = %v is %f and %f m away", p1, p2, m, d1, d2)
+	}
+
+	if m := Midpoint(Point{Lat: 0, Lon: 179}, Point{Lat: 0, Lon: -179}); math.Abs(m.Lat) > 1e-9 || math.Abs(math.Abs(m.Lon)-180) > 1e-9 {
+		t.Errorf("Midpoint across the antimeridian = %v", m)
+	}
+}
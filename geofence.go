@@ -0,0 +1,134 @@
+package geomodel
+
+import (
+	"iter"
+	"math"
+)
+
+// Geofence answers whether points lie inside a region, using a covering of
+// the region by cells computed once when the fence is created. Cells wholly
+// inside the region are interior cells: points in them are inside without
+// further checks. Cells of the finest resolution crossed by the region's
+// edge are boundary cells, for which the region's exact geometry decides.
+// Points in neither are outside.
+//
+// A Geofence is immutable and safe for concurrent use.
+type Geofence struct {
+	region     Region
+	resolution int
+	interior   *CellTrie
+	boundary   *CellTrie
+}
+
+// boxContainer is implemented by regions that can tell whether they contain
+// a box entirely, which lets Geofence find interior cells. Coverings of other
+// regions consist of boundary cells only.
+type boxContainer interface {
+	containsBox(bbox BoundingBox) bool
+}
+
+// NewGeofence returns a fence for region, a Circle, BoundingBox, Polygon or
+// other Region, covered with cells no finer than resolution. Finer coverings
+// hug the region's edge more tightly, so fewer points need the exact check,
+// at the cost of more boundary cells.
+func NewGeofence(region Region, resolution int) *Geofence {
+	var f *Geofence = &Geofence{
+		region:     region,
+		resolution: max(1, min(resolution, MAX_GEOCELL_RESOLUTION)),
+		interior:   NewCellTrie(),
+		boundary:   NewCellTrie(),
+	}
+	var container, _ = region.(boxContainer)
+	var cover func(cell string)
+	cover = func(cell string) {
+		var bbox BoundingBox = ComputeBox(cell)
+		if !region.Intersects(bbox) {
+			return
+		}
+		if container != nil && container.containsBox(bbox) {
+			f.interior.Insert(cell)
+			return
+		}
+		if len(cell) == f.resolution {
+			f.boundary.Insert(cell)
+			return
+		}
+		for i := 0; i < len(GEOCELL_ALPHABET); i++ {
+			cover(cell + GEOCELL_ALPHABET[i:i+1])
+		}
+	}
+	for i := 0; i < len(GEOCELL_ALPHABET); i++ {
+		cover(GEOCELL_ALPHABET[i : i+1])
+	}
+	return f
+}
+
+// Contains reports whether (lat, lon) lies inside the fence. It looks up the
+// point's cell in the covering, in time proportional to the resolution, and
+// checks the exact geometry only for points in boundary cells.
+func (f *Geofence) Contains(lat, lon float64) bool {
+	var cell string = GeoCell(lat, lon, f.resolution)
+	if f.interior.ContainsPointViaAncestors(cell) {
+		return true
+	}
+	return f.boundary.Contains(cell) && f.region.Contains(lat, lon)
+}
+
+// Region returns the region the fence was created for.
+func (f *Geofence) Region() Region { return f.region }
+
+// Resolution returns the resolution of the fence's boundary cells.
+func (f *Geofence) Resolution() int { return f.resolution }
+
+// InteriorCells returns the cells wholly inside the fence, in lexicographic
+// order.
+func (f *Geofence) InteriorCells() iter.Seq[string] { return f.interior.All() }
+
+// BoundaryCells returns the cells crossed by the fence's edge, in
+// lexicographic order.
+func (f *Geofence) BoundaryCells() iter.Seq[string] { return f.boundary.All() }
+
+// containsBox reports whether c contains every point of bbox: the point of
+// bbox farthest from the center is the one nearest to its antipode.
+func (c Circle) containsBox(bbox BoundingBox) bool {
+	var farthest float64 = math.Pi*EARTH_RADIUS - distanceToBox(-c.Center.Lat, wrapLon(c.Center.Lon+180), bbox)
+	return farthest <= c.Radius
+}
+
+// containsBox reports whether bbox contains every point of other.
+func (bbox BoundingBox) containsBox(other BoundingBox) bool {
+	if other.latSW < bbox.latSW || other.latNE > bbox.latNE {
+		return false
+	}
+	var width, otherWidth float64 = bbox.lonNE - bbox.lonSW, other.lonNE - other.lonSW
+	if width < 0 {
+		width += 360
+	}
+	if otherWidth < 0 {
+		otherWidth += 360
+	}
+	return width >= 360 || math.Mod(other.lonSW-bbox.lonSW+360, 360)+otherWidth <= width
+}
+
+// containsBox reports whether p contains every point of bbox: its corners
+// are inside and no edge of p enters it. Vertices on the box's edge count as
+// entering it, so that the answer errs towards false.
+func (p Polygon) containsBox(bbox BoundingBox) bool {
+	var corners []Point = []Point{{bbox.latSW, bbox.lonSW}, {bbox.latSW, bbox.lonNE}, {bbox.latNE, bbox.lonNE}, {bbox.latNE, bbox.lonSW}}
+	for _, c := range corners {
+		if !p.Contains(c.Lat, c.Lon) {
+			return false
+		}
+	}
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		if bbox.Contains(p[i].Lat, p[i].Lon) {
+			return false
+		}
+		for k, l := 0, len(corners)-1; k < len(corners); l, k = k, k+1 {
+			if segmentsIntersect(p[j], p[i], corners[l], corners[k]) {
+				return false
+			}
+		}
+	}
+	return true
+}
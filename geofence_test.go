@@ -0,0 +1,70 @@
+package geomodel
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGeofence(t *testing.T) {
+	var regions = map[string]Region{
+		"circle":       Circle{Center: Point{Lat: 50, Lon: 8}, Radius: 5000},
+		"northern":     Circle{Center: Point{Lat: 78.2, Lon: 15.6}, Radius: 3000},
+		"box":          NewBoundingBox(50.05, 8.1, 49.98, 7.95),
+		"antimeridian": NewBoundingBox(10.1, -179.9, 9.9, 179.8),
+		"polygon":      Polygon{{Lat: 50, Lon: 8}, {Lat: 50.06, Lon: 8.02}, {Lat: 50.01, Lon: 8.03}, {Lat: 50.05, Lon: 8.1}, {Lat: 49.98, Lon: 8.08}},
+	}
+	var random *rand.Rand = rand.New(rand.NewSource(1))
+	for name, region := range regions {
+		var fence *Geofence = NewGeofence(region, 7)
+		var interior, boundary int
+		for range fence.InteriorCells() {
+			interior++
+		}
+		for cell := range fence.BoundaryCells() {
+			if len(cell) != 7 {
+				t.Errorf("%s: boundary cell %q is not at resolution 7", name, cell)
+			}
+			boundary++
+		}
+		if interior == 0 || boundary == 0 {
+			t.Errorf("%s: %d interior and %d boundary cells", name, interior, boundary)
+		}
+
+		// Points sampled around the region's bounding box.
+		var bbox BoundingBox
+		switch r := region.(type) {
+		case Circle:
+			bbox = circleBox(r.Center.Lat, r.Center.Lon, r.Radius*1.2)
+		case BoundingBox:
+			bbox = NewBoundingBox(r.latNE+0.02, r.lonNE+0.02, r.latSW-0.02, r.lonSW-0.02)
+		case Polygon:
+			bbox = NewBoundingBox(50.08, 8.12, 49.96, 7.98)
+		}
+		var width float64 = bbox.lonNE - bbox.lonSW
+		if width < 0 {
+			width += 360
+		}
+		var inside int
+		for i := 0; i < 20000; i++ {
+			var lat float64 = bbox.latSW + random.Float64()*(bbox.latNE-bbox.latSW)
+			var lon float64 = wrapLon(bbox.lonSW + random.Float64()*width)
+			var want bool = region.Contains(lat, lon)
+			if got := fence.Contains(lat, lon); got != want {
+				t.Fatalf("%s: Contains(%v, %v) = %v, want %v", name, lat, lon, got, want)
+			}
+			if want {
+				inside++
+			}
+		}
+		if inside == 0 {
+			t.Errorf("%s: no sampled point inside", name)
+		}
+	}
+}
+
+func BenchmarkGeofenceContains(b *testing.B) {
+	var fence *Geofence = NewGeofence(Circle{Center: Point{Lat: 50, Lon: 8}, Radius: 5000}, 7)
+	for i := 0; i < b.N; i++ {
+		fence.Contains(50+float64(i%100)*0.0005, 8+float64(i%37)*0.001)
+	}
+}
@@ -0,0 +1,177 @@
+package geomodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedRegion is returned when encoding a Geofence whose region is
+// not a Circle, BoundingBox or Polygon.
+var ErrUnsupportedRegion = errors.New("geomodel: geofence region cannot be encoded")
+
+// geofenceVersion is the version of the JSON form of geofences. Decoding
+// rejects later versions.
+const geofenceVersion = 1
+
+// geofenceJSON is the JSON representation of a Geofence.
+type geofenceJSON struct {
+	Version    int         `json:"version"`
+	Region     *regionJSON `json:"region"`
+	Resolution int         `json:"resolution"`
+	Interior   []string    `json:"interior"`
+	Boundary   []string    `json:"boundary"`
+}
+
+// regionJSON is the JSON representation of the region of a Geofence, with
+// the members of its type.
+type regionJSON struct {
+	Type string `json:"type"`
+	// A circle.
+	Center *[2]float64 `json:"center,omitempty"`
+	Radius float64     `json:"radius,omitempty"`
+	// A box.
+	Box *BoundingBox `json:"box,omitempty"`
+	// A polygon.
+	Points [][2]float64 `json:"points,omitempty"`
+}
+
+// MarshalJSON encodes the fence's region and covering, so that the fence can
+// be stored and restored without recomputing the covering:
+//
+//	{"version": 1,
+//	 "region": {"type": "circle", "center": [50, 8], "radius": 1000},
+//	 "resolution": 7, "interior": ["u0vjx", ...], "boundary": ["u0vjwzz", ...]}
+//
+// Points are [lat, lon] pairs in degrees; boxes are encoded as by
+// BoundingBox.MarshalJSON under "box", and polygons as their vertices under
+// "points". Fences over other regions return ErrUnsupportedRegion.
+func (f *Geofence) MarshalJSON() ([]byte, error) {
+	var region *regionJSON = &regionJSON{}
+	switch r := f.region.(type) {
+	case Circle:
+		region.Type, region.Center, region.Radius = "circle", &[2]float64{r.Center.Lat, r.Center.Lon}, r.Radius
+	case BoundingBox:
+		region.Type, region.Box = "box", &r
+	case Polygon:
+		region.Type, region.Points = "polygon", make([][2]float64, len(r))
+		for i, p := range r {
+			region.Points[i] = [2]float64{p.Lat, p.Lon}
+		}
+	default:
+		return nil, ErrUnsupportedRegion
+	}
+
+	var v geofenceJSON = geofenceJSON{Version: geofenceVersion, Region: region, Resolution: f.resolution}
+	v.Interior = make([]string, 0, f.interior.Len())
+	for cell := range f.interior.All() {
+		v.Interior = append(v.Interior, cell)
+	}
+	v.Boundary = make([]string, 0, f.boundary.Len())
+	for cell := range f.boundary.All() {
+		v.Boundary = append(v.Boundary, cell)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the object MarshalJSON produces, taking the covering
+// as stored. Cells must consist of geocell characters, with boundary cells
+// at the fence's resolution and interior cells no finer.
+func (f *Geofence) UnmarshalJSON(data []byte) error {
+	var v geofenceJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version < 1 || v.Version > geofenceVersion {
+		return fmt.Errorf("geomodel: unsupported geofence version %d", v.Version)
+	}
+	if v.Region == nil {
+		return errors.New("geomodel: geofence has no region")
+	}
+	if v.Resolution < 1 || v.Resolution > MAX_GEOCELL_RESOLUTION {
+		return fmt.Errorf("geomodel: geofence resolution %d out of range", v.Resolution)
+	}
+
+	var region Region
+	switch v.Region.Type {
+	case "circle":
+		if v.Region.Center == nil {
+			return errors.New("geomodel: geofence circle has no center")
+		}
+		region = Circle{Center: Point{Lat: v.Region.Center[0], Lon: v.Region.Center[1]}, Radius: v.Region.Radius}
+	case "box":
+		if v.Region.Box == nil {
+			return errors.New("geomodel: geofence box has no bounds")
+		}
+		region = *v.Region.Box
+	case "polygon":
+		var polygon Polygon = make(Polygon, len(v.Region.Points))
+		for i, p := range v.Region.Points {
+			polygon[i] = Point{Lat: p[0], Lon: p[1]}
+		}
+		region = polygon
+	default:
+		return fmt.Errorf("geomodel: unknown geofence region type %q", v.Region.Type)
+	}
+
+	var decoded Geofence = Geofence{region: region, resolution: v.Resolution, interior: NewCellTrie(), boundary: NewCellTrie()}
+	for _, cell := range v.Interior {
+		if !validFenceCell(cell, 1, v.Resolution) {
+			return fmt.Errorf("geomodel: invalid geofence interior cell %q", cell)
+		}
+		decoded.interior.Insert(cell)
+	}
+	for _, cell := range v.Boundary {
+		if !validFenceCell(cell, v.Resolution, v.Resolution) {
+			return fmt.Errorf("geomodel: invalid geofence boundary cell %q", cell)
+		}
+		decoded.boundary.Insert(cell)
+	}
+	*f = decoded
+	return nil
+}
+
+// validFenceCell reports whether cell is a geocell with a resolution between
+// minResolution and maxResolution.
+func validFenceCell(cell string, minResolution, maxResolution int) bool {
+	return len(cell) >= minResolution && len(cell) <= maxResolution && Cell(cell).Valid()
+}
+
+// fenceSetJSON is the JSON representation of a FenceSet.
+type fenceSetJSON struct {
+	Version int                  `json:"version"`
+	Fences  map[string]*Geofence `json:"fences"`
+}
+
+// MarshalJSON encodes the fences of the set by name, each as by
+// Geofence.MarshalJSON:
+//
+//	{"version": 1, "fences": {"depot": {...}, "downtown": {...}}}
+func (s *FenceSet) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(fenceSetJSON{Version: geofenceVersion, Fences: s.fences})
+}
+
+// UnmarshalJSON decodes the object MarshalJSON produces into s, replacing
+// its fences. The zero FenceSet may be decoded into.
+func (s *FenceSet) UnmarshalJSON(data []byte) error {
+	var v fenceSetJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version < 1 || v.Version > geofenceVersion {
+		return fmt.Errorf("geomodel: unsupported fence set version %d", v.Version)
+	}
+	for name, fence := range v.Fences {
+		if fence == nil {
+			return fmt.Errorf("geomodel: fence %q is null", name)
+		}
+	}
+
+	var decoded *FenceSet = NewFenceSet(v.Fences)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fences, s.byCell, s.resolution = decoded.fences, decoded.byCell, decoded.resolution
+	return nil
+}
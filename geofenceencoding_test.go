@@ -0,0 +1,66 @@
+package geomodel
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGeofenceJSON(t *testing.T) {
+	var fences = map[string]*Geofence{
+		"circle":  NewGeofence(Circle{Center: Point{Lat: 50, Lon: 8}, Radius: 2000}, 6),
+		"box":     NewGeofence(NewBoundingBox(10.1, -179.9, 9.9, 179.8), 5),
+		"polygon": NewGeofence(Polygon{{Lat: 50, Lon: 8}, {Lat: 50.06, Lon: 8.02}, {Lat: 49.98, Lon: 8.08}}, 7),
+	}
+	for name, fence := range fences {
+		data, err := json.Marshal(fence)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var decoded Geofence
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(decoded.Region(), fence.Region()) || decoded.Resolution() != fence.Resolution() {
+			t.Errorf("%s: decoded region %v at %d, want %v at %d", name, decoded.Region(), decoded.Resolution(), fence.Region(), fence.Resolution())
+		}
+		if !slices.Equal(slices.Collect(decoded.InteriorCells()), slices.Collect(fence.InteriorCells())) ||
+			!slices.Equal(slices.Collect(decoded.BoundaryCells()), slices.Collect(fence.BoundaryCells())) {
+			t.Errorf("%s: decoded covering differs", name)
+		}
+	}
+
+	var set *FenceSet = NewFenceSet(fences)
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded FenceSet
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Names(), set.Names()) || !reflect.DeepEqual(decoded.Containing(50.01, 8.02), set.Containing(50.01, 8.02)) {
+		t.Errorf("decoded set holds %v, want %v", decoded.Names(), set.Names())
+	}
+
+	var custom = NewGeofence(struct{ Circle }{Circle{Center: Point{Lat: 50, Lon: 8}, Radius: 10}}, 5)
+	if _, err := json.Marshal(custom); !errors.Is(err, ErrUnsupportedRegion) {
+		t.Errorf("encoding a custom region returned %v, want ErrUnsupportedRegion", err)
+	}
+
+	for _, bad := range []string{
+		`{"version": 2, "region": {"type": "circle", "center": [0, 0], "radius": 1}, "resolution": 3}`,
+		`{"version": 1, "region": {"type": "hexagon"}, "resolution": 3}`,
+		`{"version": 1, "region": {"type": "circle", "center": [0, 0], "radius": 1}, "resolution": 3, "boundary": ["s0"]}`,
+		`{"version": 1, "region": {"type": "circle", "center": [0, 0], "radius": 1}, "resolution": 3, "interior": ["s0a"]}`,
+		`{"version": 1, "region": {"type": "box"}, "resolution": 3}`,
+	} {
+		var fence Geofence
+		if err := json.Unmarshal([]byte(bad), &fence); err == nil || !strings.HasPrefix(err.Error(), "geomodel: ") {
+			t.Errorf("decoding %s returned %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package geomodel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func geoJSONPoint(lat, lon float64, properties map[string]interface{}) geoJSONFeature {
+	return geoJSONFeature{"Feature", geoJSONGeometry{"Point", []float64{lon, lat}}, properties}
+}
+
+// WriteResultsGeoJSON writes results as a GeoJSON FeatureCollection of
+// points. The first feature is origin, with the property "origin" set to
+// true; each result follows with its "key", its distance from origin in
+// meters as "distance_m", and its finest geocell as "cell".
+func WriteResultsGeoJSON(w io.Writer, origin Point, results []SearchResult) error {
+	var collection geoJSONFeatureCollection = geoJSONFeatureCollection{"FeatureCollection", make([]geoJSONFeature, 0, len(results)+1)}
+	collection.Features = append(collection.Features, geoJSONPoint(origin.Lat, origin.Lon, map[string]interface{}{"origin": true}))
+
+	for _, r := range results {
+		var properties map[string]interface{} = map[string]interface{}{
+			"key":        r.Entity.Key(),
+			"distance_m": r.Distance,
+		}
+		if cells := r.Entity.Geocells(); len(cells) > 0 {
+			properties["cell"] = cells[len(cells)-1]
+		}
+		collection.Features = append(collection.Features, geoJSONPoint(r.Entity.Latitude(), r.Entity.Longitude(), properties))
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
+
+// geoJSONPolygon returns bbox as a Polygon feature, or as a MultiPolygon of
+// its parts if it crosses the antimeridian, as RFC 7946 requires. Rings run
+// counterclockwise.
+func geoJSONPolygon(bbox BoundingBox, properties map[string]interface{}) geoJSONFeature {
+	var polygons [][][][]float64
+	for _, part := range bbox.Split() {
+		var ring [][]float64
+		for _, p := range part.ring() {
+			ring = append(ring, []float64{p[0], p[1]})
+		}
+		polygons = append(polygons, [][][]float64{ring})
+	}
+	if len(polygons) == 1 {
+		return geoJSONFeature{"Feature", geoJSONGeometry{"Polygon", polygons[0]}, properties}
+	}
+	return geoJSONFeature{"Feature", geoJSONGeometry{"MultiPolygon", polygons}, properties}
+}
+
+// CellToGeoJSON returns cell as a GeoJSON polygon Feature with the cell id
+// as its "cell" property, for drawing coverings and search frontiers in
+// tools such as Leaflet or Mapbox.
+func CellToGeoJSON(cell string) ([]byte, error) {
+	return json.Marshal(geoJSONPolygon(ComputeBox(cell), map[string]interface{}{"cell": cell}))
+}
+
+// CellsToFeatureCollection returns cells as a GeoJSON FeatureCollection of
+// the polygons CellToGeoJSON returns.
+func CellsToFeatureCollection(cells []string) ([]byte, error) {
+	var collection geoJSONFeatureCollection = geoJSONFeatureCollection{"FeatureCollection", make([]geoJSONFeature, 0, len(cells))}
+	for _, cell := range cells {
+		collection.Features = append(collection.Features, geoJSONPolygon(ComputeBox(cell), map[string]interface{}{"cell": cell}))
+	}
+	return json.Marshal(collection)
+}
+
+// BoundingBoxToGeoJSON returns bbox as a GeoJSON Feature without properties:
+// a Polygon, or a MultiPolygon of its two parts if it crosses the
+// antimeridian.
+func BoundingBoxToGeoJSON(bbox BoundingBox) ([]byte, error) {
+	return json.Marshal(geoJSONPolygon(bbox, map[string]interface{}{}))
+}
+
+// GeoJSONFeature is a point feature read by LoadGeoJSON: an IndexRecord
+// carrying the feature's properties.
+type GeoJSONFeature struct {
+	IndexRecord
+	Properties map[string]interface{}
+}
+
+// Relocate implements Relocatable, keeping the properties.
+func (f *GeoJSONFeature) Relocate(lat, lon float64, geocells []string) LocationCapable {
+	return &GeoJSONFeature{IndexRecord{f.ID, lat, lon, geocells}, f.Properties}
+}
+
+// LoadGeoJSON reads a GeoJSON FeatureCollection of Point features, keyed by
+// the property keyProperty or, if keyProperty is empty, by the feature id,
+// with geocells computed up to resolution. Numeric keys are taken as
+// written, so that ids beyond the precision of float64 stay exact. Features
+// with another geometry type, without a key or with coordinates failing
+// Point.Validate are an error.
+func LoadGeoJSON(r io.Reader, keyProperty string, resolution int) ([]*GeoJSONFeature, error) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			ID       json.RawMessage `json:"id"`
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("geomodel: GeoJSON type %q is not FeatureCollection", collection.Type)
+	}
+
+	var features []*GeoJSONFeature = make([]*GeoJSONFeature, 0, len(collection.Features))
+	for i, f := range collection.Features {
+		if f.Geometry.Type != "Point" || len(f.Geometry.Coordinates) < 2 {
+			return nil, fmt.Errorf("geomodel: GeoJSON feature %d is not a point", i)
+		}
+		var id json.RawMessage = f.ID
+		if keyProperty != "" {
+			id = f.Properties[keyProperty]
+		}
+		var key, ok = geoJSONKey(id)
+		if !ok {
+			return nil, fmt.Errorf("geomodel: GeoJSON feature %d has no key", i)
+		}
+		var properties map[string]interface{}
+		if f.Properties != nil {
+			properties = make(map[string]interface{}, len(f.Properties))
+			for name, raw := range f.Properties {
+				var value interface{}
+				if err := json.Unmarshal(raw, &value); err != nil {
+					return nil, err
+				}
+				properties[name] = value
+			}
+		}
+
+		var lat, lon float64 = f.Geometry.Coordinates[1], f.Geometry.Coordinates[0]
+		if err := (Point{Lat: lat, Lon: lon}).Validate(); err != nil {
+			return nil, fmt.Errorf("%w in GeoJSON feature %d", err, i)
+		}
+		features = append(features, &GeoJSONFeature{IndexRecord{key, lat, lon, GeoCells(lat, lon, resolution)}, properties})
+	}
+	return features, nil
+}
+
+// geoJSONKey returns the key a feature id or key property holds: a string,
+// or a number as written.
+func geoJSONKey(raw json.RawMessage) (string, bool) {
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var id interface{}
+	if decoder.Decode(&id) != nil {
+		return "", false
+	}
+	switch id := id.(type) {
+	case string:
+		return id, true
+	case json.Number:
+		return id.String(), true
+	}
+	return "", false
+}
+
+// LoadGeoJSONInto reads features as LoadGeoJSON does and inserts them into
+// index, returning how many it inserted. Nothing is inserted if the input
+// is invalid.
+func LoadGeoJSONInto(index *GeoIndex, r io.Reader, keyProperty string, resolution int) (int, error) {
+	var features, err = LoadGeoJSON(r, keyProperty, resolution)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range features {
+		index.Insert(f)
+	}
+	return len(features), nil
+}
@@ -0,0 +1,139 @@
+package geomodel
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteResultsGeoJSON(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "1", GeoCells(50, 8, 10)}, Place{50.01, 8.01, "2", GeoCells(50.01, 8.01, 10)}}
+	var results = ProximityFetchResults(50, 8, 2, 0, searchPlaces(places), 10)
+
+	var buf bytes.Buffer
+	if err := WriteResultsGeoJSON(&buf, Point{50, 8}, results); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type != "FeatureCollection" || len(decoded.Features) != 3 {
+		t.Fatalf("unexpected collection %s", buf.String())
+	}
+	if decoded.Features[0].Properties["origin"] != true {
+		t.Errorf("first feature is not the origin: %v", decoded.Features[0].Properties)
+	}
+	var second = decoded.Features[2]
+	if second.Properties["key"] != "2" || second.Properties["cell"] != GeoCell(50.01, 8.01, 10) || second.Properties["distance_m"].(float64) <= 0 {
+		t.Errorf("unexpected properties %v", second.Properties)
+	}
+	if second.Geometry.Coordinates[0] != 8.01 || second.Geometry.Coordinates[1] != 50.01 {
+		t.Errorf("coordinates not in [lon, lat] order: %v", second.Geometry.Coordinates)
+	}
+}
+
+func TestCellsToFeatureCollection(t *testing.T) {
+	var cells = []string{"u1", "u1m"}
+	data, err := CellsToFeatureCollection(cells)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates [][][2]float64
+			}
+			Properties map[string]string
+		}
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type != "FeatureCollection" || len(decoded.Features) != 2 {
+		t.Fatalf("unexpected collection %s", data)
+	}
+	for i, f := range decoded.Features {
+		var bbox = ComputeBox(cells[i])
+		var ring = f.Geometry.Coordinates[0]
+		if f.Geometry.Type != "Polygon" || f.Properties["cell"] != cells[i] || len(ring) != 5 || ring[0] != ring[4] {
+			t.Errorf("feature %d: %+v", i, f)
+		}
+		if ring[0] != [2]float64{bbox.lonSW, bbox.latSW} || ring[2] != [2]float64{bbox.lonNE, bbox.latNE} {
+			t.Errorf("feature %d ring %v does not match %+v", i, ring, bbox)
+		}
+	}
+
+	single, err := CellToGeoJSON("u1")
+	if err != nil || !bytes.Contains(data, single) {
+		t.Errorf("CellToGeoJSON(%q) = %s, not the collection's first feature", "u1", single)
+	}
+
+	wrapped, err := BoundingBoxToGeoJSON(NewBoundingBox(10, -170, 0, 170))
+	if err != nil || !bytes.Contains(wrapped, []byte(`"MultiPolygon"`)) {
+		t.Errorf("BoundingBoxToGeoJSON across the antimeridian = %s", wrapped)
+	}
+}
+
+func TestLoadGeoJSON(t *testing.T) {
+	const input = `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "id": 7, "geometry": {"type": "Point", "coordinates": [8, 50]}, "properties": {"name": "a"}},
+		{"type": "Feature", "id": "b", "geometry": {"type": "Point", "coordinates": [8.001, 50.001, 120]}, "properties": {"name": "b"}}
+	]}`
+
+	features, err := LoadGeoJSON(strings.NewReader(input), "", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 2 || features[0].Key() != "7" || features[1].Latitude() != 50.001 || features[1].Properties["name"] != "b" {
+		t.Fatalf("LoadGeoJSON returned %+v", features)
+	}
+	if !reflect.DeepEqual(features[0].Geocells(), GeoCells(50, 8, 8)) {
+		t.Errorf("geocells %v, want %v", features[0].Geocells(), GeoCells(50, 8, 8))
+	}
+
+	// Ids beyond the precision of float64 keep their digits.
+	const big = `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "id": 9007199254740993, "geometry": {"type": "Point", "coordinates": [8, 50]}, "properties": {"osm": 12345678901234567891, "n": 1.5}}
+	]}`
+	if features, err := LoadGeoJSON(strings.NewReader(big), "", 8); err != nil || features[0].Key() != "9007199254740993" || features[0].Properties["n"] != 1.5 {
+		t.Errorf("LoadGeoJSON with a large id returned %+v, %v", features, err)
+	}
+	if features, err := LoadGeoJSON(strings.NewReader(big), "osm", 8); err != nil || features[0].Key() != "12345678901234567891" {
+		t.Errorf("LoadGeoJSON with a large key property returned %+v, %v", features, err)
+	}
+
+	var index = NewGeoIndex()
+	if n, err := LoadGeoJSONInto(index, strings.NewReader(input), "name", 8); err != nil || n != 2 {
+		t.Fatalf("LoadGeoJSONInto = %d, %v", n, err)
+	}
+	if found := index.Nearest(50, 8, 1); len(found) != 1 || found[0].Entity.Key() != "a" {
+		t.Errorf("Nearest after loading returned %v", found)
+	}
+
+	for _, bad := range []string{
+		`{"type": "Feature"}`,
+		`{"type": "FeatureCollection", "features": [{"geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}}]}`,
+		`{"type": "FeatureCollection", "features": [{"geometry": {"type": "Point", "coordinates": [0, 0]}}]}`,
+		`{"type": "FeatureCollection", "features": [{"id": "a", "geometry": {"type": "Point", "coordinates": [8, 95]}}]}`,
+	} {
+		if _, err := LoadGeoJSON(strings.NewReader(bad), "", 8); err == nil {
+			t.Errorf("LoadGeoJSON(%s) succeeded", bad)
+		}
+	}
+}
@@ -16,17 +16,25 @@
 
 package geomodel
 
+import "iter"
 import "math"
-import "sort"
-import "log"
-import "strings"
+import "slices"
+
+import "github.com/alternaDev/geomodel/internal/curve"
 
 const (
 	GEOCELL_GRID_SIZE      = 4
 	GEOCELL_ALPHABET       = "0123456789bcdefghjkmnpqrstuvwxyz"
 	MAX_GEOCELL_RESOLUTION = 13 // The maximum *practical* geocell resolution.
+	EARTH_RADIUS           = 6378135 // Radius used for distance computations, in meters.
+
+	// Unlimited passed as maxDistance disables the distance limit of a search.
+	Unlimited = 0.0
 )
 
+// noDirection is the direction of the first, containing cell of a search.
+var noDirection = []int{0, 0}
+
 var (
 	NORTHWEST = []int{-1, 1}
 	NORTH     = []int{0, 1}
@@ -67,174 +75,237 @@ func (a ByDistance) Len() int           { return len(a) }
 func (a ByDistance) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByDistance) Less(i, j int) bool { return a[i].second < a[j].second }
 
+// RepositorySearch returns the entities indexed under any of the given
+// cells. Searches reuse the cells slice once the call returns, so it must
+// not be kept.
 type RepositorySearch func([]string) []LocationCapable
 
+// Point is a latitude/longitude pair in degrees.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// SearchResult is an entity found by a search and its distance in meters
+// from the search origin.
+type SearchResult struct {
+	Entity   LocationCapable
+	Distance float64
+}
+
 func GeoHash(lat, lon float64, resolution int) string {
 	return GeoCell(lat, lon, resolution)
 }
 
+// DecodeGeoHash returns the center of the box ComputeBox returns for hash.
 func DecodeGeoHash(hash string) (float64, float64) {
-	latMin := -90.0
-	latMax := 90.0
-	lonMin := -180.0
-	lonMax := 180.0
-	even := true
-	for i := 0; i < len(hash); i++ {
-		chr := hash[i]
-		index := strings.Index(GEOCELL_ALPHABET, string(chr))
-
-		for n := 4; n >= 0; n-- {
-			bitN := index >> uint(n) & 1;
-			if even {
-				lonMid := (lonMin + lonMax) / 2
-				if bitN == 1 {
-					lonMin = lonMid
-				} else {
-					lonMax = lonMid
-				}
-			} else {
-				latMid := (latMin + latMax) / 2
-				if bitN == 1 {
-					latMin = latMid
-				} else {
-					latMax = latMid
-				}
-			}
-			even = !even
-		}
+	var bbox BoundingBox = ComputeBox(hash)
+	return (bbox.latSW + bbox.latNE) / 2.0, (bbox.lonSW + bbox.lonNE) / 2.0
+}
+
+// GeoCell returns the cell of resolution containing (lat, lon). A point on
+// the edge between cells belongs to the cell north or east of it, so that
+// every service encoding it agrees at every resolution; see Curve.
+//
+// Latitudes outside [-90, 90] are clamped into them and other longitudes
+// wrapped into [-180, 180), so that longitudes in [0, 360) map to their
+// cells. NaN or infinite coordinates and resolutions below 1 yield "".
+// GeoCellChecked reports such input as an error instead.
+func GeoCell(lat, lon float64, resolution int) string {
+	lat, lon, ok := clampCoordinates(lat, lon)
+	if !ok {
+		return ""
 	}
+	return curve.Geohash.Encode(lat, lon, resolution)
+}
 
-	return (latMin + latMax) / 2.0, (lonMin + lonMax) / 2.0
+// AppendGeoCell appends GeoCell(lat, lon, resolution) to dst and returns the
+// extended buffer, without allocating if dst has enough capacity. It is
+// meant for bulk indexing, where a single buffer is reused across points.
+func AppendGeoCell(dst []byte, lat, lon float64, resolution int) []byte {
+	lat, lon, ok := clampCoordinates(lat, lon)
+	if !ok {
+		return dst
+	}
+	return curve.AppendGeohash(dst, lat, lon, resolution)
 }
 
-func GeoCell(lat, lon float64, resolution int) string {
-	resolution = resolution + 1
-
-	north := 90.0
-	south := -90.0
-	east := 180.0
-	west := -180.0
-	isEven := true
-	mid := 0.0
-	ch := 0
-	bit := 0
-	bits := []int{16, 8, 4, 2, 1}
-	cell := make([]byte, resolution, resolution)
-
-	i := 0
-
-	for i = 0; i < resolution; {
-		if isEven {
-			mid = (west + east) / 2
-			if lon > mid {
-				ch |= bits[bit]
-				west = mid
-			} else {
-				east = mid
-			}
-		} else {
-			mid = (south + north) / 2
-			if lat > mid {
-				ch |= bits[bit]
-				south = mid
-			} else {
-				north = mid
+// clampCoordinates clamps lat into the range GeoCell encodes, reporting
+// false if lat is NaN or lon is NaN or infinite. Longitudes are wrapped by
+// the curve.
+func clampCoordinates(lat, lon float64) (float64, float64, bool) {
+	if math.IsNaN(lat) || math.IsNaN(lon) || math.IsInf(lon, 0) {
+		return 0, 0, false
+	}
+	return min(max(lat, -90), 90), lon, true
+}
+
+// GeoCellPrefixes yields the cells GeoCells(lat, lon, resolution) would
+// return, coarsest first. All of them share the memory of a single encoded
+// cell, so iterating costs one allocation instead of one per level.
+func GeoCellPrefixes(lat, lon float64, resolution int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		var cell string = GeoCell(lat, lon, resolution)
+		for i := 1; i <= len(cell); i++ {
+			if !yield(cell[:i]) {
+				return
 			}
 		}
-		isEven = !isEven
-		if bit < 4 {
-			bit = bit + 1
-		} else {
-			cell[i] = GEOCELL_ALPHABET[ch]
-			i = i + 1
-			bit = 0
-			ch = 0
-		}
 	}
-
-	cell = cell[:len(cell)-1]
-
-	return string(cell)
 }
 
+// GeoCells returns the cells containing (lat, lon) at resolutions 1 to
+// resolution, coarsest first.
 func GeoCells(lat, lon float64, resolution int) []string {
-	g := GeoCell(lat, lon, resolution)
-	cells := make([]string, len(g), len(g))
-	for i := 0; i < resolution; i++ {
-		cells[i] = g[0 : i+1]
+	return AppendGeoCells(make([]string, 0, max(resolution, 0)), lat, lon, resolution)
+}
+
+// AppendGeoCells appends GeoCells(lat, lon, resolution) to dst and returns
+// the extended slice. The cell is encoded once and every prefix shares its
+// memory, so only the cell itself is allocated if dst has enough capacity.
+func AppendGeoCells(dst []string, lat, lon float64, resolution int) []string {
+	if resolution <= 0 {
+		return dst
+	}
+	var buf [MAX_GEOCELL_RESOLUTION]byte
+	var cell string = string(AppendGeoCell(buf[:0], lat, lon, resolution))
+	for i := 1; i <= len(cell); i++ {
+		dst = append(dst, cell[:i])
 	}
-	return cells
+	return dst
 }
 
+// Distance returns the great-circle distance in meters between two points on
+// a sphere of radius EARTH_RADIUS. It is the default metric of searches and
+// uses the haversine formula, which unlike the spherical law of cosines stays
+// accurate for points less than a meter apart.
 func Distance(lat1, lon1, lat2, lon2 float64) float64 {
-	var p1lat = DegToRad(lat1)
-	var p1lon = DegToRad(lon1)
-	var p2lat = DegToRad(lat2)
-	var p2lon = DegToRad(lon2)
-	return 6378135 * math.Acos(math.Sin(p1lat)*math.Sin(p2lat)+math.Cos(p1lat)*math.Cos(p2lat)*math.Cos(p2lon-p1lon))
+	return DistanceHaversine(lat1, lon1, lat2, lon2)
 }
 
 func DistanceSortedEdges(cells []string, lat, lon float64) []IntArrayDoubleTuple {
-	var boxes []BoundingBox = make([]BoundingBox, 0, len(cells))
-	for _, cell := range cells {
-		boxes = append(boxes, ComputeBox(cell))
-	}
+	var edges [4]IntArrayDoubleTuple = distanceSortedEdges(ComputeBox, Distance, cells, lat, lon)
+	return edges[:]
+}
 
+// distanceSortedEdges returns the directions of the edges of the area of
+// cells with their distances from (lat, lon), nearest first, in an array so
+// that searches computing them every iteration do not allocate. box returns
+// the bounding box of a cell.
+func distanceSortedEdges(box func(cell string) BoundingBox, distance DistanceFunc, cells []string, lat, lon float64) [4]IntArrayDoubleTuple {
 	var maxNorth float64 = -math.MaxFloat64
 	var maxEast float64 = -math.MaxFloat64
 	var maxSouth float64 = -math.MaxFloat64
 	var maxWest float64 = -math.MaxFloat64
 
-	for _, box := range boxes {
-		maxNorth = math.Max(maxNorth, box.latNE)
-		maxEast = math.Max(maxEast, box.lonNE)
-		maxSouth = math.Max(maxSouth, box.latSW)
-		maxWest = math.Max(maxWest, box.lonSW)
+	for _, cell := range cells {
+		var bbox BoundingBox = box(cell)
+		maxNorth = math.Max(maxNorth, bbox.latNE)
+		maxEast = math.Max(maxEast, bbox.lonNE)
+		maxSouth = math.Max(maxSouth, bbox.latSW)
+		maxWest = math.Max(maxWest, bbox.lonSW)
 	}
 
-	result := make([]IntArrayDoubleTuple, 4)
-	result[0] = IntArrayDoubleTuple{SOUTH, Distance(maxSouth, lon, lat, lon)}
-	result[1] = IntArrayDoubleTuple{NORTH, Distance(maxNorth, lon, lat, lon)}
-	result[2] = IntArrayDoubleTuple{WEST, Distance(lat, maxWest, lat, lon)}
-	result[3] = IntArrayDoubleTuple{EAST, Distance(maxSouth, maxEast, lat, lon)}
+	var result [4]IntArrayDoubleTuple = [4]IntArrayDoubleTuple{
+		{SOUTH, distance(maxSouth, lon, lat, lon)},
+		{NORTH, distance(maxNorth, lon, lat, lon)},
+		{WEST, distance(lat, maxWest, lat, lon)},
+		{EAST, distance(maxSouth, maxEast, lat, lon)},
+	}
 
-	sort.Sort(ByDistanceIA(result))
+	// A stable insertion sort, as sort.Sort does for so few elements.
+	for i := 1; i < len(result); i++ {
+		for j := i; j > 0 && result[j].second < result[j-1].second; j-- {
+			result[j], result[j-1] = result[j-1], result[j]
+		}
+	}
 
 	return result
 }
 
+// ComputeBox returns the bounding box of cell as encoded by GeoCell. Each
+// character holds five geohash bits, halving the longitude and latitude
+// ranges in turn, so that a character splits its parent into 8x4 or 4x8
+// children rather than the 4x4 grid of GEOCELL_GRID_SIZE.
 func ComputeBox(cell string) BoundingBox {
+	return computeBox(curve.Geohash, cell)
+}
+
+func computeBox(c Curve, cell string) BoundingBox {
 	var bbox BoundingBox
 	if cell == "" {
 		return bbox
 	}
 
-	bbox = NewBoundingBox(90.0, 180.0, -90.0, -180.0)
-	for len(cell) > 0 {
-		var subcellLonSpan float64 = (bbox.lonNE - bbox.lonSW) / GEOCELL_GRID_SIZE
-		var subcellLatSpan float64 = (bbox.latNE - bbox.latSW) / GEOCELL_GRID_SIZE
+	south, west, north, east := c.Bounds(cell)
+	return NewBoundingBox(north, east, south, west)
+}
 
-		var l []int = SubdivXY(rune(cell[0]))
-		var x int = l[0]
-		var y int = l[1]
+// ProximityFetch returns up to maxResults entities nearest to (lat, lon)
+// within maxDistance meters, ordered by distance. A maxDistance of Unlimited
+// (or any non-positive value) disables the distance limit.
+func ProximityFetch(lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) []LocationCapable {
+	var results []SearchResult = ProximityFetchResults(lat, lon, maxResults, maxDistance, search, maxResolution, opts...)
+	var result []LocationCapable = make([]LocationCapable, 0, len(results))
+	for _, r := range results {
+		result = append(result, r.Entity)
+	}
+	return result
+}
 
-		bbox = NewBoundingBox(bbox.latSW+subcellLatSpan*(float64(y)+1),
-			bbox.lonSW+subcellLonSpan*(float64(x)+1),
-			bbox.latSW+subcellLatSpan*float64(y),
-			bbox.lonSW+subcellLonSpan*float64(x))
-		cell = cell[1:]
+// ProximityFetchResults performs the same search as ProximityFetch and
+// returns each entity together with its distance from (lat, lon).
+func ProximityFetchResults(lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) []SearchResult {
+	return proximityFetch(nil, new(searchBuffers), lat, lon, maxResults, maxDistance, search, maxResolution, opts)
+}
+
+// proximityFetch performs the search of ProximityFetchResults in the buffers
+// of buf and appends the results to dst, allocating a result slice if dst is
+// nil.
+func proximityFetch(dst []SearchResult, buf *searchBuffers, lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts []Option) []SearchResult {
+	var options *searchOptions = &buf.options
+	options.reset(opts)
+	defer options.finish()
+	if options.trace != nil {
+		options.trace.start(Point{lat, lon}, maxResults, maxDistance, options)
 	}
 
-	return bbox
+	if dst == nil {
+		dst = make([]SearchResult, 0, min(max(maxResults, 0), maxPreallocatedResults))
+	}
+	var start int = len(dst)
+	dst = proximitySearch(dst, buf, options, lat, lon, maxResults, maxDistance, search, maxResolution)
+	sortResults(dst[start:], options)
+	if options.trace != nil {
+		options.trace.Results = slices.Clone(dst[start:])
+	}
+	return dst
 }
 
-func ProximityFetch(lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int) []LocationCapable {
-	var results []LocationComparableTuple
+// proximitySearch runs the search loop of proximityFetch with options that
+// are already set up, and appends the results within maxDistance to dst
+// nearest first. Several searches may share options, whose statistics then
+// add up.
+func proximitySearch(dst []SearchResult, buf *searchBuffers, options *searchOptions, lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int) []SearchResult {
+	var logger = options.logger
+	if maxDistance <= Unlimited {
+		maxDistance = math.Inf(1)
+	}
+
+	if maxResults <= 0 {
+		return dst
+	}
+
+	var results *topK = &buf.results
+	results.reset(maxResults, options.tiebreaker)
+	defer results.release()
 
 	// The current search geocell containing the lat,lon.
-	var curContainingGeocell string = GeoCell(lat, lon, maxResolution)
+	var curContainingGeocell string = options.curve.Encode(lat, lon, options.initialResolution(lat, lon, maxResults, maxResolution))
 
-	var searchedCells []string = make([]string, 0)
+	// The cells already passed to the repository.
+	var searchedCells map[string]struct{} = getKeySet()
+	defer putKeySet(searchedCells)
 
 	/*
 	 * The currently-being-searched geocells.
@@ -243,86 +314,104 @@ func ProximityFetch(lat, lon float64, maxResults int, maxDistance float64, searc
 	 * Must always be of the same resolution.
 	 * Must always form a rectangular region.
 	 * One of these must be equal to the cur_containing_geocell.
+	 * Frontiers hold at most four cells; coarsening alternates between the
+	 * two halves of one buffer.
 	 */
-	var curGeocells []string = make([]string, 0)
-	curGeocells = append(curGeocells, curContainingGeocell)
+	var curGeocells []string = append(buf.frontier[:0:8], curContainingGeocell)
+	var nextGeocells []string = buf.frontier[8:8:16]
+	defer clear(buf.frontier[:])
 	var closestPossibleNextResultDist float64 = 0
 
-	var noDirection = []int{0, 0}
-
-	var sortedEdgeDistances []IntArrayDoubleTuple
-	sortedEdgeDistances = append(sortedEdgeDistances, IntArrayDoubleTuple{noDirection, 0})
+	var sortedEdgeDistances [4]IntArrayDoubleTuple
+	sortedEdgeDistances[0] = IntArrayDoubleTuple{noDirection, 0}
 
 	for len(curGeocells) != 0 {
 		closestPossibleNextResultDist = sortedEdgeDistances[0].second
-		if maxDistance > 0 && closestPossibleNextResultDist > maxDistance {
+		if closestPossibleNextResultDist > maxDistance {
 			break
 		}
 
-		var curTempUnique = deleteRecords(curGeocells, searchedCells)
-
-		var curGeocellsUnique = curTempUnique
-
-		var newResultEntities = search(curGeocellsUnique)
-
-		searchedCells = append(searchedCells, curGeocells...)
+		var curGeocellsUnique []string = buf.unique[:0]
+		for _, cell := range curGeocells {
+			if _, ok := searchedCells[cell]; cell != "" && !ok {
+				curGeocellsUnique = append(curGeocellsUnique, cell)
+			}
+		}
 
-		// Begin storing distance from the search result entity to the
-		// search center along with the search result itself, in a tuple.
-		var newResults []LocationComparableTuple = make([]LocationComparableTuple, 0, len(newResultEntities))
-		for _, entity := range newResultEntities {
-			newResults = append(newResults, LocationComparableTuple{entity, Distance(lat, lon, entity.Latitude(), entity.Longitude())})
+		if options.exhausted(len(curGeocellsUnique)) {
+			break
 		}
+		options.stats.Iterations++
 
-		sort.Sort(ByDistance(newResults))
-		newResults = newResults[0:int(math.Min(float64(maxResults), float64(len(newResults))))]
+		if options.debug {
+			logger.Debug("geomodel: searching cells", "cells", curGeocellsUnique)
+		}
+		var newResultEntities = runSearch(search, curGeocellsUnique, options)
+		var step *TraceStep
+		if options.trace != nil {
+			options.trace.Steps = append(options.trace.Steps, TraceStep{Frontier: slices.Clone(curGeocells), Searched: slices.Clone(curGeocellsUnique)})
+			step = &options.trace.Steps[len(options.trace.Steps)-1]
+		}
+		for _, cell := range curGeocellsUnique {
+			searchedCells[cell] = struct{}{}
+		}
+		buf.unique = clearCells(curGeocellsUnique)
 
-		// Merge new_results into results
-		for _, tuple := range newResults {
-			// contains method will check if entity in tuple have same key
-			if !contains(results, tuple) {
-				results = append(results, tuple)
+		// Keep the nearest maxResults entities, storing their distance from
+		// the search center along with them.
+		for _, entity := range newResultEntities {
+			var d float64 = options.entityDistance(lat, lon, entity)
+			if step != nil {
+				step.Found = append(step.Found, SearchResult{entity, d})
+			}
+			if options.strictDistance && d > maxDistance {
+				continue
+			}
+			if !results.offer(LocationComparableTuple{entity, d}) {
+				options.stats.DuplicatesDropped++
 			}
 		}
 
-		sort.Sort(ByDistance(results))
-		results = results[0:int(math.Min(float64(maxResults), float64(len(results))))]
-
-		sortedEdgeDistances = DistanceSortedEdges(curGeocells, lat, lon)
+		sortedEdgeDistances = distanceSortedEdges(options.box, options.distance, curGeocells, lat, lon)
 
-		if len(results) == 0 || len(curGeocells) == 4 {
+		if results.Len() == 0 || len(curGeocells) > 2 {
 			/* Either no results (in which case we optimize by not looking at
 			   adjacents, go straight to the parent) or we've searched 4 adjacent
 			   geocells, in which case we should now search the parents of those
-			   geocells.*/
-			curContainingGeocell = curContainingGeocell[:int(math.Max(float64(len(curContainingGeocell))-1, float64(0)))]
+			   geocells. Any other frontier larger than two cells is coarsened
+			   as well, so that every iteration makes progress.*/
+			curContainingGeocell = curContainingGeocell[:max(len(curContainingGeocell)-1, 0)]
 
-			if len(curContainingGeocell) == 0 {
+			if len(curContainingGeocell) == 0 || len(curContainingGeocell) < options.minResolution {
 				break
 			}
 
-			var oldCurGeocells []string = curGeocells
-			curGeocells = make([]string, 0)
-
-			for _, cell := range oldCurGeocells {
+			nextGeocells = nextGeocells[:0]
+			for _, cell := range curGeocells {
 				if len(cell) > 0 {
-					var newCell string = cell[:len(cell)-1]
-					i := sort.SearchStrings(curGeocells, newCell)
-					if !(i < len(curGeocells) && curGeocells[i] == newCell) {
-						curGeocells = append(curGeocells, newCell)
+					if parent := cell[:len(cell)-1]; !slices.Contains(nextGeocells, parent) {
+						nextGeocells = append(nextGeocells, parent)
 					}
 				}
 			}
+			curGeocells, nextGeocells = nextGeocells, curGeocells
 
 			if len(curGeocells) == 0 {
 				break
 			}
+			if options.debug {
+				logger.Debug("geomodel: coarsening to parent cells", "cells", curGeocells)
+			}
 		} else if len(curGeocells) == 1 {
 			var nearestEdge []int = sortedEdgeDistances[0].first
-			curGeocells = append(curGeocells, Adjacent(curGeocells[0], nearestEdge))
+			curGeocells = append(curGeocells, options.curve.Neighbor(curGeocells[0], nearestEdge[0], nearestEdge[1]))
+			if options.debug {
+				logger.Debug("geomodel: expanding towards nearest edge", "edge", nearestEdge, "cells", curGeocells)
+			}
 		} else if len(curGeocells) == 2 {
-			var nearestEdge []int = DistanceSortedEdges([]string{curContainingGeocell}, lat, lon)[0].first
-			var perpendicularNearestEdge []int = []int{0, 0}
+			var containingEdges [4]IntArrayDoubleTuple = distanceSortedEdges(options.box, options.distance, []string{curContainingGeocell}, lat, lon)
+			var nearestEdge []int = containingEdges[0].first
+			var perpendicularNearestEdge []int = noDirection
 
 			if nearestEdge[0] == 0 {
 				for _, edgeDistance := range sortedEdgeDistances {
@@ -340,41 +429,73 @@ func ProximityFetch(lat, lon float64, maxResults int, maxDistance float64, searc
 				}
 			}
 
-			var tempCells []string = make([]string, 0)
-
-			for _, cell := range curGeocells {
-				tempCells = append(tempCells, Adjacent(cell, perpendicularNearestEdge))
+			for _, cell := range curGeocells[:2] {
+				curGeocells = append(curGeocells, options.curve.Neighbor(cell, perpendicularNearestEdge[0], perpendicularNearestEdge[1]))
 			}
 
-			curGeocells = append(curGeocells, tempCells...)
+			if options.debug {
+				logger.Debug("geomodel: expanding towards perpendicular edge", "edge", perpendicularNearestEdge, "cells", curGeocells)
+			}
 		}
 
-		if len(results) < maxResults {
+		if !results.full() {
 			// Keep Searchin!
-			log.Printf("%d results found but want %d results, continuing...", len(results), maxResults)
+			if options.debug {
+				logger.Debug("geomodel: not enough results, continuing", "found", results.Len(), "want", maxResults)
+			}
 			continue
 		}
 
 		// Found things!
-		log.Printf("%d results found.", len(results))
-
-		var currentFarthestReturnableResultDist float64 = Distance(lat, lon, results[maxResults-1].first.Latitude(), results[maxResults-1].first.Longitude())
+		var currentFarthestReturnableResultDist float64 = results.farthest()
 
 		if closestPossibleNextResultDist >= currentFarthestReturnableResultDist {
 			// Done
-			log.Printf("DONE next result at least %d away, current farthest is %d dist.", closestPossibleNextResultDist, currentFarthestReturnableResultDist)
+			if options.debug {
+				logger.Debug("geomodel: search done", "found", results.Len(), "nextResultDistance", closestPossibleNextResultDist, "farthestResultDistance", currentFarthestReturnableResultDist)
+			}
 			break
 		}
 
-		log.Printf("next result at least %d away, current farthest is %d dist", closestPossibleNextResultDist, currentFarthestReturnableResultDist)
+		if options.debug {
+			logger.Debug("geomodel: closer results may exist, continuing", "found", results.Len(), "nextResultDistance", closestPossibleNextResultDist, "farthestResultDistance", currentFarthestReturnableResultDist)
+		}
 
 	}
 
+	for _, entry := range results.sorted() {
+		if entry.second <= maxDistance {
+			dst = append(dst, SearchResult{entry.first, entry.second})
+		}
+	}
+	return dst
+}
+
+// BoundingBoxFetch returns the entities returned by search that lie inside
+// bbox. Boxes crossing the antimeridian are split and each part is covered
+// and searched separately; each part is covered with at most 64 cells no finer
+// than maxResolution, or as configured by WithAdaptiveCover.
+func BoundingBoxFetch(bbox BoundingBox, search RepositorySearch, maxResolution int, opts ...Option) []LocationCapable {
+	var options = newSearchOptions(opts)
+	defer options.finish()
+
 	var result []LocationCapable = make([]LocationCapable, 0)
+	var seen map[string]struct{} = make(map[string]struct{})
+	for _, part := range bbox.Split() {
+		options.stats.Iterations++
+		var cells []string = options.coverBox(part, maxResolution)
+		if options.debug {
+			options.logger.Debug("geomodel: searching bounding box cells", "cells", cells)
+		}
 
-	for _, entry := range results[0:int(math.Min(float64(maxResults), float64(len(results))))] {
-		if maxDistance == 0 || entry.second < maxDistance {
-			result = append(result, entry.first)
+		for _, entity := range runSearch(search, cells, options) {
+			if _, ok := seen[entity.Key()]; ok {
+				continue
+			}
+			if part.Contains(entity.Latitude(), entity.Longitude()) {
+				seen[entity.Key()] = struct{}{}
+				result = append(result, entity)
+			}
 		}
 	}
 
@@ -1,9 +1,18 @@
 package geomodel
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"reflect"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 /*type LocationCapable interface {
@@ -44,6 +53,19 @@ func TestDecodeGeoHash(t *testing.T) {
 	log.Printf("DecodeLat: %f, DecodeLon: %f", lat, lon)
 }
 
+func TestComputeBox(t *testing.T) {
+	// The geohash bits of "u" are 11010: east, north, west, north, east.
+	if box, want := ComputeBox("u"), NewBoundingBox(90, 45, 45, 0); box != want {
+		t.Errorf("ComputeBox(u) = %v, want %v", box, want)
+	}
+	if box := ComputeBox(GeoCell(53.12869, 8.18976, 6)); !box.Contains(53.12869, 8.18976) {
+		t.Errorf("box %v does not contain the point its cell was encoded from", box)
+	}
+	if lat, lon := DecodeGeoHash("u"); lat != 67.5 || lon != 22.5 {
+		t.Errorf("DecodeGeoHash(u) = %v, %v, want the center of its box", lat, lon)
+	}
+}
+
 func TestProximityFetch(t *testing.T) {
 
 	// Example Places
@@ -81,3 +103,489 @@ func TestProximityFetch(t *testing.T) {
 
 	// ProximityFetch(lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int) []LocationCapable
 }
+
+// searchPlaces returns a RepositorySearch over places matching any of the
+// requested cells.
+func searchPlaces(places []LocationCapable) RepositorySearch {
+	return func(cells []string) []LocationCapable {
+		var result []LocationCapable = make([]LocationCapable, 0)
+		for _, place := range places {
+		match:
+			for _, c := range place.Geocells() {
+				for _, cell := range cells {
+					if c == cell {
+						result = append(result, place)
+						break match
+					}
+				}
+			}
+		}
+		return result
+	}
+}
+
+func TestProximityFetchLogger(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "1", GeoCells(50, 8, 10)}, Place{50.01, 8.01, "2", GeoCells(50.01, 8.01, 10)}}
+
+	var buf bytes.Buffer
+	var logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ProximityFetch(50, 8, 2, 0, searchPlaces(places), 10)
+	if buf.Len() != 0 {
+		t.Fatalf("expected silent search, got %q", buf.String())
+	}
+
+	ProximityFetch(50, 8, 2, 0, searchPlaces(places), 10, WithLogger(logger))
+	if buf.Len() == 0 {
+		t.Fatal("expected debug trace from WithLogger")
+	}
+}
+
+func TestProximityFetchMaxCellsPerQuery(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "1", GeoCells(50, 8, 10)}, Place{50.3, 8.3, "2", GeoCells(50.3, 8.3, 10)}, Place{49.7, 7.7, "3", GeoCells(49.7, 7.7, 10)}}
+	var search = searchPlaces(places)
+
+	var maxCells int
+	var limited RepositorySearch = func(cells []string) []LocationCapable {
+		if len(cells) > maxCells {
+			maxCells = len(cells)
+		}
+		return search(cells)
+	}
+
+	var want = ProximityFetch(50, 8, 3, 0, search, 10)
+	var got = ProximityFetch(50, 8, 3, 0, limited, 10, WithMaxCellsPerQuery(1))
+	if maxCells != 1 {
+		t.Errorf("search received %d cells, want at most 1", maxCells)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Key() != want[i].Key() {
+			t.Errorf("result %d is %q, want %q", i, got[i].Key(), want[i].Key())
+		}
+	}
+}
+
+func TestBoundingBoxSplit(t *testing.T) {
+	if parts := NewBoundingBox(10, 20, -10, -20).Split(); len(parts) != 1 {
+		t.Errorf("regular box split into %d parts", len(parts))
+	}
+
+	var parts = NewBoundingBox(10, -170, -10, 170).Split()
+	if len(parts) != 2 {
+		t.Fatalf("wrapping box split into %d parts, want 2", len(parts))
+	}
+	if parts[0].lonSW != 170 || parts[0].lonNE != 180 || parts[1].lonSW != -180 || parts[1].lonNE != -170 {
+		t.Errorf("unexpected parts %+v", parts)
+	}
+}
+
+func TestBoundingBoxFetch(t *testing.T) {
+	var places = []LocationCapable{Place{0, 179.5, "east", GeoCells(0, 179.5, 10)}, Place{0, -179.5, "west", GeoCells(0, -179.5, 10)}, Place{0, 0, "far", GeoCells(0, 0, 10)}}
+
+	var result = BoundingBoxFetch(NewBoundingBox(1, -179, -1, 179), searchPlaces(places), 10)
+	if len(result) != 2 {
+		t.Fatalf("got %d results, want 2", len(result))
+	}
+	for _, entity := range result {
+		if entity.Key() == "far" {
+			t.Errorf("entity outside the box returned")
+		}
+	}
+}
+
+func TestProximityFetchParallelism(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "1", GeoCells(50, 8, 10)}, Place{50.3, 8.3, "2", GeoCells(50.3, 8.3, 10)}, Place{49.7, 7.7, "3", GeoCells(49.7, 7.7, 10)}}
+
+	var want = ProximityFetch(50, 8, 3, 0, searchPlaces(places), 10)
+	var got = ProximityFetch(50, 8, 3, 0, searchPlaces(places), 10, WithParallelism(4), WithMaxCellsPerQuery(1))
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Key() != want[i].Key() {
+			t.Errorf("result %d is %q, want %q", i, got[i].Key(), want[i].Key())
+		}
+	}
+
+	// Hold the calls of a step searching several cells until two of them
+	// run at once; a serial search would never release them.
+	var trace SearchTrace
+	ProximityFetch(50, 8, 3, 0, searchPlaces(places), 10, WithTrace(&trace))
+	var step = slices.IndexFunc(trace.Steps, func(s TraceStep) bool { return len(s.Searched) >= 2 })
+	if step < 0 {
+		t.Fatal("no step searched several cells")
+	}
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	var held = rendezvous(t, searchPlaces(places), 2, trace.Steps[step].Searched)
+	var counting RepositorySearch = func(cells []string) []LocationCapable {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		return held(cells)
+	}
+	ProximityFetch(50, 8, 3, 0, counting, 10, WithParallelism(2), WithMaxCellsPerQuery(1))
+	if maxInFlight != 2 {
+		t.Errorf("%d searches ran at once, want 2", maxInFlight)
+	}
+}
+
+// rendezvous returns a search holding calls for any of cells until n such
+// calls are in flight together, and otherwise passing them to search. It
+// fails the test if that does not happen within a few seconds, so that a
+// search running them one at a time does not hang.
+func rendezvous(t *testing.T, search RepositorySearch, n int, cells []string) RepositorySearch {
+	var mu sync.Mutex
+	var arrived int
+	var all = make(chan struct{})
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return func(batch []string) []LocationCapable {
+		if slices.ContainsFunc(batch, func(cell string) bool { return slices.Contains(cells, cell) }) {
+			mu.Lock()
+			if arrived++; arrived == n {
+				close(all)
+			}
+			mu.Unlock()
+			select {
+			case <-all:
+			case <-ctx.Done():
+				t.Errorf("calls for %v did not reach %d in flight", cells, n)
+			}
+		}
+		return search(batch)
+	}
+}
+
+type renamedCurve struct {
+	Curve
+}
+
+func (renamedCurve) Name() string { return "geohash-test" }
+
+func TestRegisterCurve(t *testing.T) {
+	var geohash, ok = LookupCurve("geohash")
+	if !ok {
+		t.Fatal("geohash curve not registered")
+	}
+	if err := RegisterCurve(geohash); err == nil {
+		t.Error("registering a duplicate name succeeded")
+	}
+	if err := RegisterCurve(renamedCurve{geohash}); err != nil {
+		t.Fatal(err)
+	}
+
+	var c, _ = LookupCurve("geohash-test")
+	var places = []LocationCapable{Place{50, 8, "1", GeoCells(50, 8, 10)}}
+	if result := ProximityFetch(50, 8, 1, 0, searchPlaces(places), 10, WithCurve(c)); len(result) != 1 {
+		t.Errorf("got %d results with a registered curve, want 1", len(result))
+	}
+}
+
+func TestProximityFetchResolutionBounds(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "near", GeoCells(50, 8, 10)}, Place{52, 13, "far", GeoCells(52, 13, 10)}}
+
+	var calls []string
+	var search = searchPlaces(places)
+	var recording RepositorySearch = func(cells []string) []LocationCapable {
+		calls = append(calls, cells...)
+		return search(cells)
+	}
+
+	ProximityFetch(50.0001, 8.0001, 1, 0, recording, 10, WithStartResolution(6))
+	for _, cell := range calls {
+		if len(cell) > 6 {
+			t.Errorf("searched cell %q finer than the start resolution", cell)
+		}
+	}
+
+	calls = nil
+	if result := ProximityFetch(0, 0, 1, 0, recording, 10, WithMinResolution(5)); len(result) != 0 {
+		t.Errorf("got %d results from an empty region, want 0", len(result))
+	}
+	for _, cell := range calls {
+		if len(cell) < 5 {
+			t.Errorf("searched cell %q coarser than the minimum resolution", cell)
+		}
+	}
+}
+
+func TestProximityFetchDensityStats(t *testing.T) {
+	var places []LocationCapable
+	for i := 0; i < 5; i++ {
+		var lat = 20 + 0.5*float64(i)
+		places = append(places, Place{lat, 20, fmt.Sprint(i), GeoCells(lat, 20, 10)})
+	}
+
+	var calls int
+	var search = searchPlaces(places)
+	var counting RepositorySearch = func(cells []string) []LocationCapable {
+		calls++
+		return search(cells)
+	}
+
+	var stats = NewMemoryDensityStats()
+	var first = ProximityFetch(20.1, 20, 3, 0, counting, 10, WithDensityStats(stats))
+	var firstCalls = calls
+
+	calls = 0
+	var second = ProximityFetch(20.1, 20, 3, 0, counting, 10, WithDensityStats(stats))
+	if calls >= firstCalls {
+		t.Errorf("second search made %d repository calls, first made %d", calls, firstCalls)
+	}
+	if len(first) != len(second) {
+		t.Errorf("adaptive search found %d results, want %d", len(second), len(first))
+	}
+}
+
+func TestProximityFetchT(t *testing.T) {
+	type store struct {
+		Name     string
+		Lat, Lon float64
+	}
+	var stores = []store{{"a", 50, 8}, {"b", 50.001, 8}, {"c", 51, 9}}
+
+	var search = func(cells []string) []store {
+		var found []store
+		for _, s := range stores {
+			for _, cell := range cells {
+				if strings.HasPrefix(GeoCell(s.Lat, s.Lon, 10), cell) {
+					found = append(found, s)
+					break
+				}
+			}
+		}
+		return found
+	}
+
+	var result = ProximityFetchT(50, 8, 2, 0, search,
+		func(s store) (float64, float64) { return s.Lat, s.Lon },
+		func(s store) string { return s.Name }, 10)
+	if len(result) != 2 || result[0].Name != "a" || result[1].Name != "b" {
+		t.Errorf("got %+v, want stores a and b", result)
+	}
+
+	// Options see the values, and their geocells.
+	var seen []store
+	var cells []string
+	result = ProximityFetchT(50, 8, 2, 0, search,
+		func(s store) (float64, float64) { return s.Lat, s.Lon },
+		func(s store) string { return s.Name }, 10,
+		WithScorer(func(entity LocationCapable, distance float64) float64 {
+			seen = append(seen, entity.(Located[store]).Value())
+			cells = entity.Geocells()
+			return distance
+		}))
+	if len(seen) == 0 || seen[0].Name == "" || !reflect.DeepEqual(cells, GeoCells(seen[len(seen)-1].Lat, seen[len(seen)-1].Lon, 10)) {
+		t.Errorf("scorer saw %+v with geocells %v", seen, cells)
+	}
+}
+
+func TestProximityFetchResultOrder(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "a", GeoCells(50, 8, 10)}, Place{50.001, 8, "c", GeoCells(50.001, 8, 10)}, Place{50.001, 8, "b", GeoCells(50.001, 8, 10)}}
+
+	var keys = func(result []LocationCapable) string {
+		var s string
+		for _, e := range result {
+			s += e.Key()
+		}
+		return s
+	}
+	var byKey = func(a, b LocationCapable, da, db float64) bool { return a.Key() < b.Key() }
+
+	if got := keys(ProximityFetch(50, 8, 3, 0, searchPlaces(places), 10, WithTiebreaker(byKey))); got != "abc" {
+		t.Errorf("ascending order = %q, want abc", got)
+	}
+	if got := keys(ProximityFetch(50, 8, 3, 0, searchPlaces(places), 10, WithTiebreaker(byKey), WithResultOrder(Descending))); got != "bca" {
+		t.Errorf("descending order = %q, want bca", got)
+	}
+
+	// The tiebreaker also picks which of the tied entities make the cut,
+	// whatever order the repository returns them in.
+	for _, order := range [][]LocationCapable{{places[0], places[1], places[2]}, {places[0], places[2], places[1]}} {
+		if got := keys(ProximityFetch(50, 8, 2, 0, searchPlaces(order), 10, WithTiebreaker(byKey))); got != "ab" {
+			t.Errorf("two results = %q, want ab", got)
+		}
+	}
+}
+
+func TestProximityFetchWithScorer(t *testing.T) {
+	// Ratings are the last character of the keys.
+	var places = []LocationCapable{Place{50, 8, "a1", GeoCells(50, 8, 10)}, Place{50.001, 8, "b9", GeoCells(50.001, 8, 10)}, Place{50.002, 8, "c5", GeoCells(50.002, 8, 10)}, Place{50.1, 8, "d9", GeoCells(50.1, 8, 10)}}
+	var byRating = func(entity LocationCapable, distance float64) float64 {
+		return -float64(entity.Key()[1] - '0')
+	}
+
+	var results = ProximityFetchResults(50, 8, 3, 0, searchPlaces(places), 10, WithScorer(byRating))
+	var keys []string
+	for _, r := range results {
+		keys = append(keys, r.Entity.Key())
+	}
+	// d9 is rated as well as b9 but not among the three nearest.
+	if fmt.Sprint(keys) != "[b9 c5 a1]" {
+		t.Errorf("scored order = %v, want [b9 c5 a1]", keys)
+	}
+	if results[0].Distance != Distance(50, 8, 50.001, 8) {
+		t.Errorf("distance of b9 = %v, want its distance", results[0].Distance)
+	}
+
+	var flat = func(LocationCapable, float64) float64 { return 0 }
+	if got := ProximityFetch(50, 8, 3, 0, searchPlaces(places), 10, WithScorer(flat), WithResultOrder(Descending)); got[0].Key() != "c5" {
+		t.Errorf("equal scores in descending order start with %v, want c5", got[0].Key())
+	}
+}
+
+func TestProximityFetchStatsHook(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "1", GeoCells(50, 8, 10)}, Place{50.3, 8.3, "2", GeoCells(50.3, 8.3, 10)}}
+
+	var calls int
+	var search = searchPlaces(places)
+	var counting RepositorySearch = func(cells []string) []LocationCapable {
+		calls++
+		return search(cells)
+	}
+
+	var stats SearchStats
+	var reported int
+	ProximityFetch(50, 8, 2, 0, counting, 10, WithStatsHook(func(s SearchStats) {
+		stats = s
+		reported++
+	}))
+	if reported != 1 {
+		t.Fatalf("hook called %d times, want 1", reported)
+	}
+	if stats.RepositoryCalls != calls || stats.Iterations == 0 || stats.CellsQueried == 0 || stats.EntitiesScanned < 2 || stats.WallTime <= 0 {
+		t.Errorf("unexpected stats %+v after %d repository calls", stats, calls)
+	}
+}
+
+func TestProximityFetchSearchLimits(t *testing.T) {
+	var stats SearchStats
+	var hook = WithStatsHook(func(s SearchStats) { stats = s })
+
+	ProximityFetch(0, 0, 1, 0, searchPlaces(nil), 10, hook, WithMaxIterations(3))
+	if !stats.LimitReached || stats.Iterations != 3 {
+		t.Errorf("WithMaxIterations(3): stats %+v", stats)
+	}
+
+	ProximityFetch(0, 0, 1, 0, searchPlaces(nil), 10, hook, WithMaxCellsSearched(5))
+	if !stats.LimitReached || stats.CellsQueried > 5 {
+		t.Errorf("WithMaxCellsSearched(5): stats %+v", stats)
+	}
+
+	if _, _, err := Nearest(0, 0, searchPlaces(nil), WithMaxIterations(2)); err != ErrSearchLimit {
+		t.Errorf("Nearest returned %v, want ErrSearchLimit", err)
+	}
+}
+
+func TestProximityFetchStrictDistance(t *testing.T) {
+	var near = Place{50.001, 8, "near", GeoCells(50.001, 8, 10)}
+	var far = Place{60, 20, "far", GeoCells(60, 20, 10)}
+
+	// A repository ignoring the requested cells and returning a far-away
+	// record with every response.
+	var search RepositorySearch = func(cells []string) []LocationCapable {
+		var result = []LocationCapable{far}
+		for _, cell := range cells {
+			if strings.HasPrefix(near.geocells[len(near.geocells)-1], cell) {
+				result = append(result, near)
+			}
+		}
+		return result
+	}
+
+	// Without WithStrictDistance the far record fills the single slot
+	// first. Being farther than every cell still to be searched, it does
+	// not end the search, so the near place is found and replaces it; the
+	// far record would only have been dropped from the final results.
+	if result := keysOf(ProximityFetch(50, 8, 1, 1000, search, 10)); !slices.Equal(result, []string{"near"}) {
+		t.Errorf("lenient search returned %v, want the near place", result)
+	}
+	var result = ProximityFetch(50, 8, 1, 1000, search, 10, WithStrictDistance())
+	if len(result) != 1 || result[0].Key() != "near" {
+		t.Errorf("strict search returned %v, want the near place", result)
+	}
+	if result := ProximityFetch(50, 8, 2, 1000, search, 10, WithStrictDistance()); len(result) != 1 {
+		t.Errorf("strict search for two results returned %v, want the near place only", result)
+	}
+
+	if result := ProximityFetch(50, 8, 2, Unlimited, search, 10); len(result) != 2 {
+		t.Errorf("unlimited search returned %d results, want 2", len(result))
+	}
+}
+
+func TestProximityFetchExcludeKeys(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "1", GeoCells(50, 8, 10)}, Place{50.001, 8, "2", GeoCells(50.001, 8, 10)}, Place{50.002, 8, "3", GeoCells(50.002, 8, 10)}}
+
+	var result = ProximityFetch(50, 8, 2, 0, searchPlaces(places), 10, WithExcludeKeys([]string{"1"}))
+	if len(result) != 2 || result[0].Key() != "2" || result[1].Key() != "3" {
+		t.Errorf("got %v, want places 2 and 3", result)
+	}
+}
+
+func TestAppendGeoCell(t *testing.T) {
+	var buf = make([]byte, 0, MAX_GEOCELL_RESOLUTION)
+	if got, want := string(AppendGeoCell(buf, 53.12869, 8.18976, 6)), GeoCell(53.12869, 8.18976, 6); got != want {
+		t.Errorf("AppendGeoCell = %q, want %q", got, want)
+	}
+	if got := string(AppendGeoCell([]byte("x:"), 53.12869, 8.18976, 3)); got != "x:u1m" {
+		t.Errorf("AppendGeoCell with prefix = %q, want x:u1m", got)
+	}
+
+	var allocs = testing.AllocsPerRun(100, func() {
+		buf = AppendGeoCell(buf[:0], 53.12869, 8.18976, MAX_GEOCELL_RESOLUTION)
+	})
+	if allocs != 0 {
+		t.Errorf("AppendGeoCell allocated %v times per run", allocs)
+	}
+
+	var i int
+	var cells = GeoCells(53.12869, 8.18976, 8)
+	for prefix := range GeoCellPrefixes(53.12869, 8.18976, 8) {
+		if prefix != cells[i] {
+			t.Errorf("prefix %d = %q, want %q", i, prefix, cells[i])
+		}
+		i++
+	}
+	if i != len(cells) {
+		t.Errorf("got %d prefixes, want %d", i, len(cells))
+	}
+}
+
+func TestAppendGeoCells(t *testing.T) {
+	var cell = GeoCell(53.12869, 8.18976, MAX_GEOCELL_RESOLUTION)
+	var cells = GeoCells(53.12869, 8.18976, MAX_GEOCELL_RESOLUTION)
+	if len(cells) != MAX_GEOCELL_RESOLUTION || cap(cells) != MAX_GEOCELL_RESOLUTION {
+		t.Fatalf("GeoCells returned len %d, cap %d, want %d", len(cells), cap(cells), MAX_GEOCELL_RESOLUTION)
+	}
+	for i, c := range cells {
+		if c != cell[:i+1] {
+			t.Errorf("GeoCells()[%d] = %q, want %q", i, c, cell[:i+1])
+		}
+	}
+	if got := GeoCells(53.12869, 8.18976, 0); got == nil || len(got) != 0 {
+		t.Errorf("GeoCells at resolution 0 = %#v, want empty", got)
+	}
+
+	var got = AppendGeoCells([]string{"x"}, 53.12869, 8.18976, 3)
+	if !reflect.DeepEqual(got, []string{"x", cell[:1], cell[:2], cell[:3]}) {
+		t.Errorf("AppendGeoCells with prefix = %v", got)
+	}
+
+	var buf = make([]string, 0, MAX_GEOCELL_RESOLUTION)
+	var allocs = testing.AllocsPerRun(100, func() {
+		buf = AppendGeoCells(buf[:0], 53.12869, 8.18976, MAX_GEOCELL_RESOLUTION)
+	})
+	if allocs > 1 {
+		t.Errorf("AppendGeoCells allocated %v times per run, want at most 1", allocs)
+	}
+}
@@ -0,0 +1,302 @@
+// Package geomodelhttp serves geomodel searches over HTTP, so that a
+// proximity service can be stood up in a few lines:
+//
+//	var index = geomodel.NewGeoIndex(entities...)
+//	http.ListenAndServe(":8080", &geomodelhttp.Handler{Search: index.Search})
+//
+// The handler answers three GET endpoints:
+//
+//	/nearby?lat=53.08&lon=8.80&max_results=10&max_distance=1000
+//	/cover?lat=53.08&lon=8.80&radius=1000&resolution=7
+//	/cover?bbox=8.7,53.0,8.9,53.2&resolution=7
+//	/cell/u1my4r
+//
+// /nearby runs ProximityFetchResults; /cover returns the cells covering a
+// circle or a box given as "west,south,east,north", as Geofence computes
+// them; /cell returns the box, center, parent and children of a cell.
+// Responses are JSON, or GeoJSON if the request asks for
+// "application/geo+json" in its Accept header or passes format=geojson.
+// Invalid parameters yield 400 Bad Request with a JSON body {"error": ...}.
+package geomodelhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alternaDev/geomodel"
+)
+
+const (
+	// GEOJSON_CONTENT_TYPE is the media type of GeoJSON responses.
+	GEOJSON_CONTENT_TYPE = "application/geo+json"
+	// DEFAULT_MAX_RESULTS is the number of results /nearby returns without
+	// max_results, and DEFAULT_MAX_DISTANCE its radius in meters without
+	// max_distance.
+	DEFAULT_MAX_RESULTS  = 10
+	DEFAULT_MAX_DISTANCE = 1000
+	// DEFAULT_RESULTS_CAP caps max_results of /nearby unless
+	// Handler.MaxResults is set.
+	DEFAULT_RESULTS_CAP = 1000
+	// DEFAULT_MAX_COVER_CELLS bounds coverings returned by /cover unless
+	// Handler.MaxCoverCells is set.
+	DEFAULT_MAX_COVER_CELLS = 1024
+)
+
+// Handler is an http.Handler serving the endpoints of the package over
+// Search. Its fields must not change once it serves requests.
+type Handler struct {
+	// Search finds the entities of cells, such as GeoIndex.Search or the
+	// Search method of an sqlrepo query.
+	Search geomodel.RepositorySearch
+	// MaxResolution is the finest resolution searched, and the default of
+	// /cover. Zero means MAX_GEOCELL_RESOLUTION.
+	MaxResolution int
+	// MaxResults caps max_results of /nearby: larger values are rejected
+	// with 400 Bad Request. Zero means DEFAULT_RESULTS_CAP.
+	MaxResults int
+	// MaxCoverCells bounds the cells /cover returns: coverings are coarsened
+	// until they fit. Zero means DEFAULT_MAX_COVER_CELLS.
+	MaxCoverCells int
+	// Options are passed to every search.
+	Options []geomodel.Option
+
+	once sync.Once
+	mux  *http.ServeMux
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.mux = http.NewServeMux()
+		h.mux.HandleFunc("/nearby", h.nearby)
+		h.mux.HandleFunc("/cover", h.cover)
+		h.mux.HandleFunc("/cell/", h.cell)
+	})
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// result is an entry of the JSON response of /nearby.
+type result struct {
+	Key      string  `json:"key"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Distance float64 `json:"distance_m"`
+	Cell     string  `json:"cell,omitempty"`
+}
+
+func (h *Handler) nearby(w http.ResponseWriter, r *http.Request) {
+	var p params = params{query: r.URL.Query()}
+	var origin geomodel.Point = geomodel.Point{Lat: p.float("lat", nil), Lon: p.float("lon", nil)}
+	var maxResults int = p.int("max_results", DEFAULT_MAX_RESULTS)
+	var maxDistance float64 = p.float("max_distance", ptr(DEFAULT_MAX_DISTANCE))
+	if p.err == nil {
+		p.check(origin.Validate())
+	}
+	if p.err == nil && (maxResults < 1 || maxResults > h.maxResults()) {
+		p.err = fmt.Errorf("max_results must be between 1 and %d", h.maxResults())
+	}
+	if p.err == nil && maxDistance < 0 {
+		p.err = errors.New("max_distance must not be negative")
+	}
+	if p.err != nil {
+		writeError(w, p.err)
+		return
+	}
+
+	var results []geomodel.SearchResult = geomodel.ProximityFetchResults(origin.Lat, origin.Lon, maxResults, maxDistance, h.Search, h.maxResolution(), h.Options...)
+	if wantsGeoJSON(r) {
+		w.Header().Set("Content-Type", GEOJSON_CONTENT_TYPE)
+		geomodel.WriteResultsGeoJSON(w, origin, results)
+		return
+	}
+	var out []result = make([]result, 0, len(results))
+	for _, res := range results {
+		var entry result = result{Key: res.Entity.Key(), Lat: res.Entity.Latitude(), Lon: res.Entity.Longitude(), Distance: res.Distance}
+		if cells := res.Entity.Geocells(); len(cells) > 0 {
+			entry.Cell = cells[len(cells)-1]
+		}
+		out = append(out, entry)
+	}
+	writeJSON(w, map[string]interface{}{"results": out})
+}
+
+func (h *Handler) cover(w http.ResponseWriter, r *http.Request) {
+	var p params = params{query: r.URL.Query()}
+	var resolution int = int(p.float("resolution", ptr(float64(h.maxResolution()))))
+	var region geomodel.Region
+	if text := p.query.Get("bbox"); text != "" {
+		var bbox geomodel.BoundingBox
+		p.check(bbox.UnmarshalText([]byte(text)))
+		region = bbox
+	} else {
+		var circle geomodel.Circle = geomodel.Circle{
+			Center: geomodel.Point{Lat: p.float("lat", nil), Lon: p.float("lon", nil)},
+			Radius: p.float("radius", nil),
+		}
+		if p.err == nil {
+			p.check(circle.Center.Validate())
+		}
+		region = circle
+	}
+	if p.err == nil && (resolution < 1 || resolution > geomodel.MAX_GEOCELL_RESOLUTION) {
+		p.err = fmt.Errorf("resolution must be between 1 and %d", geomodel.MAX_GEOCELL_RESOLUTION)
+	}
+	if p.err != nil {
+		writeError(w, p.err)
+		return
+	}
+
+	var maxCells int = h.MaxCoverCells
+	if maxCells <= 0 {
+		maxCells = DEFAULT_MAX_COVER_CELLS
+	}
+	var cells []string
+	var used int
+	for res := 1; res <= resolution; res++ {
+		var next []string = covering(region, res)
+		if used > 0 && len(next) > maxCells {
+			break
+		}
+		cells, used = next, res
+	}
+
+	if wantsGeoJSON(r) {
+		var data, err = geomodel.CellsToFeatureCollection(cells)
+		writeGeoJSON(w, data, err)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"resolution": used, "cells": cells})
+}
+
+// covering returns the interior and boundary cells of a fence over region
+// at resolution.
+func covering(region geomodel.Region, resolution int) []string {
+	var fence *geomodel.Geofence = geomodel.NewGeofence(region, resolution)
+	var cells []string = []string{}
+	for cell := range fence.InteriorCells() {
+		cells = append(cells, cell)
+	}
+	for cell := range fence.BoundaryCells() {
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+func (h *Handler) cell(w http.ResponseWriter, r *http.Request) {
+	var cell, err = geomodel.ParseCell(strings.TrimPrefix(r.URL.Path, "/cell/"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if wantsGeoJSON(r) {
+		var data, err = geomodel.CellToGeoJSON(string(cell))
+		writeGeoJSON(w, data, err)
+		return
+	}
+	var center geomodel.Point = cell.Center()
+	writeJSON(w, map[string]interface{}{
+		"cell":       cell,
+		"resolution": cell.Resolution(),
+		"box":        cell.Box(),
+		"center":     [2]float64{center.Lat, center.Lon},
+		"parent":     cell.Parent(),
+		"children":   cell.Children(),
+	})
+}
+
+func (h *Handler) maxResults() int {
+	if h.MaxResults <= 0 {
+		return DEFAULT_RESULTS_CAP
+	}
+	return h.MaxResults
+}
+
+func (h *Handler) maxResolution() int {
+	if h.MaxResolution <= 0 {
+		return geomodel.MAX_GEOCELL_RESOLUTION
+	}
+	return h.MaxResolution
+}
+
+// params parses query parameters, keeping the first error.
+type params struct {
+	query url.Values
+	err   error
+}
+
+// float returns the parameter name as a number, or def if it is absent. A
+// nil def makes the parameter required.
+func (p *params) float(name string, def *float64) float64 {
+	var value string = p.query.Get(name)
+	if value == "" {
+		if def == nil {
+			p.check(fmt.Errorf("missing parameter %s", name))
+			return 0
+		}
+		return *def
+	}
+	var v, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.check(fmt.Errorf("invalid parameter %s: %q", name, value))
+	}
+	return v
+}
+
+// int returns the parameter name as an integer, or def if it is absent.
+func (p *params) int(name string, def int) int {
+	var value string = p.query.Get(name)
+	if value == "" {
+		return def
+	}
+	var v, err = strconv.Atoi(value)
+	if err != nil {
+		p.check(fmt.Errorf("invalid parameter %s: %q", name, value))
+	}
+	return v
+}
+
+func (p *params) check(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+// wantsGeoJSON reports whether r asks for a GeoJSON response.
+func wantsGeoJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "geojson" || strings.Contains(r.Header.Get("Accept"), GEOJSON_CONTENT_TYPE)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeGeoJSON writes data, the GeoJSON document returned by one of the
+// geomodel encoders with err.
+func writeGeoJSON(w http.ResponseWriter, data []byte, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", GEOJSON_CONTENT_TYPE)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,126 @@
+package geomodelhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+func serve(t *testing.T, h http.Handler, target string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: invalid JSON %q", target, w.Body)
+	}
+	return w, body
+}
+
+func newHandler() *Handler {
+	var index = geomodel.NewGeoIndex()
+	for i, p := range []geomodel.Point{{Lat: 53.0793, Lon: 8.8017}, {Lat: 53.0800, Lon: 8.8030}, {Lat: 53.1435, Lon: 8.2146}} {
+		var key = string(rune('a' + i))
+		index.Insert(&geomodel.IndexRecord{ID: key, Lat: p.Lat, Lon: p.Lon, Cells: geomodel.GeoCells(p.Lat, p.Lon, geomodel.MAX_GEOCELL_RESOLUTION)})
+	}
+	return &Handler{Search: index.Search, MaxCoverCells: 64}
+}
+
+func TestNearby(t *testing.T) {
+	var h = newHandler()
+	var w, body = serve(t, h, "/nearby?lat=53.0793&lon=8.8017&max_distance=500")
+	var results, _ = body["results"].([]interface{})
+	if w.Code != http.StatusOK || len(results) != 2 {
+		t.Fatalf("got %d with %v, want two results", w.Code, body)
+	}
+	if first := results[0].(map[string]interface{}); first["key"] != "a" || first["distance_m"] != 0.0 || first["cell"] == "" {
+		t.Errorf("first result %v", first)
+	}
+
+	w, body = serve(t, h, "/nearby?lat=53.0793&lon=8.8017&max_distance=500&format=geojson")
+	if w.Header().Get("Content-Type") != GEOJSON_CONTENT_TYPE || body["type"] != "FeatureCollection" || len(body["features"].([]interface{})) != 3 {
+		t.Errorf("GeoJSON response %v", body)
+	}
+
+	for _, target := range []string{"/nearby?lon=8", "/nearby?lat=91&lon=8", "/nearby?lat=x&lon=8", "/nearby?lat=53&lon=8&max_results=0",
+		"/nearby?lat=53&lon=8&max_results=1e18", "/nearby?lat=53&lon=8&max_results=2.5", "/nearby?lat=53&lon=8&max_results=1001",
+		"/nearby?lat=53&lon=8&max_results=99999999999999999999"} {
+		if w, body := serve(t, h, target); w.Code != http.StatusBadRequest || body["error"] == nil {
+			t.Errorf("%s: got %d with %v, want an error", target, w.Code, body)
+		}
+	}
+}
+
+func TestNearbyMaxResults(t *testing.T) {
+	var h = newHandler()
+	h.MaxResults = 1
+	if w, body := serve(t, h, "/nearby?lat=53.0793&lon=8.8017&max_results=1&max_distance=500"); w.Code != http.StatusOK || len(body["results"].([]interface{})) != 1 {
+		t.Errorf("max_results at the cap: got %d with %v", w.Code, body)
+	}
+	if w, body := serve(t, h, "/nearby?lat=53.0793&lon=8.8017&max_results=2"); w.Code != http.StatusBadRequest || body["error"] == nil {
+		t.Errorf("max_results beyond the cap: got %d with %v, want an error", w.Code, body)
+	}
+}
+
+func TestCover(t *testing.T) {
+	var h = newHandler()
+	var w, body = serve(t, h, "/cover?lat=53.0793&lon=8.8017&radius=1000&resolution=6")
+	if w.Code != http.StatusOK || body["resolution"] != 6.0 || len(body["cells"].([]interface{})) == 0 {
+		t.Fatalf("got %d with %v", w.Code, body)
+	}
+	for _, cell := range body["cells"].([]interface{}) {
+		if !geomodel.NewBoundingBoxAround(geomodel.Point{Lat: 53.0793, Lon: 8.8017}, 1000).Intersects(geomodel.ComputeBox(cell.(string))) {
+			t.Errorf("cell %v outside the circle", cell)
+		}
+	}
+
+	// Fine coverings of large regions are coarsened to MaxCoverCells.
+	w, body = serve(t, h, "/cover?bbox=8,53,9,54")
+	if cells := body["cells"].([]interface{}); w.Code != http.StatusOK || len(cells) > 64 || body["resolution"].(float64) >= geomodel.MAX_GEOCELL_RESOLUTION {
+		t.Errorf("got %d with %d cells at resolution %v", w.Code, len(cells), body["resolution"])
+	}
+
+	w = httptest.NewRecorder()
+	var r = httptest.NewRequest("GET", "/cover?bbox=8,53,9,54&resolution=2", nil)
+	r.Header.Set("Accept", GEOJSON_CONTENT_TYPE)
+	h.ServeHTTP(w, r)
+	if w.Header().Get("Content-Type") != GEOJSON_CONTENT_TYPE || !strings.Contains(w.Body.String(), `"FeatureCollection"`) {
+		t.Errorf("GeoJSON response %q", w.Body)
+	}
+
+	for _, target := range []string{"/cover?lat=53&lon=8", "/cover?bbox=1,2,3", "/cover?bbox=8,53,9,54&resolution=14"} {
+		if w, _ := serve(t, h, target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestCell(t *testing.T) {
+	var h = newHandler()
+	var w, body = serve(t, h, "/cell/u1my4r")
+	if w.Code != http.StatusOK || body["cell"] != "u1my4r" || body["resolution"] != 6.0 || body["parent"] != "u1my4" || len(body["children"].([]interface{})) != 32 {
+		t.Errorf("got %d with %v", w.Code, body)
+	}
+	if box := body["box"].(map[string]interface{}); box["north"] != geomodel.ComputeBox("u1my4r").North() {
+		t.Errorf("box %v", box)
+	}
+
+	w, body = serve(t, h, "/cell/u1my4r?format=geojson")
+	if body["type"] != "Feature" {
+		t.Errorf("GeoJSON response %v", body)
+	}
+	if w, _ = serve(t, h, "/cell/u1ai"); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid cell: got %d, want 400", w.Code)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	var w = httptest.NewRecorder()
+	newHandler().ServeHTTP(w, httptest.NewRequest("POST", "/nearby?lat=53&lon=8", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST got %d, want 405", w.Code)
+	}
+}
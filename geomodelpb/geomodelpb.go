@@ -0,0 +1,487 @@
+// Package geomodelpb implements the messages of geomodel.proto, the
+// canonical wire format for services exchanging proximity queries, and
+// converts them to and from geomodel types. The messages encode to the
+// standard protobuf binary format, so peers may use code generated from
+// geomodel.proto, while this package needs no protobuf runtime.
+// ProximityServer serves the ProximityService of geomodel.proto to gRPC
+// clients.
+package geomodelpb
+
+import "github.com/alternaDev/geomodel"
+
+// Point is the Point message.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Cell is the Cell message.
+type Cell struct {
+	ID string
+}
+
+// Location is the Location message.
+type Location struct {
+	Key      string
+	Point    *Point
+	Geocells []string
+}
+
+// ProximityRequest is the ProximityRequest message.
+type ProximityRequest struct {
+	Origin        *Point
+	MaxResults    uint32
+	MaxDistanceM  float64
+	MaxResolution uint32
+}
+
+// ProximityResult is the ProximityResult message.
+type ProximityResult struct {
+	Location  *Location
+	DistanceM float64
+}
+
+// NearestRequest is the NearestRequest message.
+type NearestRequest struct {
+	Origin *Point
+}
+
+// CoverRequest is the CoverRequest message.
+type CoverRequest struct {
+	Center        *Point
+	RadiusM       float64
+	MaxResolution uint32
+}
+
+// CoverResponse is the CoverResponse message.
+type CoverResponse struct {
+	Cells []string
+}
+
+// ProximityResponse is the ProximityResponse message.
+type ProximityResponse struct {
+	Results []*ProximityResult
+}
+
+// FromPoint converts a geomodel.Point.
+func FromPoint(p geomodel.Point) *Point {
+	return &Point{Lat: p.Lat, Lon: p.Lon}
+}
+
+// ToPoint converts p to a geomodel.Point; a nil p is the zero point.
+func (p *Point) ToPoint() geomodel.Point {
+	if p == nil {
+		return geomodel.Point{}
+	}
+	return geomodel.Point{Lat: p.Lat, Lon: p.Lon}
+}
+
+// FromEntity converts the key, location and geocells of entity.
+func FromEntity(entity geomodel.LocationCapable) *Location {
+	return &Location{
+		Key:      entity.Key(),
+		Point:    &Point{Lat: entity.Latitude(), Lon: entity.Longitude()},
+		Geocells: entity.Geocells(),
+	}
+}
+
+// ToEntity converts l to an entity.
+func (l *Location) ToEntity() *geomodel.IndexRecord {
+	var p geomodel.Point = l.Point.ToPoint()
+	return &geomodel.IndexRecord{ID: l.Key, Lat: p.Lat, Lon: p.Lon, Cells: l.Geocells}
+}
+
+// FromResults converts the results of a proximity search.
+func FromResults(results []geomodel.SearchResult) *ProximityResponse {
+	var response *ProximityResponse = &ProximityResponse{Results: make([]*ProximityResult, len(results))}
+	for i, r := range results {
+		response.Results[i] = &ProximityResult{Location: FromEntity(r.Entity), DistanceM: r.Distance}
+	}
+	return response
+}
+
+// ToResults converts r to search results whose entities are
+// *geomodel.IndexRecord values. Results without a location are dropped.
+func (r *ProximityResponse) ToResults() []geomodel.SearchResult {
+	var results []geomodel.SearchResult = make([]geomodel.SearchResult, 0, len(r.Results))
+	for _, result := range r.Results {
+		if result != nil && result.Location != nil {
+			results = append(results, geomodel.SearchResult{Entity: result.Location.ToEntity(), Distance: result.DistanceM})
+		}
+	}
+	return results
+}
+
+// Fetch runs geomodel.ProximityFetchResults for the request over search.
+// Zero limits take their geomodel meaning: no distance limit, and
+// geomodel.MAX_GEOCELL_RESOLUTION as the resolution.
+func (r *ProximityRequest) Fetch(search geomodel.RepositorySearch, opts ...geomodel.Option) *ProximityResponse {
+	var origin geomodel.Point = r.Origin.ToPoint()
+	var resolution int = int(r.MaxResolution)
+	if resolution == 0 {
+		resolution = geomodel.MAX_GEOCELL_RESOLUTION
+	}
+	return FromResults(geomodel.ProximityFetchResults(origin.Lat, origin.Lon, int(r.MaxResults), r.MaxDistanceM, search, resolution, opts...))
+}
+
+// Marshal returns the protobuf encoding of p.
+func (p *Point) Marshal() []byte { return p.append(nil) }
+
+func (p *Point) append(buf []byte) []byte {
+	buf = appendDouble(buf, 1, p.Lat)
+	return appendDouble(buf, 2, p.Lon)
+}
+
+// Unmarshal decodes data into p, replacing its contents.
+func (p *Point) Unmarshal(data []byte) error {
+	*p = Point{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			if err = expect(wireType, wireFixed64); err == nil {
+				p.Lat, err = d.double()
+			}
+		case 2:
+			if err = expect(wireType, wireFixed64); err == nil {
+				p.Lon, err = d.double()
+			}
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of c.
+func (c *Cell) Marshal() []byte {
+	return appendString(nil, 1, c.ID, false)
+}
+
+// Unmarshal decodes data into c, replacing its contents.
+func (c *Cell) Unmarshal(data []byte) error {
+	*c = Cell{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		if field == 1 {
+			var b []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				b, err = d.bytes()
+				c.ID = string(b)
+			}
+		} else {
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of l.
+func (l *Location) Marshal() []byte { return l.append(nil) }
+
+func (l *Location) append(buf []byte) []byte {
+	buf = appendString(buf, 1, l.Key, false)
+	if l.Point != nil {
+		buf = appendMessage(buf, 2, l.Point.append)
+	}
+	for _, cell := range l.Geocells {
+		buf = appendString(buf, 3, cell, true)
+	}
+	return buf
+}
+
+// Unmarshal decodes data into l, replacing its contents.
+func (l *Location) Unmarshal(data []byte) error {
+	*l = Location{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		var b []byte
+		if field >= 1 && field <= 3 {
+			if err = expect(wireType, wireBytes); err == nil {
+				b, err = d.bytes()
+			}
+		} else {
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			l.Key = string(b)
+		case 2:
+			l.Point = &Point{}
+			err = l.Point.Unmarshal(b)
+		case 3:
+			l.Geocells = append(l.Geocells, string(b))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of r.
+func (r *ProximityRequest) Marshal() []byte {
+	var buf []byte
+	if r.Origin != nil {
+		buf = appendMessage(buf, 1, r.Origin.append)
+	}
+	buf = appendUint32(buf, 2, r.MaxResults)
+	buf = appendDouble(buf, 3, r.MaxDistanceM)
+	return appendUint32(buf, 4, r.MaxResolution)
+}
+
+// Unmarshal decodes data into r, replacing its contents.
+func (r *ProximityRequest) Unmarshal(data []byte) error {
+	*r = ProximityRequest{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			var b []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if b, err = d.bytes(); err == nil {
+					r.Origin = &Point{}
+					err = r.Origin.Unmarshal(b)
+				}
+			}
+		case 2, 4:
+			if err = expect(wireType, wireVarint); err == nil {
+				var v uint64 = d.varint()
+				if d.data == nil {
+					err = ErrInvalidMessage
+				} else if field == 2 {
+					r.MaxResults = uint32(v)
+				} else {
+					r.MaxResolution = uint32(v)
+				}
+			}
+		case 3:
+			if err = expect(wireType, wireFixed64); err == nil {
+				r.MaxDistanceM, err = d.double()
+			}
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of r.
+func (r *ProximityResult) Marshal() []byte { return r.append(nil) }
+
+func (r *ProximityResult) append(buf []byte) []byte {
+	if r.Location != nil {
+		buf = appendMessage(buf, 1, r.Location.append)
+	}
+	return appendDouble(buf, 2, r.DistanceM)
+}
+
+// Unmarshal decodes data into r, replacing its contents.
+func (r *ProximityResult) Unmarshal(data []byte) error {
+	*r = ProximityResult{}
+	return r.unmarshal(data)
+}
+
+func (r *ProximityResult) unmarshal(data []byte) error {
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			var b []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if b, err = d.bytes(); err == nil {
+					r.Location = &Location{}
+					err = r.Location.Unmarshal(b)
+				}
+			}
+		case 2:
+			if err = expect(wireType, wireFixed64); err == nil {
+				r.DistanceM, err = d.double()
+			}
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of r.
+func (r *ProximityResponse) Marshal() []byte {
+	var buf []byte
+	for _, result := range r.Results {
+		buf = appendMessage(buf, 1, result.append)
+	}
+	return buf
+}
+
+// Unmarshal decodes data into r, replacing its contents.
+func (r *ProximityResponse) Unmarshal(data []byte) error {
+	*r = ProximityResponse{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		if field == 1 {
+			var b []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if b, err = d.bytes(); err == nil {
+					var result *ProximityResult = &ProximityResult{}
+					err = result.unmarshal(b)
+					r.Results = append(r.Results, result)
+				}
+			}
+		} else {
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of r.
+func (r *NearestRequest) Marshal() []byte {
+	if r.Origin == nil {
+		return nil
+	}
+	return appendMessage(nil, 1, r.Origin.append)
+}
+
+// Unmarshal decodes data into r, replacing its contents.
+func (r *NearestRequest) Unmarshal(data []byte) error {
+	*r = NearestRequest{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		if field == 1 {
+			var b []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if b, err = d.bytes(); err == nil {
+					r.Origin = &Point{}
+					err = r.Origin.Unmarshal(b)
+				}
+			}
+		} else {
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of r.
+func (r *CoverRequest) Marshal() []byte {
+	var buf []byte
+	if r.Center != nil {
+		buf = appendMessage(buf, 1, r.Center.append)
+	}
+	buf = appendDouble(buf, 2, r.RadiusM)
+	return appendUint32(buf, 3, r.MaxResolution)
+}
+
+// Unmarshal decodes data into r, replacing its contents.
+func (r *CoverRequest) Unmarshal(data []byte) error {
+	*r = CoverRequest{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			var b []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if b, err = d.bytes(); err == nil {
+					r.Center = &Point{}
+					err = r.Center.Unmarshal(b)
+				}
+			}
+		case 2:
+			if err = expect(wireType, wireFixed64); err == nil {
+				r.RadiusM, err = d.double()
+			}
+		case 3:
+			if err = expect(wireType, wireVarint); err == nil {
+				r.MaxResolution = uint32(d.varint())
+				if d.data == nil {
+					err = ErrInvalidMessage
+				}
+			}
+		default:
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Marshal returns the protobuf encoding of r.
+func (r *CoverResponse) Marshal() []byte {
+	var buf []byte
+	for _, cell := range r.Cells {
+		buf = appendString(buf, 1, cell, true)
+	}
+	return buf
+}
+
+// Unmarshal decodes data into r, replacing its contents.
+func (r *CoverResponse) Unmarshal(data []byte) error {
+	*r = CoverResponse{}
+	var d decoder = decoder{data}
+	for {
+		var field, wireType, ok, err = d.next()
+		if !ok || err != nil {
+			return err
+		}
+		if field == 1 {
+			var b []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				b, err = d.bytes()
+				r.Cells = append(r.Cells, string(b))
+			}
+		} else {
+			err = d.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package geomodelpb
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+func TestPointEncoding(t *testing.T) {
+	// As encoded by protoc-generated code: field 1 and field 2, both fixed64.
+	const want = "09000000000000f83f" + "1100000000000000c0"
+	var p = &Point{Lat: 1.5, Lon: -2}
+	if got := hex.EncodeToString(p.Marshal()); got != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+	if got := (&Point{}).Marshal(); len(got) != 0 {
+		t.Errorf("zero point encodes to %x, want nothing", got)
+	}
+
+	// An unknown varint field 7 is skipped.
+	var data, _ = hex.DecodeString("3801" + want)
+	var decoded Point
+	if err := decoded.Unmarshal(data); err != nil || decoded != *p {
+		t.Errorf("Unmarshal = %+v, %v, want %+v", decoded, err, *p)
+	}
+	if err := decoded.Unmarshal(data[:len(data)-1]); err != ErrInvalidMessage {
+		t.Errorf("Unmarshal of truncated data = %v, want ErrInvalidMessage", err)
+	}
+}
+
+func TestProximityRoundTrip(t *testing.T) {
+	var request = &ProximityRequest{Origin: FromPoint(geomodel.Point{Lat: 50, Lon: 8}), MaxResults: 2, MaxDistanceM: 500}
+	var decodedRequest ProximityRequest
+	if err := decodedRequest.Unmarshal(request.Marshal()); err != nil || !reflect.DeepEqual(&decodedRequest, request) {
+		t.Fatalf("request round trip = %+v, %v", decodedRequest, err)
+	}
+
+	var index = geomodel.NewGeoIndex(
+		&geomodel.IndexRecord{ID: "a", Lat: 50, Lon: 8.001, Cells: geomodel.GeoCells(50, 8.001, 10)},
+		&geomodel.IndexRecord{ID: "b", Lat: 50, Lon: 8.002, Cells: geomodel.GeoCells(50, 8.002, 10)},
+		&geomodel.IndexRecord{ID: "c", Lat: 51, Lon: 8, Cells: geomodel.GeoCells(51, 8, 10)},
+	)
+	var response = decodedRequest.Fetch(index.Search)
+
+	var decoded ProximityResponse
+	if err := decoded.Unmarshal(response.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	var results = decoded.ToResults()
+	if len(results) != 2 || results[0].Entity.Key() != "a" || results[1].Distance != response.Results[1].DistanceM {
+		t.Fatalf("decoded results %+v", results)
+	}
+	if !reflect.DeepEqual(results[0].Entity.Geocells(), geomodel.GeoCells(50, 8.001, 10)) {
+		t.Errorf("decoded geocells %v", results[0].Entity.Geocells())
+	}
+
+	var cell Cell
+	if err := cell.Unmarshal((&Cell{ID: "u1m"}).Marshal()); err != nil || cell.ID != "u1m" {
+		t.Errorf("cell round trip = %+v, %v", cell, err)
+	}
+}
@@ -0,0 +1,270 @@
+package geomodelpb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alternaDev/geomodel"
+)
+
+// SERVICE_NAME is the full name of the ProximityService of geomodel.proto.
+const SERVICE_NAME = "geomodel.v1.ProximityService"
+
+// DEFAULT_MAX_RESULTS caps max_results for a ProximityServer without
+// MaxResults.
+const DEFAULT_MAX_RESULTS = 1000
+
+// maxMessageSize bounds request messages, as gRPC does by default.
+const maxMessageSize = 4 << 20
+
+// gRPC status codes returned by ProximityServer.
+const (
+	codeOK               = 0
+	codeCanceled         = 1
+	codeInvalidArgument  = 3
+	codeDeadlineExceeded = 4
+	codeNotFound         = 5
+	codeUnimplemented    = 12
+	codeInternal         = 13
+)
+
+// statusError is an error carrying a gRPC status code.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string { return e.message }
+
+func errorf(code int, format string, args ...any) error {
+	return &statusError{code, fmt.Sprintf(format, args...)}
+}
+
+// ProximityServer implements the ProximityService of geomodel.proto over
+// Search. Its methods can back a service generated from geomodel.proto, and
+// it serves the service itself as an http.Handler speaking the gRPC
+// protocol, without a gRPC runtime. gRPC requires HTTP/2, which net/http
+// serves over TLS, or unencrypted when enabled:
+//
+//	var server = &http.Server{Addr: ":50051", Handler: &geomodelpb.ProximityServer{Search: index.Search}}
+//	server.Protocols = new(http.Protocols)
+//	server.Protocols.SetUnencryptedHTTP2(true)
+//	server.ListenAndServe()
+//
+// Messages must be uncompressed. Its fields must not change once it serves
+// requests.
+type ProximityServer struct {
+	Search geomodel.RepositorySearch
+	// Options are passed to every search.
+	Options []geomodel.Option
+	// MaxResults caps the max_results of Search requests, which are
+	// rejected with INVALID_ARGUMENT above it, so that a request cannot make
+	// the server hold an arbitrary number of results. Zero means
+	// DEFAULT_MAX_RESULTS.
+	MaxResults int
+}
+
+// SearchResults sends the results of r, nearest first, stopping at the first
+// error of send or when ctx is done.
+func (s *ProximityServer) SearchResults(ctx context.Context, r *ProximityRequest, send func(*ProximityResult) error) error {
+	if err := validOrigin(r.Origin); err != nil {
+		return err
+	}
+	var maxResults int = s.MaxResults
+	if maxResults <= 0 {
+		maxResults = DEFAULT_MAX_RESULTS
+	}
+	if uint64(r.MaxResults) > uint64(maxResults) {
+		return errorf(codeInvalidArgument, "max_results %d exceeds %d", r.MaxResults, maxResults)
+	}
+	for _, result := range r.Fetch(s.Search, s.Options...).Results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := send(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Nearest returns the entity nearest to the origin of r, as geomodel.Nearest
+// finds it.
+func (s *ProximityServer) Nearest(ctx context.Context, r *NearestRequest) (*ProximityResult, error) {
+	if err := validOrigin(r.Origin); err != nil {
+		return nil, err
+	}
+	var entity, distance, err = geomodel.Nearest(r.Origin.Lat, r.Origin.Lon, s.Search, s.Options...)
+	if entity == nil {
+		if errors.Is(err, geomodel.ErrNoResults) {
+			return nil, errorf(codeNotFound, "%v", err)
+		}
+		return nil, err
+	}
+	return &ProximityResult{Location: FromEntity(entity), DistanceM: distance}, nil
+}
+
+// Cover returns the cells a search of the circle of r covers, as
+// geomodel.CoverCircle computes them.
+func (s *ProximityServer) Cover(ctx context.Context, r *CoverRequest) (*CoverResponse, error) {
+	if err := validOrigin(r.Center); err != nil {
+		return nil, err
+	}
+	if !(r.RadiusM > 0) {
+		return nil, errorf(codeInvalidArgument, "radius must be positive")
+	}
+	var resolution int = int(r.MaxResolution)
+	if resolution == 0 || resolution > geomodel.MAX_GEOCELL_RESOLUTION {
+		resolution = geomodel.MAX_GEOCELL_RESOLUTION
+	}
+	return &CoverResponse{Cells: geomodel.CoverCircle(r.Center.ToPoint(), r.RadiusM, resolution, s.Options...)}, nil
+}
+
+func validOrigin(p *Point) error {
+	if p == nil {
+		return errorf(codeInvalidArgument, "missing point")
+	}
+	if err := p.ToPoint().Validate(); err != nil {
+		return errorf(codeInvalidArgument, "%v", err)
+	}
+	return nil
+}
+
+// ServeHTTP serves the calls of gRPC clients to the methods of
+// SERVICE_NAME.
+func (s *ProximityServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/grpc" && !strings.HasPrefix(contentType, "application/grpc+proto") {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	var status *statusError = statusOf(s.serveCall(w, r))
+	w.Header().Set("Grpc-Status", strconv.Itoa(status.code))
+	if status.message != "" {
+		w.Header().Set("Grpc-Message", encodeGrpcMessage(status.message))
+	}
+}
+
+// statusOf returns the gRPC status of a call ending with err.
+func statusOf(err error) *statusError {
+	var status *statusError
+	switch {
+	case err == nil:
+		return &statusError{codeOK, ""}
+	case errors.As(err, &status):
+		return status
+	case errors.Is(err, context.DeadlineExceeded):
+		return &statusError{codeDeadlineExceeded, err.Error()}
+	case errors.Is(err, context.Canceled):
+		return &statusError{codeCanceled, err.Error()}
+	}
+	return &statusError{codeInternal, err.Error()}
+}
+
+// serveCall reads the request of the method named by the path of r, calls
+// it and writes its responses.
+func (s *ProximityServer) serveCall(w http.ResponseWriter, r *http.Request) error {
+	var method, ok = strings.CutPrefix(r.URL.Path, "/"+SERVICE_NAME+"/")
+	if !ok || method != "Search" && method != "Nearest" && method != "Cover" {
+		return errorf(codeUnimplemented, "unknown method %s", r.URL.Path)
+	}
+	var data, err = readFrame(r.Body)
+	if err != nil {
+		return err
+	}
+	var ctx context.Context = r.Context()
+
+	switch method {
+	case "Search":
+		var request ProximityRequest
+		if err := request.Unmarshal(data); err != nil {
+			return errorf(codeInvalidArgument, "%v", err)
+		}
+		return s.SearchResults(ctx, &request, func(result *ProximityResult) error {
+			return writeFrame(w, result.Marshal())
+		})
+	case "Nearest":
+		var request NearestRequest
+		if err := request.Unmarshal(data); err != nil {
+			return errorf(codeInvalidArgument, "%v", err)
+		}
+		var result, err = s.Nearest(ctx, &request)
+		if err != nil {
+			return err
+		}
+		return writeFrame(w, result.Marshal())
+	default:
+		var request CoverRequest
+		if err := request.Unmarshal(data); err != nil {
+			return errorf(codeInvalidArgument, "%v", err)
+		}
+		var response, err = s.Cover(ctx, &request)
+		if err != nil {
+			return err
+		}
+		return writeFrame(w, response.Marshal())
+	}
+}
+
+// readFrame reads the single, uncompressed message of a unary or
+// server-streaming call: a compression flag, a four-byte big-endian length
+// and the message.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, errorf(codeInvalidArgument, "reading request: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, errorf(codeUnimplemented, "compressed messages are not supported")
+	}
+	var size uint32 = binary.BigEndian.Uint32(header[1:])
+	if size > maxMessageSize {
+		return nil, errorf(codeInvalidArgument, "request of %d bytes exceeds %d", size, maxMessageSize)
+	}
+	var data []byte = make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errorf(codeInvalidArgument, "reading request: %v", err)
+	}
+	return data, nil
+}
+
+// writeFrame writes message uncompressed and flushes it to the client.
+func writeFrame(w http.ResponseWriter, message []byte) error {
+	var frame []byte = make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	if _, err := w.Write(append(frame, message...)); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// encodeGrpcMessage percent-encodes the bytes of message outside printable
+// ASCII, and percent signs, as grpc-message requires.
+func encodeGrpcMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		if c := message[i]; c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
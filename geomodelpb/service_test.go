@@ -0,0 +1,162 @@
+package geomodelpb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+func newServer() *ProximityServer {
+	var index = geomodel.NewGeoIndex(
+		&geomodel.IndexRecord{ID: "a", Lat: 50, Lon: 8.001, Cells: geomodel.GeoCells(50, 8.001, 10)},
+		&geomodel.IndexRecord{ID: "b", Lat: 50, Lon: 8.002, Cells: geomodel.GeoCells(50, 8.002, 10)},
+		&geomodel.IndexRecord{ID: "c", Lat: 51, Lon: 8, Cells: geomodel.GeoCells(51, 8, 10)},
+	)
+	return &ProximityServer{Search: index.Search}
+}
+
+// call makes a gRPC call to method with request over client and returns the
+// response messages and the status trailers.
+func call(t *testing.T, client *http.Client, url, method string, request []byte) ([][]byte, http.Header) {
+	var body []byte = make([]byte, 5, 5+len(request))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(request)))
+	req, _ := http.NewRequest("POST", url+"/"+SERVICE_NAME+"/"+method, bytes.NewReader(append(body, request...)))
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/grpc" {
+		t.Fatalf("%s: HTTP %d, %s", method, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var messages [][]byte
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(resp.Body, header[:]); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		var message []byte = make([]byte, binary.BigEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(resp.Body, message); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, resp.Trailer
+}
+
+func TestProximityServer(t *testing.T) {
+	var ts = httptest.NewUnstartedServer(newServer())
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	var client *http.Client = ts.Client()
+
+	var request = &ProximityRequest{Origin: &Point{Lat: 50, Lon: 8}, MaxResults: 5, MaxDistanceM: 1000}
+	var messages, trailer = call(t, client, ts.URL, "Search", request.Marshal())
+	if trailer.Get("Grpc-Status") != "0" || len(messages) != 2 {
+		t.Fatalf("Search: status %q, %d messages", trailer.Get("Grpc-Status"), len(messages))
+	}
+	var previous float64
+	for i, message := range messages {
+		var result ProximityResult
+		if err := result.Unmarshal(message); err != nil || result.Location.Key != "ab"[i:i+1] || result.DistanceM < previous {
+			t.Errorf("Search result %d = %+v, %v", i, result, err)
+		}
+		previous = result.DistanceM
+	}
+
+	messages, trailer = call(t, client, ts.URL, "Nearest", (&NearestRequest{Origin: &Point{Lat: 51.1, Lon: 8}}).Marshal())
+	var nearest ProximityResult
+	if trailer.Get("Grpc-Status") != "0" || len(messages) != 1 || nearest.Unmarshal(messages[0]) != nil || nearest.Location.Key != "c" {
+		t.Errorf("Nearest: status %q, result %+v", trailer.Get("Grpc-Status"), nearest)
+	}
+
+	var cover = &CoverRequest{Center: &Point{Lat: 50, Lon: 8}, RadiusM: 1000, MaxResolution: 6}
+	messages, trailer = call(t, client, ts.URL, "Cover", cover.Marshal())
+	var covering CoverResponse
+	if trailer.Get("Grpc-Status") != "0" || len(messages) != 1 || covering.Unmarshal(messages[0]) != nil ||
+		len(covering.Cells) == 0 || len(covering.Cells[0]) > 6 {
+		t.Errorf("Cover: status %q, cells %v", trailer.Get("Grpc-Status"), covering.Cells)
+	}
+
+	// Errors end the call with a status and no messages.
+	for _, c := range []struct {
+		method  string
+		request []byte
+		status  string
+	}{
+		{"Search", (&ProximityRequest{MaxResults: 1}).Marshal(), "3"},
+		{"Search", []byte{0xff}, "3"},
+		{"Search", (&ProximityRequest{Origin: &Point{Lat: 50, Lon: 8}, MaxResults: math.MaxUint32}).Marshal(), "3"},
+		{"Search", (&ProximityRequest{Origin: &Point{Lat: 50, Lon: 8}, MaxResults: DEFAULT_MAX_RESULTS + 1}).Marshal(), "3"},
+		{"Cover", (&CoverRequest{Center: &Point{Lat: 50, Lon: 8}}).Marshal(), "3"},
+		{"Nearest", (&NearestRequest{Origin: &Point{Lat: 91}}).Marshal(), "3"},
+		{"Delete", nil, "12"},
+	} {
+		if messages, trailer = call(t, client, ts.URL, c.method, c.request); trailer.Get("Grpc-Status") != c.status || len(messages) != 0 {
+			t.Errorf("%s: status %q with %d messages, want %s", c.method, trailer.Get("Grpc-Status"), len(messages), c.status)
+		}
+	}
+
+	var capped = &ProximityServer{Search: newServer().Search, MaxResults: 1}
+	var sent int
+	if err := capped.SearchResults(t.Context(), &ProximityRequest{Origin: &Point{Lat: 50, Lon: 8}, MaxResults: 1}, func(*ProximityResult) error { sent++; return nil }); err != nil || sent != 1 {
+		t.Errorf("Search within MaxResults sent %d results, %v", sent, err)
+	}
+	if err := capped.SearchResults(t.Context(), &ProximityRequest{Origin: &Point{Lat: 50, Lon: 8}, MaxResults: 2}, nil); err == nil || err.(*statusError).code != codeInvalidArgument {
+		t.Errorf("Search beyond MaxResults = %v, want INVALID_ARGUMENT", err)
+	}
+
+	var empty = &ProximityServer{Search: geomodel.NewGeoIndex().Search}
+	if _, err := empty.Nearest(t.Context(), &NearestRequest{Origin: &Point{}}); err == nil || err.(*statusError).code != codeNotFound {
+		t.Errorf("Nearest over no entities = %v, want NOT_FOUND", err)
+	}
+}
+
+func TestServiceMessages(t *testing.T) {
+	var cover = &CoverRequest{Center: &Point{Lat: 50, Lon: 8}, RadiusM: 1000, MaxResolution: 6}
+	var decoded CoverRequest
+	if err := decoded.Unmarshal(cover.Marshal()); err != nil || *decoded.Center != *cover.Center || decoded.RadiusM != 1000 || decoded.MaxResolution != 6 {
+		t.Errorf("CoverRequest round trip = %+v, %v", decoded, err)
+	}
+	var response CoverResponse
+	if err := response.Unmarshal((&CoverResponse{Cells: []string{"u0", "u1"}}).Marshal()); err != nil || len(response.Cells) != 2 || response.Cells[1] != "u1" {
+		t.Errorf("CoverResponse round trip = %+v, %v", response, err)
+	}
+	if got := encodeGrpcMessage("50% off\n"); got != "50%25 off%0A" {
+		t.Errorf("encodeGrpcMessage = %q", got)
+	}
+
+	for _, c := range []struct {
+		err  error
+		code int
+	}{
+		{nil, codeOK},
+		{errorf(codeNotFound, "none"), codeNotFound},
+		{context.Canceled, codeCanceled},
+		{fmt.Errorf("search: %w", context.DeadlineExceeded), codeDeadlineExceeded},
+		{io.ErrUnexpectedEOF, codeInternal},
+	} {
+		if got := statusOf(c.err).code; got != c.code {
+			t.Errorf("statusOf(%v) = %d, want %d", c.err, got, c.code)
+		}
+	}
+
+	var w = httptest.NewRecorder()
+	newServer().ServeHTTP(w, httptest.NewRequest("POST", "/"+SERVICE_NAME+"/Search", nil))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("request without gRPC content type got HTTP %d", w.Code)
+	}
+}
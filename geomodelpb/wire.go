@@ -0,0 +1,145 @@
+package geomodelpb
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// ErrInvalidMessage is returned for input that is not a valid encoding of
+// the message being decoded.
+var ErrInvalidMessage = errors.New("geomodelpb: invalid message")
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendTag(buf []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(field)<<3|uint64(wireType))
+}
+
+// appendDouble appends a double field, omitted when zero as in proto3.
+func appendDouble(buf []byte, field int, v float64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = appendTag(buf, field, wireFixed64)
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+}
+
+// appendUint32 appends a uint32 field, omitted when zero.
+func appendUint32(buf []byte, field int, v uint32) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = appendTag(buf, field, wireVarint)
+	return binary.AppendUvarint(buf, uint64(v))
+}
+
+// appendString appends a string field, omitted when empty unless it is an
+// element of a repeated field.
+func appendString(buf []byte, field int, s string, repeated bool) []byte {
+	if s == "" && !repeated {
+		return buf
+	}
+	buf = appendTag(buf, field, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// appendMessage appends an embedded message field encoded by marshal.
+func appendMessage(buf []byte, field int, marshal func([]byte) []byte) []byte {
+	buf = appendTag(buf, field, wireBytes)
+	var start int = len(buf)
+	buf = marshal(buf)
+	var body []byte = append([]byte(nil), buf[start:]...)
+	buf = binary.AppendUvarint(buf[:start], uint64(len(body)))
+	return append(buf, body...)
+}
+
+// decoder reads the fields of one message.
+type decoder struct {
+	data []byte
+}
+
+// next returns the number and wire type of the next field, or false at the
+// end of the message.
+func (d *decoder) next() (int, int, bool, error) {
+	if len(d.data) == 0 {
+		return 0, 0, false, nil
+	}
+	var tag uint64 = d.varint()
+	if d.data == nil || tag>>3 == 0 {
+		return 0, 0, false, ErrInvalidMessage
+	}
+	return int(tag >> 3), int(tag & 7), true, nil
+}
+
+// varint reads a varint, setting data to nil on error.
+func (d *decoder) varint() uint64 {
+	var v, n = binary.Uvarint(d.data)
+	if n <= 0 {
+		d.data = nil
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) double() (float64, error) {
+	if len(d.data) < 8 {
+		return 0, ErrInvalidMessage
+	}
+	var v float64 = math.Float64frombits(binary.LittleEndian.Uint64(d.data))
+	d.data = d.data[8:]
+	return v, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	var n uint64 = d.varint()
+	if d.data == nil && n == 0 || n > uint64(len(d.data)) {
+		return nil, ErrInvalidMessage
+	}
+	var b []byte = d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+// skip discards a field of an unknown number.
+func (d *decoder) skip(wireType int) error {
+	switch wireType {
+	case wireVarint:
+		if d.varint(); d.data == nil {
+			return ErrInvalidMessage
+		}
+	case wireFixed64:
+		if len(d.data) < 8 {
+			return ErrInvalidMessage
+		}
+		d.data = d.data[8:]
+	case wireBytes:
+		if _, err := d.bytes(); err != nil {
+			return err
+		}
+	case wireFixed32:
+		if len(d.data) < 4 {
+			return ErrInvalidMessage
+		}
+		d.data = d.data[4:]
+	default:
+		return ErrInvalidMessage
+	}
+	return nil
+}
+
+// expect checks that a known field has the wire type of its declaration.
+func expect(got, want int) error {
+	if got != want {
+		return ErrInvalidMessage
+	}
+	return nil
+}
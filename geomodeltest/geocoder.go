@@ -0,0 +1,23 @@
+// Package geomodeltest provides test doubles for code using geomodel.
+package geomodeltest
+
+import (
+	"strings"
+
+	"github.com/alternaDev/geomodel"
+)
+
+// Geocoder is a geomodel.Geocoder resolving addresses from a fixed table.
+// Lookups ignore case and surrounding whitespace; unknown addresses fail
+// with geomodel.ErrAddressNotFound.
+type Geocoder map[string]geomodel.Point
+
+func (g Geocoder) Geocode(address string) (geomodel.Point, error) {
+	var normalized string = strings.ToLower(strings.TrimSpace(address))
+	for a, p := range g {
+		if strings.ToLower(strings.TrimSpace(a)) == normalized {
+			return p, nil
+		}
+	}
+	return geomodel.Point{}, geomodel.ErrAddressNotFound
+}
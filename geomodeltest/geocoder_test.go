@@ -0,0 +1,44 @@
+package geomodeltest
+
+import (
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+type place struct {
+	lat, lon float64
+	key      string
+}
+
+func (p place) Latitude() float64  { return p.lat }
+func (p place) Longitude() float64 { return p.lon }
+func (p place) Key() string        { return p.key }
+func (p place) Geocells() []string { return geomodel.GeoCells(p.lat, p.lon, 10) }
+
+func TestProximityFetchByAddress(t *testing.T) {
+	var geocoder = Geocoder{"Alexanderplatz, Berlin": {Lat: 52.5219, Lon: 13.4132}}
+	var places = []geomodel.LocationCapable{place{52.5219, 13.4132, "tv-tower"}}
+	var search = func(cells []string) []geomodel.LocationCapable {
+		for _, cell := range cells {
+			for _, c := range places[0].Geocells() {
+				if c == cell {
+					return places
+				}
+			}
+		}
+		return nil
+	}
+
+	result, err := geomodel.ProximityFetchByAddress(" alexanderplatz, berlin", geocoder, 1, 0, search, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Key() != "tv-tower" {
+		t.Errorf("got %v, want the tv-tower", result)
+	}
+
+	if _, err := geomodel.ProximityFetchByAddress("nowhere", geocoder, 1, 0, search, 10); err != geomodel.ErrAddressNotFound {
+		t.Errorf("got error %v, want ErrAddressNotFound", err)
+	}
+}
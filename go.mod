@@ -0,0 +1,3 @@
+module github.com/alternaDev/geomodel
+
+go 1.24
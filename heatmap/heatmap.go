@@ -0,0 +1,200 @@
+// Package heatmap draws per-cell values as PNG heat overlays, for quick
+// dashboards and debugging without a GIS stack:
+//
+//	var counts = geomodel.Aggregate(entities, 5, func(n float64, _ geomodel.LocationCapable) float64 { return n + 1 })
+//	var err = heatmap.WritePNG(w, counts, 1024, 512, heatmap.WithBounds(europe))
+//
+// Each cell is filled with the color the ramp gives its value, scaled
+// between the smallest and largest value drawn; pixels outside every cell
+// stay transparent. Images are equirectangular by default, or Web Mercator
+// to lay over web maps.
+package heatmap
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/alternaDev/geomodel"
+)
+
+// Projection maps latitudes to image rows. Longitudes map linearly to
+// columns in both.
+type Projection int
+
+const (
+	// EQUIRECTANGULAR spaces latitudes evenly.
+	EQUIRECTANGULAR Projection = iota
+	// WEB_MERCATOR stretches latitudes towards the poles like web map tiles,
+	// ending at about ±85.05°.
+	WEB_MERCATOR
+)
+
+// maxMercatorLat is the latitude at which Web Mercator maps end.
+var maxMercatorLat = 180 / math.Pi * math.Atan(math.Sinh(math.Pi))
+
+// Ramp returns the color of a value scaled to [0, 1].
+type Ramp func(t float64) color.Color
+
+// LinearRamp returns a ramp blending evenly between the given colors, the
+// first for 0 and the last for 1.
+func LinearRamp(stops ...color.NRGBA) Ramp {
+	return func(t float64) color.Color {
+		if len(stops) == 0 {
+			return color.NRGBA{}
+		}
+		var f float64 = math.Max(0, math.Min(1, t)) * float64(len(stops)-1)
+		var i int = min(int(f), len(stops)-2)
+		if i < 0 {
+			return stops[0]
+		}
+		var a, b color.NRGBA = stops[i], stops[i+1]
+		f -= float64(i)
+		var mix = func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + f*(float64(y)-float64(x)))) }
+		return color.NRGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+	}
+}
+
+// DefaultRamp runs from translucent blue for the smallest values through
+// cyan and yellow to opaque red for the largest.
+var DefaultRamp Ramp = LinearRamp(
+	color.NRGBA{0, 0, 255, 96},
+	color.NRGBA{0, 255, 255, 144},
+	color.NRGBA{255, 255, 0, 200},
+	color.NRGBA{255, 0, 0, 255},
+)
+
+// Option configures Render and WritePNG.
+type Option func(*options)
+
+type options struct {
+	projection Projection
+	bounds     *geomodel.BoundingBox
+	ramp       Ramp
+	scaled     bool
+	min, max   float64
+}
+
+// WithProjection selects the projection; the default is EQUIRECTANGULAR.
+func WithProjection(p Projection) Option {
+	return func(o *options) { o.projection = p }
+}
+
+// WithBounds draws the area of bbox, which may cross the antimeridian,
+// instead of the whole world. Web Mercator images end at about ±85.05°
+// whatever the bounds.
+func WithBounds(bbox geomodel.BoundingBox) Option {
+	return func(o *options) { o.bounds = &bbox }
+}
+
+// WithRamp colors cells with ramp instead of DefaultRamp.
+func WithRamp(ramp Ramp) Option {
+	return func(o *options) { o.ramp = ramp }
+}
+
+// WithRange scales values between min and max instead of between the
+// smallest and largest value drawn, so that images rendered at different
+// times compare. Values outside the range take the color of its nearest end.
+func WithRange(min, max float64) Option {
+	return func(o *options) { o.scaled, o.min, o.max = true, min, max }
+}
+
+// Render returns a width×height image of values by cell. Coarser cells are
+// drawn first, so that finer cells inside them stay visible, and cells
+// narrower than a pixel fill one. Cells that do not decode are skipped.
+func Render(values map[string]float64, width, height int, opts ...Option) *image.NRGBA {
+	var o options = options{ramp: DefaultRamp}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var img *image.NRGBA = image.NewNRGBA(image.Rect(0, 0, max(width, 0), max(height, 0)))
+
+	var bounds geomodel.BoundingBox = geomodel.NewBoundingBox(90, 180, -90, -180)
+	if o.bounds != nil {
+		bounds = *o.bounds
+	}
+	var project = func(lat float64) float64 { return lat }
+	if o.projection == WEB_MERCATOR {
+		project = func(lat float64) float64 {
+			lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat)) * math.Pi / 180
+			return math.Log(math.Tan(math.Pi/4 + lat/2))
+		}
+	}
+	var top, bottom float64 = project(bounds.North()), project(bounds.South())
+	var west float64 = bounds.West()
+	var lonWidth float64 = bounds.East() - west
+	if bounds.CrossesAntimeridian() {
+		lonWidth += 360
+	}
+	if top <= bottom || lonWidth <= 0 {
+		return img
+	}
+
+	var cells []string
+	var boxes map[string]geomodel.BoundingBox = make(map[string]geomodel.BoundingBox, len(values))
+	var low, high float64 = math.Inf(1), math.Inf(-1)
+	for cell, value := range values {
+		var box, err = geomodel.DecodeCell(cell)
+		if err != nil || math.IsNaN(value) {
+			continue
+		}
+		cells = append(cells, cell)
+		boxes[cell] = box
+		low, high = math.Min(low, value), math.Max(high, value)
+	}
+	if o.scaled {
+		low, high = o.min, o.max
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return len(cells[i]) < len(cells[j]) || len(cells[i]) == len(cells[j]) && cells[i] < cells[j]
+	})
+
+	for _, cell := range cells {
+		var box geomodel.BoundingBox = boxes[cell]
+		var cellWidth float64 = box.East() - box.West()
+		// The offset of the cell's west edge from that of the bounds, taken
+		// negative for a cell straddling it.
+		var offset float64 = math.Mod(box.West()-west+360, 360)
+		if offset+cellWidth > 360 {
+			offset -= 360
+		}
+		var x0, x1 int = pixelSpan(offset/lonWidth*float64(width), (offset+cellWidth)/lonWidth*float64(width), width)
+		var y0, y1 int = pixelSpan((top-project(box.North()))/(top-bottom)*float64(height), (top-project(box.South()))/(top-bottom)*float64(height), height)
+		if x0 >= x1 || y0 >= y1 {
+			continue
+		}
+
+		var t float64 = 1
+		if high > low {
+			t = (values[cell] - low) / (high - low)
+		}
+		var c color.NRGBA = color.NRGBAModel.Convert(o.ramp(t)).(color.NRGBA)
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+	return img
+}
+
+// pixelSpan returns the pixels from start to end, rounded to the nearest
+// pixel edges but at least one pixel wide, and clipped to [0, size).
+func pixelSpan(start, end float64, size int) (int, int) {
+	if end <= 0 || start >= float64(size) {
+		return 0, 0
+	}
+	var first, last int = int(math.Round(start)), int(math.Round(end))
+	if last <= first {
+		last = first + 1
+	}
+	return max(first, 0), min(last, size)
+}
+
+// WritePNG renders values like Render and writes the image to w as a PNG.
+func WritePNG(w io.Writer, values map[string]float64, width, height int, opts ...Option) error {
+	return png.Encode(w, Render(values, width, height, opts...))
+}
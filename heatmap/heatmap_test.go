@@ -0,0 +1,69 @@
+package heatmap
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+func TestRender(t *testing.T) {
+	// "s" spans latitudes 0..45 and longitudes 0..45, "s0" its south-west
+	// corner, "9" latitudes 0..45 and longitudes -135..-90.
+	var values = map[string]float64{"s": 1, "s0": 3, "9": 2, "not a cell": 9}
+	var img = Render(values, 360, 180, WithRamp(LinearRamp(color.NRGBA{0, 0, 0, 255}, color.NRGBA{200, 0, 0, 255})))
+
+	var checks = []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{200, 60, color.NRGBA{0, 0, 0, 255}},   // in "s", the smallest value
+		{182, 88, color.NRGBA{200, 0, 0, 255}}, // in "s0", drawn over "s"
+		{50, 60, color.NRGBA{100, 0, 0, 255}},  // in "9", halfway
+		{10, 10, color.NRGBA{}},                // no cell
+		{226, 60, color.NRGBA{}},               // just east of "s"
+	}
+	for _, c := range checks {
+		if got := img.NRGBAAt(c.x, c.y); got != c.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", c.x, c.y, got, c.want)
+		}
+	}
+}
+
+func TestRenderBoundsAndProjection(t *testing.T) {
+	// Bounds across the antimeridian, from 170 to -170.
+	var bounds = geomodel.NewBoundingBox(10, -170, -10, 170)
+	var values = map[string]float64{geomodel.GeoCell(0.5, 179.5, 3): 1, geomodel.GeoCell(0.5, -179.5, 3): 1}
+	var img = Render(values, 200, 200, WithBounds(bounds), WithRange(0, 2))
+	var want = DefaultRamp(0.5).(color.NRGBA)
+	if got := img.NRGBAAt(98, 90); got != want {
+		t.Errorf("pixel west of the antimeridian = %v, want %v", got, want)
+	}
+	if got := img.NRGBAAt(102, 90); got != want {
+		t.Errorf("pixel east of the antimeridian = %v, want %v", got, want)
+	}
+
+	// In Web Mercator the cell "u", latitudes 45..90 and longitudes 0..45, covers the rows from
+	// the top down to latitude 45.
+	img = Render(map[string]float64{"u": 1}, 256, 256, WithProjection(WEB_MERCATOR))
+	var row45 int = 92 // 128·(1 - ln(tan(67.5°))/π)
+	if img.NRGBAAt(140, 0).A == 0 || img.NRGBAAt(140, row45-2).A == 0 || img.NRGBAAt(140, row45+1).A != 0 {
+		t.Errorf("Mercator rows around latitude 45: %v %v %v", img.NRGBAAt(140, 0), img.NRGBAAt(140, row45-2), img.NRGBAAt(140, row45+1))
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, map[string]float64{"s": 1}, 64, 32); err != nil {
+		t.Fatal(err)
+	}
+	var img, err = png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 64 || size.Y != 32 {
+		t.Errorf("decoded image is %v, want 64×32", size)
+	}
+}
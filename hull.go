@@ -0,0 +1,103 @@
+package geomodel
+
+import (
+	"cmp"
+	"slices"
+)
+
+// BoundsOf returns the smallest box holding the locations of entities, such
+// as to zoom a map to the results of a search. Of the ways to span their
+// longitudes around the globe the narrowest is taken, so entities on both
+// sides of the antimeridian yield a box crossing it. It returns the zero
+// BoundingBox if entities is empty.
+func BoundsOf(entities []LocationCapable) BoundingBox {
+	if len(entities) == 0 {
+		return BoundingBox{}
+	}
+	var north, south float64 = entities[0].Latitude(), entities[0].Latitude()
+	var lons []float64 = make([]float64, len(entities))
+	for i, entity := range entities {
+		north, south = max(north, entity.Latitude()), min(south, entity.Latitude())
+		lons[i] = wrapLon(entity.Longitude())
+	}
+	slices.Sort(lons)
+
+	// The box leaves out the widest gap between neighboring longitudes,
+	// starting with the gap across the antimeridian.
+	var west, east float64 = lons[0], lons[len(lons)-1]
+	var gap float64 = lons[0] + 360 - lons[len(lons)-1]
+	for i := 1; i < len(lons); i++ {
+		if lons[i]-lons[i-1] > gap {
+			gap = lons[i] - lons[i-1]
+			west, east = lons[i], lons[i-1]
+		}
+	}
+	return BoundingBox{north, east, south, west}
+}
+
+// HullOf returns the convex hull of the locations of entities in the plane
+// of latitudes and longitudes, as drawn on a web map, counter-clockwise from
+// its westernmost (then southernmost) corner and without repeating it. The
+// hull of entities on both sides of the antimeridian is taken around the
+// narrower side, as for BoundsOf, with longitudes wrapped into [-180, 180).
+// Points on an edge of the hull are left out; fewer than three distinct
+// locations are returned as they are.
+func HullOf(entities []LocationCapable) []Point {
+	if len(entities) == 0 {
+		return nil
+	}
+	// Longitudes measured eastwards from the west edge of the bounds, so
+	// that the hull does not wrap.
+	var west float64 = BoundsOf(entities).lonSW
+	var points []Point = make([]Point, len(entities))
+	for i, entity := range entities {
+		var lon float64 = wrapLon(entity.Longitude()) - west
+		if lon < 0 {
+			lon += 360
+		}
+		points[i] = Point{Lat: entity.Latitude(), Lon: lon}
+	}
+	slices.SortFunc(points, func(a, b Point) int {
+		if c := cmp.Compare(a.Lon, b.Lon); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Lat, b.Lat)
+	})
+	points = slices.Compact(points)
+
+	var hull []Point = points
+	if len(points) > 2 {
+		hull = monotoneChain(points)
+	}
+	for i := range hull {
+		hull[i].Lon = wrapLon(hull[i].Lon + west)
+	}
+	return hull
+}
+
+// monotoneChain returns the convex hull of points, sorted by longitude and
+// then latitude, with Andrew's monotone chain algorithm.
+func monotoneChain(points []Point) []Point {
+	// cross is positive if o, a, b turn counter-clockwise.
+	var cross = func(o, a, b Point) float64 {
+		return (a.Lon-o.Lon)*(b.Lat-o.Lat) - (a.Lat-o.Lat)*(b.Lon-o.Lon)
+	}
+
+	var hull []Point = make([]Point, 0, 2*len(points))
+	// The lower chain from west to east, then the upper one back.
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	var lower int = len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], points[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, points[i])
+	}
+	// The last point is the first.
+	return hull[:len(hull)-1]
+}
@@ -0,0 +1,53 @@
+package geomodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func placesAt(points ...Point) []LocationCapable {
+	var result []LocationCapable
+	for _, p := range points {
+		result = append(result, Place{lat: p.Lat, lon: p.Lon})
+	}
+	return result
+}
+
+func TestBoundsOf(t *testing.T) {
+	var cases = []struct {
+		points []Point
+		want   BoundingBox
+	}{
+		{nil, BoundingBox{}},
+		{[]Point{{50, 8}}, NewBoundingBox(50, 8, 50, 8)},
+		{[]Point{{50, 8}, {48, 11}, {52, 9}}, NewBoundingBox(52, 11, 48, 8)},
+		// Fiji, across the antimeridian.
+		{[]Point{{-16, 178}, {-18, -179}, {-17, 177}}, BoundingBox{-16, -179, -18, 177}},
+		{[]Point{{0, -170}, {0, 0}, {0, 170}}, NewBoundingBox(0, -170, 0, 0)},
+	}
+	for _, c := range cases {
+		if got := BoundsOf(placesAt(c.points...)); got != c.want {
+			t.Errorf("BoundsOf(%v) = %v, want %v", c.points, got, c.want)
+		}
+	}
+}
+
+func TestHullOf(t *testing.T) {
+	var cases = []struct {
+		points []Point
+		want   []Point
+	}{
+		{nil, nil},
+		{[]Point{{1, 1}, {1, 1}}, []Point{{1, 1}}},
+		{[]Point{{0, 0}, {2, 2}}, []Point{{0, 0}, {2, 2}}},
+		// A square with inner points and a point on an edge.
+		{[]Point{{0, 0}, {1, 1}, {0, 2}, {2, 2}, {2, 0}, {0, 1}, {1.5, 0.5}}, []Point{{0, 0}, {0, 2}, {2, 2}, {2, 0}}},
+		// Across the antimeridian.
+		{[]Point{{0, 179}, {1, -179}, {-1, -179}, {0, -179.5}}, []Point{{0, 179}, {-1, -179}, {1, -179}}},
+	}
+	for _, c := range cases {
+		if got := HullOf(placesAt(c.points...)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("HullOf(%v) = %v, want %v", c.points, got, c.want)
+		}
+	}
+}
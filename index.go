@@ -0,0 +1,489 @@
+package geomodel
+
+import (
+	"errors"
+	"hash/maphash"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeyNotFound is returned by GeoIndex.Move for a key with no live
+	// entity.
+	ErrKeyNotFound = errors.New("geomodel: key not found")
+	// ErrNotRelocatable is returned by GeoIndex.Move for an entity not
+	// implementing Relocatable.
+	ErrNotRelocatable = errors.New("geomodel: entity is not relocatable")
+)
+
+// Relocatable is implemented by entities that GeoIndex.Move can move.
+type Relocatable interface {
+	LocationCapable
+	// Relocate returns a copy of the entity at (lat, lon) with the given
+	// geocells, leaving the receiver unchanged so that concurrent readers
+	// holding it are not affected.
+	Relocate(lat, lon float64, geocells []string) LocationCapable
+}
+
+// GeoIndex is an in-memory repository of entities keyed by Key and indexed
+// by each of their Geocells, for proximity search over in-process data
+// without a storage backend:
+//
+//	var index = geomodel.NewGeoIndex()
+//	index.Insert(place)
+//	var nearby = geomodel.ProximityFetch(lat, lon, 10, 1000, index.Search, geomodel.MAX_GEOCELL_RESOLUTION)
+//
+// A GeoIndex is safe for concurrent use. Entities are sharded by their
+// top-level cell, each shard under its own lock, and the shard of each key
+// is looked up under one of several locks chosen by key, so that a write
+// locks only the shards it touches and writes in one region block neither
+// writes nor searches in another.
+//
+// Entities inserted with InsertWithTTL expire: searches skip them once
+// their time is up, and Sweep or a sweeper started with StartSweeper
+// reclaims them. The zero value is not usable; create indexes with
+// NewGeoIndex.
+type GeoIndex struct {
+	// The shard holding each stored entity, by key, split into stripes by
+	// key hash. Writers hold the stripe of their key; searches do not take
+	// them.
+	stripes [indexStripes]keyStripe
+	seed    maphash.Seed
+	shards  [len(GEOCELL_ALPHABET)]indexShard
+	// now returns the current time, for expiry.
+	now func() time.Time
+}
+
+// indexStripes is the number of stripes of GeoIndex keys.
+const indexStripes = 64
+
+// keyStripe maps the keys hashed to it to their shards. Its lock is taken
+// before any shard lock, and serializes writes of its keys.
+type keyStripe struct {
+	mu      sync.RWMutex
+	shardOf map[string]*indexShard
+}
+
+// indexShard holds the entities whose geocells start with one top-level
+// cell.
+type indexShard struct {
+	mu sync.RWMutex
+	// The stored entities by key.
+	entities map[string]LocationCapable
+	// The keys of the entities having each cell among their geocells.
+	cells map[string]map[string]struct{}
+	// cells restricted to each non-empty partition, by partition.
+	partitions map[string]map[string]map[string]struct{}
+	// The expiry of the entities inserted with a TTL, by key.
+	expires map[string]time.Time
+}
+
+// NewGeoIndex returns an index holding entities.
+func NewGeoIndex(entities ...LocationCapable) *GeoIndex {
+	var index *GeoIndex = &GeoIndex{seed: maphash.MakeSeed(), now: time.Now}
+	for i := range index.stripes {
+		index.stripes[i].shardOf = make(map[string]*indexShard)
+	}
+	for i := range index.shards {
+		index.shards[i].entities = make(map[string]LocationCapable)
+		index.shards[i].cells = make(map[string]map[string]struct{})
+		index.shards[i].partitions = make(map[string]map[string]map[string]struct{})
+		index.shards[i].expires = make(map[string]time.Time)
+	}
+	for _, entity := range entities {
+		index.Insert(entity)
+	}
+	return index
+}
+
+// stripe returns the stripe of key.
+func (x *GeoIndex) stripe(key string) *keyStripe {
+	return &x.stripes[maphash.String(x.seed, key)%indexStripes]
+}
+
+// lockStripes locks every stripe, in order, excluding all writers.
+func (x *GeoIndex) lockStripes() {
+	for i := range x.stripes {
+		x.stripes[i].mu.Lock()
+	}
+}
+
+func (x *GeoIndex) unlockStripes() {
+	for i := range x.stripes {
+		x.stripes[i].mu.Unlock()
+	}
+}
+
+// shard returns the shard for cells starting with cell's first character.
+// Cells outside the alphabet share the first shard.
+func (x *GeoIndex) shard(cell string) *indexShard {
+	if cell == "" {
+		return &x.shards[0]
+	}
+	return &x.shards[max(strings.IndexByte(GEOCELL_ALPHABET, cell[0]), 0)]
+}
+
+// shardFor returns the shard holding entity. The geocells of a point all
+// share its top-level cell, so the first one decides.
+func (x *GeoIndex) shardFor(entity LocationCapable) *indexShard {
+	var cells []string = entity.Geocells()
+	if len(cells) == 0 {
+		return &x.shards[0]
+	}
+	return x.shard(cells[0])
+}
+
+// Insert stores entity under its key, replacing any entity with the same
+// key. The entity does not expire.
+func (x *GeoIndex) Insert(entity LocationCapable) {
+	var stripe *keyStripe = x.stripe(entity.Key())
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	x.insert(stripe, entity, time.Time{})
+}
+
+// InsertWithTTL stores entity under its key like Insert, expiring it ttl
+// from now. Inserting the entity again refreshes or clears its expiry.
+func (x *GeoIndex) InsertWithTTL(entity LocationCapable, ttl time.Duration) {
+	var stripe *keyStripe = x.stripe(entity.Key())
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	x.insert(stripe, entity, x.now().Add(ttl))
+}
+
+// insert stores entity with the lock of stripe, the stripe of its key, held,
+// expiring it at expires unless that is the zero time.
+func (x *GeoIndex) insert(stripe *keyStripe, entity LocationCapable, expires time.Time) {
+	var key string = entity.Key()
+	if old, ok := stripe.shardOf[key]; ok {
+		old.mu.Lock()
+		old.remove(key)
+		old.mu.Unlock()
+	}
+
+	var shard *indexShard = x.shardFor(entity)
+	shard.mu.Lock()
+	shard.add(entity)
+	if !expires.IsZero() {
+		shard.expires[key] = expires
+	}
+	shard.mu.Unlock()
+	stripe.shardOf[key] = shard
+}
+
+// Update replaces the entity with the same key as entity and reports whether
+// there was one, keeping its expiry. An entity not already in the index, or
+// expired, is not added.
+func (x *GeoIndex) Update(entity LocationCapable) bool {
+	var key string = entity.Key()
+	var stripe *keyStripe = x.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	var shard, ok = stripe.shardOf[key]
+	if !ok {
+		return false
+	}
+	shard.mu.RLock()
+	var expires time.Time = shard.expires[key]
+	shard.mu.RUnlock()
+	if !expires.IsZero() && !x.now().Before(expires) {
+		return false
+	}
+	x.insert(stripe, entity, expires)
+	return true
+}
+
+// Move relocates the entity stored under key to (lat, lon), keeping its
+// expiry. Its geocells are recomputed at their current resolutions, and only
+// the cells that differ are unlinked and relinked, so that frequent small
+// moves touch few cells. The entity must implement Relocatable, and the
+// coordinates must pass Point.Validate.
+func (x *GeoIndex) Move(key string, lat, lon float64) error {
+	if err := (Point{lat, lon}).Validate(); err != nil {
+		return err
+	}
+	var stripe *keyStripe = x.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	var shard, ok = stripe.shardOf[key]
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	shard.mu.Lock()
+	var entity LocationCapable = shard.entities[key]
+	var expires time.Time = shard.expires[key]
+	if shard.expired(key, x.now()) {
+		shard.mu.Unlock()
+		return ErrKeyNotFound
+	}
+	var relocatable, canMove = entity.(Relocatable)
+	if !canMove {
+		shard.mu.Unlock()
+		return ErrNotRelocatable
+	}
+
+	var oldCells []string = entity.Geocells()
+	var resolution int
+	for _, cell := range oldCells {
+		resolution = max(resolution, len(cell))
+	}
+	var finest string = GeoCell(lat, lon, resolution)
+	var newCells []string = make([]string, len(oldCells))
+	for i, cell := range oldCells {
+		newCells[i] = finest[:len(cell)]
+	}
+	var moved LocationCapable = relocatable.Relocate(lat, lon, newCells)
+
+	if len(finest) > 0 && x.shard(finest) != shard {
+		shard.mu.Unlock()
+		x.insert(stripe, moved, expires)
+		return nil
+	}
+	defer shard.mu.Unlock()
+	var partition string = PartitionOf(entity)
+	for i := range oldCells {
+		if oldCells[i] != newCells[i] {
+			shard.unlink(key, partition, oldCells[i])
+			shard.link(key, partition, newCells[i])
+		}
+	}
+	shard.entities[key] = moved
+	return nil
+}
+
+// Remove deletes the entity with the given key and reports whether there
+// was one.
+func (x *GeoIndex) Remove(key string) bool {
+	var stripe *keyStripe = x.stripe(key)
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	var shard, ok = stripe.shardOf[key]
+	if !ok {
+		return false
+	}
+	shard.mu.Lock()
+	shard.remove(key)
+	shard.mu.Unlock()
+	delete(stripe.shardOf, key)
+	return true
+}
+
+// Get returns the entity stored under key, unless it has expired.
+func (x *GeoIndex) Get(key string) (LocationCapable, bool) {
+	var stripe *keyStripe = x.stripe(key)
+	stripe.mu.RLock()
+	var shard, ok = stripe.shardOf[key]
+	stripe.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	var entity LocationCapable
+	entity, ok = shard.entities[key]
+	if !ok || shard.expired(key, x.now()) {
+		return nil, false
+	}
+	return entity, true
+}
+
+// Len returns the number of entities in the index, including expired
+// entities not swept yet.
+func (x *GeoIndex) Len() int {
+	var n int
+	for i := range x.stripes {
+		var stripe *keyStripe = &x.stripes[i]
+		stripe.mu.RLock()
+		n += len(stripe.shardOf)
+		stripe.mu.RUnlock()
+	}
+	return n
+}
+
+// Search returns every entity having any of cells among its geocells, each
+// once. Its method value satisfies RepositorySearch. Each shard is read
+// under its own lock, so the results reflect every completed write but
+// writes made during the search may be seen for some cells only. Expired
+// entities are skipped.
+func (x *GeoIndex) Search(cells []string) []LocationCapable {
+	return x.search(cells, "", true)
+}
+
+// SearchPartition is Search restricted to the entities of partition, as
+// reported by PartitionOf. Its method value satisfies PartitionSearch. The
+// index keeps the cells of each partition apart, so entities of other
+// partitions are not visited.
+func (x *GeoIndex) SearchPartition(partition string, cells []string) []LocationCapable {
+	return x.search(cells, partition, false)
+}
+
+// search returns the entities of partition having any of cells, or those of
+// every partition if all is set.
+func (x *GeoIndex) search(cells []string, partition string, all bool) []LocationCapable {
+	var now time.Time = x.now()
+	var byShard map[*indexShard][]string = make(map[*indexShard][]string)
+	for _, cell := range cells {
+		var shard *indexShard = x.shard(cell)
+		byShard[shard] = append(byShard[shard], cell)
+	}
+
+	var results []LocationCapable
+	var seen map[string]struct{} = make(map[string]struct{})
+	for shard, cells := range byShard {
+		shard.mu.RLock()
+		for _, cell := range cells {
+			var keys map[string]struct{} = shard.cells[cell]
+			if !all && partition != "" {
+				keys = shard.partitions[partition][cell]
+			}
+			for key := range keys {
+				if _, ok := seen[key]; ok || shard.expired(key, now) {
+					continue
+				}
+				// Entities of the empty partition are not indexed apart.
+				if !all && partition == "" && PartitionOf(shard.entities[key]) != "" {
+					continue
+				}
+				seen[key] = struct{}{}
+				results = append(results, shard.entities[key])
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return results
+}
+
+// add stores entity in the shard, which must not hold its key.
+func (s *indexShard) add(entity LocationCapable) {
+	var key string = entity.Key()
+	var partition string = PartitionOf(entity)
+	s.entities[key] = entity
+	for _, cell := range entity.Geocells() {
+		s.link(key, partition, cell)
+	}
+}
+
+// remove deletes the entity with the given key from the shard.
+func (s *indexShard) remove(key string) {
+	var partition string = PartitionOf(s.entities[key])
+	for _, cell := range s.entities[key].Geocells() {
+		s.unlink(key, partition, cell)
+	}
+	delete(s.entities, key)
+	delete(s.expires, key)
+}
+
+// link records that the entity with the given key, of partition, has cell
+// among its geocells.
+func (s *indexShard) link(key, partition, cell string) {
+	linkCell(s.cells, key, cell)
+	if partition != "" {
+		var cells map[string]map[string]struct{} = s.partitions[partition]
+		if cells == nil {
+			cells = make(map[string]map[string]struct{})
+			s.partitions[partition] = cells
+		}
+		linkCell(cells, key, cell)
+	}
+}
+
+// unlink is the inverse of link.
+func (s *indexShard) unlink(key, partition, cell string) {
+	unlinkCell(s.cells, key, cell)
+	if partition != "" {
+		unlinkCell(s.partitions[partition], key, cell)
+		if len(s.partitions[partition]) == 0 {
+			delete(s.partitions, partition)
+		}
+	}
+}
+
+func linkCell(cells map[string]map[string]struct{}, key, cell string) {
+	var keys map[string]struct{} = cells[cell]
+	if keys == nil {
+		keys = make(map[string]struct{})
+		cells[cell] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func unlinkCell(cells map[string]map[string]struct{}, key, cell string) {
+	var keys map[string]struct{} = cells[cell]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(cells, cell)
+	}
+}
+
+// expired reports whether the entity with the given key has expired at now.
+func (s *indexShard) expired(key string, now time.Time) bool {
+	var expires, ok = s.expires[key]
+	return ok && !now.Before(expires)
+}
+
+// Sweep removes the expired entities from the index and returns how many it
+// removed.
+func (x *GeoIndex) Sweep() int {
+	var now time.Time = x.now()
+	var removed int
+	for i := range x.shards {
+		var shard *indexShard = &x.shards[i]
+		var expired []string
+		shard.mu.RLock()
+		for key := range shard.expires {
+			if shard.expired(key, now) {
+				expired = append(expired, key)
+			}
+		}
+		shard.mu.RUnlock()
+
+		// Stripe locks come first, so each key is removed under its own,
+		// after checking that no writer replaced it in the meantime.
+		for _, key := range expired {
+			var stripe *keyStripe = x.stripe(key)
+			stripe.mu.Lock()
+			if stripe.shardOf[key] == shard {
+				shard.mu.Lock()
+				if shard.expired(key, now) {
+					shard.remove(key)
+					delete(stripe.shardOf, key)
+					removed++
+				}
+				shard.mu.Unlock()
+			}
+			stripe.mu.Unlock()
+		}
+	}
+	return removed
+}
+
+// StartSweeper calls Sweep every interval in a new goroutine until the
+// returned function is called. The stop function waits for a running sweep
+// to finish and may be called more than once.
+func (x *GeoIndex) StartSweeper(interval time.Duration) (stop func()) {
+	var ticker *time.Ticker = time.NewTicker(interval)
+	var done chan struct{} = make(chan struct{})
+	var exited chan struct{} = make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-ticker.C:
+				x.Sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+		<-exited
+	}
+}
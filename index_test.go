@@ -0,0 +1,242 @@
+package geomodel
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGeoIndex(t *testing.T) {
+	var berlin = Place{52.52, 13.405, "berlin", GeoCells(52.52, 13.405, MAX_GEOCELL_RESOLUTION)}
+	var potsdam = Place{52.39, 13.065, "potsdam", GeoCells(52.39, 13.065, MAX_GEOCELL_RESOLUTION)}
+	var index = NewGeoIndex(berlin, potsdam)
+
+	if found := index.Search([]string{berlin.geocells[2], berlin.geocells[3]}); len(found) != 2 {
+		t.Errorf("Search over shared ancestors returned %d entities, want 2", len(found))
+	}
+	if found := index.Search(berlin.geocells[8:9]); len(found) != 1 || found[0].Key() != "berlin" {
+		t.Errorf("Search(%v) = %v, want berlin only", berlin.geocells[8:9], found)
+	}
+
+	var moved = Place{48.137, 11.575, "berlin", GeoCells(48.137, 11.575, MAX_GEOCELL_RESOLUTION)}
+	if !index.Update(moved) {
+		t.Fatal("Update of a stored key reported false")
+	}
+	if found := index.Search(berlin.geocells[8:9]); len(found) != 0 {
+		t.Errorf("updated entity still found at its old cell")
+	}
+	if found := index.Search(moved.geocells[8:9]); len(found) != 1 {
+		t.Errorf("updated entity not found at its new cell")
+	}
+	if index.Update(Place{0, 0, "missing", GeoCells(0, 0, 4)}) || index.Len() != 2 {
+		t.Errorf("Update added a missing key")
+	}
+
+	if !index.Remove("potsdam") || index.Remove("potsdam") {
+		t.Error("Remove did not report the stored key exactly once")
+	}
+	if _, ok := index.Get("potsdam"); ok || index.Len() != 1 {
+		t.Errorf("removed entity still stored")
+	}
+	var cells int
+	for i := range index.shards {
+		cells += len(index.shards[i].cells)
+	}
+	if cells != len(moved.geocells) {
+		t.Errorf("index holds %d cells, want %d", cells, len(moved.geocells))
+	}
+}
+
+func TestGeoIndexProximityFetch(t *testing.T) {
+	var index = NewGeoIndex()
+	for i, lon := range []float64{8.003, 8.001, 8.002, 8.5} {
+		index.Insert(Place{50, lon, string(rune('a' + i)), GeoCells(50, lon, MAX_GEOCELL_RESOLUTION)})
+	}
+
+	var result = ProximityFetch(50, 8, 3, 1000, index.Search, MAX_GEOCELL_RESOLUTION)
+	var keys string
+	for _, entity := range result {
+		keys += entity.Key()
+	}
+	if keys != "bca" {
+		t.Errorf("ProximityFetch returned %q, want %q", keys, "bca")
+	}
+}
+
+func TestGeoIndexConcurrent(t *testing.T) {
+	var index = NewGeoIndex()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				var lat, lon = float64(w*10 - 40), 0.005 + float64(i%20)*0.01
+				index.Insert(Place{lat, lon, fmt.Sprint(w, "-", i%20), GeoCells(lat, lon, 8)})
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			var cells = GeoCells(float64(w*10-40), 0.1, 3)
+			for i := 0; i < 200; i++ {
+				index.Search(cells)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if index.Len() != 8*20 {
+		t.Errorf("index holds %d entities, want %d", index.Len(), 8*20)
+	}
+	for w := 0; w < 8; w++ {
+		if found := index.Search(GeoCells(float64(w*10-40), 0.1, 3)[2:]); len(found) != 20 {
+			t.Errorf("worker %d: Search returned %d entities, want 20", w, len(found))
+		}
+	}
+}
+
+func TestGeoIndexWritersInOtherShards(t *testing.T) {
+	var index = NewGeoIndex()
+	var berlin = Place{52.52, 13.405, "berlin", GeoCells(52.52, 13.405, 10)}
+	var sydney = Place{-33.87, 151.21, "sydney", GeoCells(-33.87, 151.21, 10)}
+	for i := 0; index.stripe(sydney.key) == index.stripe(berlin.key); i++ {
+		sydney.key = fmt.Sprint("sydney-", i)
+	}
+
+	// A writer blocked on Berlin's shard holds no lock Sydney's writer needs.
+	var shard = index.shardFor(berlin)
+	shard.mu.Lock()
+	var blocked = make(chan struct{})
+	go func() {
+		defer close(blocked)
+		index.Insert(berlin)
+	}()
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		index.Insert(sydney)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("Insert waited for a writer of another shard")
+	}
+	shard.mu.Unlock()
+	<-blocked
+	<-done
+	if index.Len() != 2 {
+		t.Errorf("Len = %d, want 2", index.Len())
+	}
+}
+
+func TestGeoIndexTTL(t *testing.T) {
+	var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var index = NewGeoIndex()
+	index.now = func() time.Time { return now }
+
+	var cells = GeoCells(50, 8, 8)
+	index.InsertWithTTL(Place{50, 8, "driver", cells}, 2*time.Minute)
+	index.Insert(Place{50, 8.0001, "depot", GeoCells(50, 8.0001, 8)})
+
+	now = now.Add(time.Minute)
+	if found := index.Search(cells[4:5]); len(found) != 2 {
+		t.Fatalf("Search before expiry returned %d entities, want 2", len(found))
+	}
+	if !index.Update(Place{50, 8, "driver", cells}) {
+		t.Error("Update of a live entity reported false")
+	}
+
+	now = now.Add(time.Minute)
+	if found := index.Search(cells[4:5]); len(found) != 1 || found[0].Key() != "depot" {
+		t.Errorf("Search after expiry returned %v, want depot only", found)
+	}
+	if found := index.Nearest(50, 8, 5); len(found) != 1 {
+		t.Errorf("Nearest after expiry returned %d results, want 1", len(found))
+	}
+	if _, ok := index.Get("driver"); ok || index.Update(Place{50, 8, "driver", cells}) {
+		t.Error("expired entity still retrievable")
+	}
+
+	if removed := index.Sweep(); removed != 1 || index.Len() != 1 {
+		t.Errorf("Sweep removed %d entities leaving %d, want 1 and 1", removed, index.Len())
+	}
+
+	index.InsertWithTTL(Place{50, 8, "driver", cells}, time.Minute)
+	index.Insert(Place{50, 8, "driver", cells})
+	now = now.Add(time.Hour)
+	if _, ok := index.Get("driver"); !ok {
+		t.Error("Insert did not clear the expiry")
+	}
+}
+
+func TestGeoIndexStartSweeper(t *testing.T) {
+	var index = NewGeoIndex()
+	index.InsertWithTTL(Place{50, 8, "driver", GeoCells(50, 8, 8)}, time.Millisecond)
+	var stop = index.StartSweeper(time.Millisecond)
+	defer stop()
+
+	for deadline := time.Now().Add(5 * time.Second); index.Len() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("sweeper did not remove the expired entity")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+}
+
+// vehicle is a Place that GeoIndex.Move can relocate.
+type vehicle struct{ Place }
+
+func (v vehicle) Relocate(lat, lon float64, geocells []string) LocationCapable {
+	return vehicle{Place{lat, lon, v.key, geocells}}
+}
+
+func TestGeoIndexMove(t *testing.T) {
+	var index = NewGeoIndex()
+	index.Insert(vehicle{Place{50, 8, "car", GeoCells(50, 8, 10)}})
+	index.Insert(Place{50, 8, "depot", GeoCells(50, 8, 10)})
+
+	if err := index.Move("car", 50.001, 8.001); err != nil {
+		t.Fatal(err)
+	}
+	var moved, _ = index.Get("car")
+	if want := GeoCells(50.001, 8.001, 10); !reflect.DeepEqual(moved.Geocells(), want) || moved.Latitude() != 50.001 {
+		t.Fatalf("moved entity has cells %v, want %v", moved.Geocells(), want)
+	}
+	if found := index.Search(GeoCells(50, 8, 10)[9:]); len(found) != 1 || found[0].Key() != "depot" {
+		t.Errorf("moved entity still found at its old cell")
+	}
+	if found := index.Search(moved.Geocells()[9:]); len(found) != 1 || found[0].Key() != "car" {
+		t.Errorf("moved entity not found at its new cell")
+	}
+
+	// Across top-level cells, and so across shards.
+	if err := index.Move("car", -33.87, 151.21); err != nil {
+		t.Fatal(err)
+	}
+	if found := index.Search([]string{GeoCell(-33.87, 151.21, 10)}); len(found) != 1 {
+		t.Errorf("entity moved across shards not found")
+	}
+	if found := index.Search([]string{GeoCell(50.001, 8.001, 1)}); len(found) != 1 {
+		t.Errorf("Search of the old top-level cell returned %d entities, want 1", len(found))
+	}
+
+	if err := index.Move("depot", 0, 0); err != ErrNotRelocatable {
+		t.Errorf("Move of a plain entity returned %v, want ErrNotRelocatable", err)
+	}
+	if err := index.Move("missing", 0, 0); err != ErrKeyNotFound {
+		t.Errorf("Move of a missing key returned %v, want ErrKeyNotFound", err)
+	}
+	for _, p := range []Point{{math.NaN(), 8}, {50, 181}} {
+		if err := index.Move("car", p.Lat, p.Lon); !errors.Is(err, ErrInvalidPoint) {
+			t.Errorf("Move to %v returned %v, want ErrInvalidPoint", p, err)
+		}
+	}
+	if car, _ := index.Get("car"); car.Latitude() != -33.87 {
+		t.Errorf("a rejected Move relocated the entity to %v", car.Latitude())
+	}
+}
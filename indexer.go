@@ -0,0 +1,89 @@
+package geomodel
+
+import "github.com/alternaDev/geomodel/internal/curve"
+
+// GeocellSetter is implemented by entities that store their own geocells,
+// so that an Indexer can attach freshly computed cells to them.
+type GeocellSetter interface {
+	SetGeocells(cells []string)
+}
+
+// Indexer holds the write-path logic shared by storage adapters: computing
+// the geocells of an entity at a chosen resolution, attaching them to it,
+// and working out which cells change when the entity moves. Adapters call
+// BeforePut and BeforeUpdate from their own Put and Update paths, as
+// kvrepo.Repository.Put does.
+type Indexer struct {
+	// Resolution is the finest resolution computed. It defaults to
+	// MAX_GEOCELL_RESOLUTION.
+	Resolution int
+	// Curve encodes cells. It defaults to the geohash curve and must match
+	// the curve searches use.
+	Curve Curve
+}
+
+// ComputeEntityCells returns the geocells of entity's location from
+// resolution 1 to the indexer's resolution, coarsest first, as GeoCells
+// does. Like GeoCells, it clamps latitudes and returns no cells for NaN
+// coordinates or infinite longitudes.
+func (ix *Indexer) ComputeEntityCells(entity LocationCapable) []string {
+	var resolution int = ix.Resolution
+	if resolution <= 0 {
+		resolution = MAX_GEOCELL_RESOLUTION
+	}
+	var c Curve = ix.Curve
+	if c == nil {
+		c = curve.Geohash
+	}
+
+	lat, lon, ok := clampCoordinates(entity.Latitude(), entity.Longitude())
+	if !ok {
+		return nil
+	}
+	var finest string = c.Encode(lat, lon, resolution)
+	var cells []string = make([]string, len(finest))
+	for i := range cells {
+		cells[i] = finest[:i+1]
+	}
+	return cells
+}
+
+// BeforePut computes the geocells of an entity about to be stored, attaches
+// them if the entity implements GeocellSetter, and returns them.
+func (ix *Indexer) BeforePut(entity LocationCapable) []string {
+	var cells []string = ix.ComputeEntityCells(entity)
+	if setter, ok := entity.(GeocellSetter); ok {
+		setter.SetGeocells(cells)
+	}
+	return cells
+}
+
+// BeforeUpdate is BeforePut for an entity replacing old, typically after a
+// move. Besides the new geocells it returns the cells added and removed
+// relative to old's geocells, so that adapters storing a row per cell only
+// write the rows that change.
+func (ix *Indexer) BeforeUpdate(old, entity LocationCapable) (cells, added, removed []string) {
+	// Snapshot old's cells first: old and entity may be the same value,
+	// moved in place, whose cells BeforePut replaces.
+	var oldCells []string = append([]string(nil), old.Geocells()...)
+	cells = ix.BeforePut(entity)
+
+	var before map[string]struct{} = make(map[string]struct{}, len(oldCells))
+	for _, cell := range oldCells {
+		before[cell] = struct{}{}
+	}
+	for _, cell := range cells {
+		if _, ok := before[cell]; ok {
+			delete(before, cell)
+		} else {
+			added = append(added, cell)
+		}
+	}
+	for _, cell := range oldCells {
+		if _, ok := before[cell]; ok {
+			delete(before, cell)
+			removed = append(removed, cell)
+		}
+	}
+	return cells, added, removed
+}
@@ -0,0 +1,57 @@
+package geomodel
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// record is a Place storing the geocells an Indexer attaches.
+type record struct{ Place }
+
+func (r *record) SetGeocells(cells []string) { r.geocells = cells }
+
+func TestIndexer(t *testing.T) {
+	var ix = &Indexer{Resolution: 6}
+	var r = &record{Place{lat: 50, lon: 8, key: "a"}}
+	if cells := ix.BeforePut(r); !reflect.DeepEqual(cells, GeoCells(50, 8, 6)) || !reflect.DeepEqual(r.Geocells(), cells) {
+		t.Errorf("BeforePut attached %v, returned %v, want %v", r.Geocells(), cells, GeoCells(50, 8, 6))
+	}
+
+	var moved = &record{Place{lat: 50.01, lon: 8, key: "a"}}
+	var cells, added, removed = ix.BeforeUpdate(r, moved)
+	var want = GeoCells(50.01, 8, 6)
+	var shared int
+	for shared < 6 && want[shared] == r.geocells[shared] {
+		shared++
+	}
+	if !reflect.DeepEqual(cells, want) || !reflect.DeepEqual(added, want[shared:]) || !reflect.DeepEqual(removed, r.geocells[shared:]) {
+		t.Errorf("BeforeUpdate = %v, +%v, -%v", cells, added, removed)
+	}
+	if shared == 0 || shared == 6 {
+		t.Fatalf("test move shares %d cells; pick one changing only fine cells", shared)
+	}
+
+	// An entity moved in place is diffed against its cells before the move.
+	var before = append([]string(nil), moved.geocells...)
+	moved.lat = 50
+	if _, added, removed := ix.BeforeUpdate(moved, moved); !reflect.DeepEqual(added, r.geocells[shared:]) || !reflect.DeepEqual(removed, before[shared:]) {
+		t.Errorf("BeforeUpdate in place = +%v, -%v", added, removed)
+	}
+
+	// Plain entities get cells computed without being modified.
+	var p = Place{lat: 50, lon: 8, key: "b"}
+	if cells := (&Indexer{}).BeforePut(p); len(cells) != MAX_GEOCELL_RESOLUTION || p.geocells != nil {
+		t.Errorf("BeforePut on a plain entity returned %d cells", len(cells))
+	}
+
+	// Invalid coordinates have no cells, as for GeoCells.
+	for _, p := range []Place{{lat: math.NaN(), lon: 8}, {lat: 50, lon: math.Inf(1)}} {
+		if cells := ix.ComputeEntityCells(p); cells != nil {
+			t.Errorf("ComputeEntityCells(%v, %v) = %v, want none", p.lat, p.lon, cells)
+		}
+	}
+	if cells := ix.ComputeEntityCells(Place{lat: 95, lon: 8}); !reflect.DeepEqual(cells, GeoCells(95, 8, 6)) {
+		t.Errorf("ComputeEntityCells did not clamp the latitude: %v", cells)
+	}
+}
@@ -0,0 +1,140 @@
+package geomodel
+
+import (
+	"container/heap"
+	"strings"
+	"time"
+)
+
+// indexLeafSize is the number of entities below which a cell of a GeoIndex
+// is scanned directly rather than descended into.
+const indexLeafSize = 16
+
+// Nearest returns the k entities of the index closest to (lat, lon), nearest
+// first. Unlike ProximityFetch over Search, it walks the cells held by the
+// index from the top level down, visiting cells and entities in order of
+// their distance to the point, and stops as soon as k entities are found.
+func (x *GeoIndex) Nearest(lat, lon float64, k int) []SearchResult {
+	var results []SearchResult
+	if k <= 0 {
+		return results
+	}
+	x.scan(lat, lon, func(r SearchResult) bool {
+		results = append(results, r)
+		return len(results) < k
+	})
+	return results
+}
+
+// WithinRadius returns the entities of the index within meters of
+// (lat, lon), nearest first.
+func (x *GeoIndex) WithinRadius(lat, lon, meters float64) []SearchResult {
+	var results []SearchResult
+	x.scan(lat, lon, func(r SearchResult) bool {
+		if r.Distance > meters {
+			return false
+		}
+		results = append(results, r)
+		return true
+	})
+	return results
+}
+
+// indexCandidate is a cell or an entity queued by GeoIndex.scan. For a cell,
+// distance is a lower bound on the distance of its entities.
+type indexCandidate struct {
+	cell     string
+	entity   LocationCapable
+	distance float64
+}
+
+// indexQueue is a min-heap of candidates by distance.
+type indexQueue []indexCandidate
+
+func (q indexQueue) Len() int            { return len(q) }
+func (q indexQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q indexQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *indexQueue) Push(x interface{}) { *q = append(*q, x.(indexCandidate)) }
+func (q *indexQueue) Pop() interface{} {
+	var last indexCandidate = (*q)[len(*q)-1]
+	*q = (*q)[:len(*q)-1]
+	return last
+}
+
+// scan passes the entities of the index to yield in order of their distance
+// to (lat, lon) until yield returns false. Each shard is read under its own
+// lock, so writes made during the scan may or may not be seen. Expired
+// entities are skipped.
+func (x *GeoIndex) scan(lat, lon float64, yield func(SearchResult) bool) {
+	var queue indexQueue
+	var seen map[string]struct{} = make(map[string]struct{})
+	var now time.Time = x.now()
+
+	var pushCell = func(shard *indexShard, cell string) {
+		if len(shard.cells[cell]) > 0 {
+			heap.Push(&queue, indexCandidate{cell: cell, distance: distanceToBox(lat, lon, ComputeBox(cell))})
+		}
+	}
+	var pushEntity = func(shard *indexShard, key string) {
+		if _, ok := seen[key]; ok || shard.expired(key, now) {
+			return
+		}
+		seen[key] = struct{}{}
+		var entity LocationCapable = shard.entities[key]
+		heap.Push(&queue, indexCandidate{entity: entity, distance: Distance(lat, lon, entity.Latitude(), entity.Longitude())})
+	}
+
+	for i := range x.shards {
+		var shard *indexShard = &x.shards[i]
+		shard.mu.RLock()
+		pushCell(shard, GEOCELL_ALPHABET[i:i+1])
+		shard.mu.RUnlock()
+	}
+
+	for queue.Len() > 0 {
+		var next indexCandidate = heap.Pop(&queue).(indexCandidate)
+		if next.entity != nil {
+			if !yield(SearchResult{Entity: next.entity, Distance: next.distance}) {
+				return
+			}
+			continue
+		}
+
+		var shard *indexShard = x.shard(next.cell)
+		shard.mu.RLock()
+		var keys map[string]struct{} = shard.cells[next.cell]
+		if len(keys) <= indexLeafSize || len(next.cell) >= MAX_GEOCELL_RESOLUTION {
+			for key := range keys {
+				pushEntity(shard, key)
+			}
+		} else {
+			var covered int
+			for i := 0; i < len(GEOCELL_ALPHABET); i++ {
+				var child string = next.cell + GEOCELL_ALPHABET[i:i+1]
+				covered += len(shard.cells[child])
+				pushCell(shard, child)
+			}
+			// Entities with no geocell finer than this cell are not reached
+			// through its children.
+			if covered < len(keys) {
+				for key := range keys {
+					if !hasChildCell(shard.entities[key], next.cell) {
+						pushEntity(shard, key)
+					}
+				}
+			}
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// hasChildCell reports whether entity has a geocell one resolution finer
+// than cell inside it.
+func hasChildCell(entity LocationCapable, cell string) bool {
+	for _, c := range entity.Geocells() {
+		if len(c) == len(cell)+1 && strings.HasPrefix(c, cell) {
+			return true
+		}
+	}
+	return false
+}
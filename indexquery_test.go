@@ -0,0 +1,69 @@
+package geomodel
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestGeoIndexNearestAndWithinRadius(t *testing.T) {
+	var rng = rand.New(rand.NewSource(7))
+	var places []LocationCapable
+	for i := 0; i < 2000; i++ {
+		var lat, lon = rng.Float64()*170 - 85, rng.Float64()*360 - 180
+		if i%2 == 0 {
+			// A dense cluster around the antimeridian.
+			lat, lon = 10+rng.Float64(), 179.5+rng.Float64()
+			if lon > 180 {
+				lon -= 360
+			}
+		}
+		places = append(places, Place{lat, lon, fmt.Sprint(i), GeoCells(lat, lon, 1+i%MAX_GEOCELL_RESOLUTION)})
+	}
+	var index = NewGeoIndex(places...)
+
+	for _, origin := range []Point{{10.5, 180}, {10.5, -179.9}, {0, 0}, {89, 45}, {-60, -120}} {
+		var want []float64
+		for _, p := range places {
+			want = append(want, Distance(origin.Lat, origin.Lon, p.Latitude(), p.Longitude()))
+		}
+		sort.Float64s(want)
+
+		var nearest = index.Nearest(origin.Lat, origin.Lon, 25)
+		if len(nearest) != 25 {
+			t.Fatalf("Nearest(%v) returned %d results, want 25", origin, len(nearest))
+		}
+		for i, r := range nearest {
+			if r.Distance != want[i] {
+				t.Errorf("Nearest(%v)[%d] at %v meters, want %v", origin, i, r.Distance, want[i])
+			}
+		}
+
+		var radius = want[100]
+		var within = index.WithinRadius(origin.Lat, origin.Lon, radius)
+		if len(within) != 101 {
+			t.Errorf("WithinRadius(%v, %v) returned %d results, want 101", origin, radius, len(within))
+		}
+	}
+
+	if got := index.Nearest(0, 0, 0); len(got) != 0 {
+		t.Errorf("Nearest with k=0 returned %d results", len(got))
+	}
+	if got := NewGeoIndex().Nearest(0, 0, 3); len(got) != 0 {
+		t.Errorf("Nearest on an empty index returned %d results", len(got))
+	}
+}
+
+func BenchmarkGeoIndexNearest(b *testing.B) {
+	var rng = rand.New(rand.NewSource(1))
+	var index = NewGeoIndex()
+	for i := 0; i < 100000; i++ {
+		var lat, lon = 50 + rng.Float64(), 8 + rng.Float64()
+		index.Insert(Place{lat, lon, fmt.Sprint(i), GeoCells(lat, lon, 10)})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.Nearest(50.5, 8.5, 10)
+	}
+}
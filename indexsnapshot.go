@@ -0,0 +1,278 @@
+package geomodel
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrCorruptSnapshot is returned by GeoIndex.Load for input not written by
+// GeoIndex.Save.
+var ErrCorruptSnapshot = errors.New("geomodel: corrupt index snapshot")
+
+// snapshotMagic starts every snapshot, followed by the format version.
+const (
+	snapshotMagic   = "GMIX"
+	snapshotVersion = 1
+)
+
+// IndexRecord is an entity restored by GeoIndex.Load: its key, location and
+// geocells as they were when saved.
+type IndexRecord struct {
+	ID    string
+	Lat   float64
+	Lon   float64
+	Cells []string
+}
+
+func (r *IndexRecord) Latitude() float64  { return r.Lat }
+func (r *IndexRecord) Longitude() float64 { return r.Lon }
+func (r *IndexRecord) Key() string        { return r.ID }
+func (r *IndexRecord) Geocells() []string { return r.Cells }
+
+// Relocate implements Relocatable, so that restored entities can be moved
+// with GeoIndex.Move.
+func (r *IndexRecord) Relocate(lat, lon float64, geocells []string) LocationCapable {
+	return &IndexRecord{ID: r.ID, Lat: lat, Lon: lon, Cells: geocells}
+}
+
+// PartitionedIndexRecord is an IndexRecord of a partition, as restored by
+// GeoIndex.Load for Partitioned entities.
+type PartitionedIndexRecord struct {
+	IndexRecord
+	Tenant string
+}
+
+func (r *PartitionedIndexRecord) Partition() string { return r.Tenant }
+
+// Relocate implements Relocatable, keeping the partition.
+func (r *PartitionedIndexRecord) Relocate(lat, lon float64, geocells []string) LocationCapable {
+	return &PartitionedIndexRecord{IndexRecord{ID: r.ID, Lat: lat, Lon: lon, Cells: geocells}, r.Tenant}
+}
+
+// Save writes the key, partition, location and geocells of every entity in
+// the index to w in a compact binary format read by Load, along with the
+// expiry of entities inserted with a TTL. Expired entities are left out.
+// Writers wait for Save to finish; searches do not.
+//
+// A snapshot holds a header followed by the entity count and one record per
+// entity: the key, the partition, the latitude and longitude as
+// little-endian float64 bits, the expiry in Unix nanoseconds or zero, and
+// the geocells, each stored as the length of the prefix it shares with the
+// previous geocell followed by the remaining characters. Geocells listed
+// coarsest first, as GeoCells returns them, thus cost one byte each beyond
+// the finest.
+func (x *GeoIndex) Save(w io.Writer) error {
+	for i := range x.stripes {
+		x.stripes[i].mu.RLock()
+		defer x.stripes[i].mu.RUnlock()
+	}
+
+	// Writers are excluded, so the shards keep their contents while the count
+	// is taken and the records written.
+	var now time.Time = x.now()
+	var keys [len(GEOCELL_ALPHABET)][]string
+	var count int
+	for i := range x.shards {
+		var shard *indexShard = &x.shards[i]
+		shard.mu.RLock()
+		for key := range shard.entities {
+			if !shard.expired(key, now) {
+				keys[i] = append(keys[i], key)
+			}
+		}
+		shard.mu.RUnlock()
+		sort.Strings(keys[i])
+		count += len(keys[i])
+	}
+
+	var bw *bufio.Writer = bufio.NewWriter(w)
+	var buf []byte = append([]byte(snapshotMagic), snapshotVersion)
+	buf = binary.AppendUvarint(buf, uint64(count))
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+
+	for i := range x.shards {
+		var shard *indexShard = &x.shards[i]
+		shard.mu.RLock()
+		for _, key := range keys[i] {
+			buf = appendSnapshotRecord(buf[:0], shard.entities[key], shard.expires[key])
+			if _, err := bw.Write(buf); err != nil {
+				shard.mu.RUnlock()
+				return err
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return bw.Flush()
+}
+
+func appendSnapshotRecord(buf []byte, entity LocationCapable, expires time.Time) []byte {
+	buf = appendSnapshotString(buf, entity.Key())
+	buf = appendSnapshotString(buf, PartitionOf(entity))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(entity.Latitude()))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(entity.Longitude()))
+	if expires.IsZero() {
+		buf = binary.AppendVarint(buf, 0)
+	} else {
+		buf = binary.AppendVarint(buf, expires.UnixNano())
+	}
+
+	var cells []string = entity.Geocells()
+	buf = binary.AppendUvarint(buf, uint64(len(cells)))
+	var previous string
+	for _, cell := range cells {
+		var shared int
+		for shared < len(cell) && shared < len(previous) && cell[shared] == previous[shared] {
+			shared++
+		}
+		buf = binary.AppendUvarint(buf, uint64(shared))
+		buf = appendSnapshotString(buf, cell[shared:])
+		previous = cell
+	}
+	return buf
+}
+
+func appendSnapshotString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// Load reads a snapshot written by Save and inserts its entities into the
+// index as *IndexRecord values, or *PartitionedIndexRecord values for
+// entities of a partition, replacing entities with the same keys.
+// Callers keeping richer entities elsewhere can resolve them by key.
+// Entities keep their expiry, and those expired since the snapshot was saved
+// are skipped. The index is left unchanged if the snapshot cannot be read
+// completely.
+func (x *GeoIndex) Load(r io.Reader) error {
+	var br *bufio.Reader = bufio.NewReader(r)
+
+	var header []byte = make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return snapshotError(err)
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return ErrCorruptSnapshot
+	}
+	var version byte = header[len(snapshotMagic)]
+	if version != snapshotVersion {
+		return fmt.Errorf("geomodel: unsupported index snapshot version %d", version)
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return snapshotError(err)
+	}
+	var records []LocationCapable = make([]LocationCapable, 0, min(count, 1<<20))
+	var expires []time.Time = make([]time.Time, 0, cap(records))
+	for ; count > 0; count-- {
+		var record LocationCapable
+		var expiry time.Time
+		if record, expiry, err = readSnapshotRecord(br); err != nil {
+			return snapshotError(err)
+		}
+		records = append(records, record)
+		expires = append(expires, expiry)
+	}
+
+	x.lockStripes()
+	defer x.unlockStripes()
+	var now time.Time = x.now()
+	for i, record := range records {
+		if expires[i].IsZero() || now.Before(expires[i]) {
+			x.insert(x.stripe(record.Key()), record, expires[i])
+		}
+	}
+	return nil
+}
+
+func readSnapshotRecord(r *bufio.Reader) (LocationCapable, time.Time, error) {
+	var record *IndexRecord = &IndexRecord{}
+	var err error
+	if record.ID, err = readSnapshotString(r); err != nil {
+		return nil, time.Time{}, err
+	}
+	var partition string
+	if partition, err = readSnapshotString(r); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var coords [16]byte
+	if _, err = io.ReadFull(r, coords[:]); err != nil {
+		return nil, time.Time{}, err
+	}
+	record.Lat = math.Float64frombits(binary.LittleEndian.Uint64(coords[:8]))
+	record.Lon = math.Float64frombits(binary.LittleEndian.Uint64(coords[8:]))
+
+	nanos, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var expires time.Time
+	if nanos != 0 {
+		expires = time.Unix(0, nanos)
+	}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if count > MAX_GEOCELL_RESOLUTION*64 {
+		return nil, time.Time{}, ErrCorruptSnapshot
+	}
+	record.Cells = make([]string, 0, count)
+	var previous string
+	for ; count > 0; count-- {
+		shared, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if shared > uint64(len(previous)) {
+			return nil, time.Time{}, ErrCorruptSnapshot
+		}
+		suffix, err := readSnapshotString(r)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		previous = previous[:shared] + suffix
+		record.Cells = append(record.Cells, previous)
+	}
+	if partition != "" {
+		return &PartitionedIndexRecord{*record, partition}, expires, nil
+	}
+	return record, expires, nil
+}
+
+// maxSnapshotString bounds the length of keys and cells read from a
+// snapshot, so that corrupt input cannot force a huge allocation.
+const maxSnapshotString = 1 << 20
+
+func readSnapshotString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if n > maxSnapshotString {
+		return "", ErrCorruptSnapshot
+	}
+	var buf []byte = make([]byte, n)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// snapshotError maps a truncated snapshot to ErrCorruptSnapshot.
+func snapshotError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorruptSnapshot
+	}
+	return err
+}
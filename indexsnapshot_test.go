@@ -0,0 +1,110 @@
+package geomodel
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGeoIndexSaveLoad(t *testing.T) {
+	var index = NewGeoIndex()
+	for i := 0; i < 100; i++ {
+		var lat, lon = float64(i) - 50, float64(i)*3.3 - 170
+		index.Insert(Place{lat, lon, fmt.Sprint("place-", i), GeoCells(lat, lon, 1+i%MAX_GEOCELL_RESOLUTION)})
+	}
+	index.Insert(Place{1, 2, "odd", []string{"s0zzzz", "s0"}})
+	var now = time.Now()
+	index.now = func() time.Time { return now }
+	index.InsertWithTTL(Place{3, 4, "expiring", GeoCells(3, 4, 6)}, time.Hour)
+	index.InsertWithTTL(Place{3, 4, "expired", GeoCells(3, 4, 6)}, -time.Second)
+
+	var buf bytes.Buffer
+	if err := index.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot = append([]byte(nil), buf.Bytes()...)
+
+	var loaded = NewGeoIndex()
+	loaded.now = index.now
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != index.Len()-1 {
+		t.Fatalf("loaded %d entities, want %d", loaded.Len(), index.Len()-1)
+	}
+	if !loaded.stripe("expiring").shardOf["expiring"].expires["expiring"].Equal(now.Add(time.Hour)) {
+		t.Errorf("expiry not restored")
+	}
+	now = now.Add(2 * time.Hour)
+	if _, ok := loaded.Get("expiring"); ok {
+		t.Errorf("restored entity did not expire")
+	}
+	now = now.Add(-2 * time.Hour)
+	for i := 0; i <= 100; i++ {
+		var key = fmt.Sprint("place-", i)
+		if i == 100 {
+			key = "odd"
+		}
+		var want, _ = index.Get(key)
+		var got, ok = loaded.Get(key)
+		if !ok || got.Latitude() != want.Latitude() || got.Longitude() != want.Longitude() || !reflect.DeepEqual(got.Geocells(), want.Geocells()) {
+			t.Errorf("loaded %q as %+v, want %+v", key, got, want)
+		}
+	}
+	if found := loaded.Search([]string{"s0zzzz"}); len(found) != 1 || found[0].Key() != "odd" {
+		t.Errorf("Search of a loaded cell returned %v", found)
+	}
+
+	var again bytes.Buffer
+	if err := loaded.Save(&again); err != nil || !bytes.Equal(again.Bytes(), snapshot) {
+		t.Errorf("saving a loaded index did not reproduce the snapshot (err %v)", err)
+	}
+
+	for _, corrupt := range [][]byte{nil, []byte("GMIY\x01\x00"), snapshot[:len(snapshot)-3]} {
+		var target = NewGeoIndex()
+		if err := target.Load(bytes.NewReader(corrupt)); !errors.Is(err, ErrCorruptSnapshot) || target.Len() != 0 {
+			t.Errorf("Load(%q...) = %v with %d entities, want ErrCorruptSnapshot and none", corrupt[:min(len(corrupt), 6)], err, target.Len())
+		}
+	}
+}
+
+func TestGeoIndexSaveLoadPartitions(t *testing.T) {
+	var index = NewGeoIndex(
+		tenantPlace{Place{50, 8, "a1", GeoCells(50, 8, 10)}, "a"},
+		tenantPlace{Place{50, 8.0001, "b1", GeoCells(50, 8.0001, 10)}, "b"},
+		Place{50, 8.0002, "plain", GeoCells(50, 8.0002, 10)},
+	)
+	var buf bytes.Buffer
+	if err := index.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var loaded = NewGeoIndex()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var cells = []string{GeoCell(50, 8, 4)}
+	for _, test := range []struct {
+		partition string
+		want      []string
+	}{
+		{"a", []string{"a1"}},
+		{"b", []string{"b1"}},
+		{"", []string{"plain"}},
+	} {
+		if got := keysOf(loaded.SearchPartition(test.partition, cells)); !equalKeys(got, test.want) {
+			t.Errorf("SearchPartition(%q) after Load = %v, want %v", test.partition, got, test.want)
+		}
+	}
+
+	// Moving a restored entity keeps its partition.
+	if err := loaded.Move("a1", -33.87, 151.21); err != nil {
+		t.Fatal(err)
+	}
+	if got := keysOf(loaded.SearchPartition("a", []string{GeoCell(-33.87, 151.21, 4)})); !equalKeys(got, []string{"a1"}) {
+		t.Errorf("SearchPartition after moving a restored entity = %v, want a1", got)
+	}
+}
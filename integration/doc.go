@@ -0,0 +1,10 @@
+// Package integration runs the adaptertest conformance suite against real
+// servers started in Docker with dockertest: kvrepo over Redis, mongorepo
+// over MongoDB and sqlrepo over PostgreSQL. It is a module of its own, so
+// that geomodel does not depend on database drivers or container tooling,
+// and its tests are built with the integration tag:
+//
+//	cd integration && go test -tags integration ./...
+//
+// The tests skip when no Docker daemon is reachable.
+package integration
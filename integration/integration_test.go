@@ -0,0 +1,256 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/adaptertest"
+	"github.com/alternaDev/geomodel/kvrepo"
+	"github.com/alternaDev/geomodel/mongorepo"
+	"github.com/alternaDev/geomodel/sqlrepo"
+)
+
+// pool runs the containers, or is nil if no Docker daemon is reachable.
+var pool *dockertest.Pool
+
+func TestMain(m *testing.M) {
+	var err error
+	if pool, err = dockertest.NewPool(""); err == nil {
+		err = pool.Client.Ping()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: no Docker daemon, skipping:", err)
+		pool = nil
+	}
+	os.Exit(m.Run())
+}
+
+// start runs a container of image, removed when the test ends, and returns
+// the host address of port once ready succeeds against it.
+func start(t *testing.T, repository, tag, port string, env []string, ready func(addr string) error) string {
+	if pool == nil {
+		t.Skip("no Docker daemon")
+	}
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{Repository: repository, Tag: tag, Env: env}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Purge(resource) })
+
+	var addr string = resource.GetHostPort(port)
+	if err := pool.Retry(func() error { return ready(addr) }); err != nil {
+		t.Fatalf("%s:%s not ready: %v", repository, tag, err)
+	}
+	return addr
+}
+
+// fresh numbers the tables, collections and keys of each index, so that
+// every subtest starts empty.
+var fresh atomic.Int64
+
+// redisStore is a kvrepo.Store over Redis, keeping the keys in a sorted set
+// of equal scores, which Redis orders lexicographically for prefix scans,
+// and the values in a hash.
+type redisStore struct {
+	client       *redis.Client
+	keys, values string
+}
+
+func (s *redisStore) Get(key []byte) ([]byte, error) {
+	value, err := s.client.HGet(context.Background(), s.values, string(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (s *redisStore) Put(key, value []byte) error {
+	var ctx context.Context = context.Background()
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, s.values, string(key), value)
+		p.ZAdd(ctx, s.keys, redis.Z{Member: string(key)})
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) Delete(key []byte) error {
+	var ctx context.Context = context.Background()
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HDel(ctx, s.values, string(key))
+		p.ZRem(ctx, s.keys, string(key))
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) Scan(prefix []byte, fn func(key, value []byte) error) error {
+	var ctx context.Context = context.Background()
+	var bound redis.ZRangeBy = redis.ZRangeBy{Min: "[" + string(prefix), Max: "+"}
+	if end := prefixEnd(prefix); end != nil {
+		bound.Max = "(" + string(end)
+	}
+	keys, err := s.client.ZRangeByLex(ctx, s.keys, &bound).Result()
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	values, err := s.client.HMGet(ctx, s.values, keys...).Result()
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		// Deleted since the keys were read.
+		if values[i] == nil {
+			continue
+		}
+		if err := fn([]byte(key), []byte(values[i].(string))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixEnd returns the first key after every key starting with prefix, or
+// nil if there is none.
+func prefixEnd(prefix []byte) []byte {
+	var end []byte = append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// kvIndex is a kvrepo.Repository as an adaptertest.Index.
+type kvIndex struct{ repo *kvrepo.Repository }
+
+func (x kvIndex) Put(entity geomodel.LocationCapable) error { return x.repo.Put(entity) }
+func (x kvIndex) Delete(key string) error                   { return x.repo.Delete(key) }
+func (x kvIndex) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	return x.repo.Search(cells)
+}
+
+func TestRedis(t *testing.T) {
+	var client *redis.Client
+	start(t, "redis", "7", "6379/tcp", nil, func(addr string) error {
+		client = redis.NewClient(&redis.Options{Addr: addr})
+		return client.Ping(context.Background()).Err()
+	})
+	t.Cleanup(func() { client.Close() })
+
+	adaptertest.Run(t, func() adaptertest.Index {
+		var n int64 = fresh.Add(1)
+		var store = &redisStore{client, fmt.Sprint("geomodel:", n, ":keys"), fmt.Sprint("geomodel:", n, ":values")}
+		return kvIndex{&kvrepo.Repository{Store: store}}
+	})
+}
+
+// collectionIndex stores entities as the documents of
+// mongorepo.Repository.WithGeocells.
+type collectionIndex struct {
+	collection *mongo.Collection
+	repo       *mongorepo.Repository
+}
+
+func (x *collectionIndex) Put(entity geomodel.LocationCapable) error {
+	var doc = x.repo.WithGeocells(map[string]any{"_id": entity.Key()}, entity.Latitude(), entity.Longitude())
+	_, err := x.collection.ReplaceOne(context.Background(), bson.M{"_id": entity.Key()}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (x *collectionIndex) Delete(key string) error {
+	_, err := x.collection.DeleteOne(context.Background(), bson.M{"_id": key})
+	return err
+}
+
+func (x *collectionIndex) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	return x.repo.Search(context.Background(), cells)
+}
+
+func TestMongo(t *testing.T) {
+	var client *mongo.Client
+	start(t, "mongo", "7", "27017/tcp", nil, func(addr string) error {
+		var err error
+		if client, err = mongo.Connect(options.Client().ApplyURI("mongodb://" + addr)); err != nil {
+			return err
+		}
+		return client.Ping(context.Background(), nil)
+	})
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	adaptertest.Run(t, func() adaptertest.Index {
+		var collection = client.Database("geomodel").Collection(fmt.Sprint("places", fresh.Add(1)))
+		var repo = &mongorepo.Repository{Find: func(ctx context.Context, filter map[string]any) (mongorepo.Cursor, error) {
+			return collection.Find(ctx, filter)
+		}}
+		if _, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{Keys: repo.IndexKeys()}); err != nil {
+			t.Fatal(err)
+		}
+		return &collectionIndex{collection, repo}
+	})
+}
+
+// tableIndex stores entities as rows holding their finest geocell, searched
+// with sqlrepo.MatchPrefix.
+type tableIndex struct {
+	db   *sql.DB
+	repo *sqlrepo.Repository
+}
+
+func (x *tableIndex) Put(entity geomodel.LocationCapable) error {
+	var cell string = geomodel.GeoCell(entity.Latitude(), entity.Longitude(), geomodel.MAX_GEOCELL_RESOLUTION)
+	_, err := x.db.Exec("INSERT INTO "+x.repo.Table.Name+" (id, lat, lon, geocell) VALUES ($1, $2, $3, $4) "+
+		"ON CONFLICT (id) DO UPDATE SET lat = excluded.lat, lon = excluded.lon, geocell = excluded.geocell",
+		entity.Key(), entity.Latitude(), entity.Longitude(), cell)
+	return err
+}
+
+func (x *tableIndex) Delete(key string) error {
+	_, err := x.db.Exec("DELETE FROM "+x.repo.Table.Name+" WHERE id = $1", key)
+	return err
+}
+
+func (x *tableIndex) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	return x.repo.Search(context.Background(), cells)
+}
+
+func TestPostgres(t *testing.T) {
+	var db *sql.DB
+	start(t, "postgres", "16", "5432/tcp", []string{"POSTGRES_PASSWORD=geomodel"}, func(addr string) error {
+		var err error
+		if db, err = sql.Open("pgx", "postgres://postgres:geomodel@"+addr+"/postgres?sslmode=disable"); err != nil {
+			return err
+		}
+		return db.Ping()
+	})
+	t.Cleanup(func() { db.Close() })
+
+	adaptertest.Run(t, func() adaptertest.Index {
+		var table = sqlrepo.Table{Name: fmt.Sprint("places", fresh.Add(1)), Key: "id", Lat: "lat", Lon: "lon", Cell: "geocell"}
+		if _, err := db.Exec("CREATE TABLE " + table.Name + " (id text PRIMARY KEY, lat double precision NOT NULL, lon double precision NOT NULL, geocell text NOT NULL)"); err != nil {
+			t.Fatal(err)
+		}
+		return &tableIndex{db, &sqlrepo.Repository{DB: db, Table: table, Match: sqlrepo.MatchPrefix, Placeholder: sqlrepo.Dollar}}
+	})
+}
@@ -0,0 +1,60 @@
+// Package curve defines how coordinates map to geocells. Encoding, cell
+// bounds and adjacency are expressed through the Curve interface so that the
+// search layer works unchanged with alternative space-filling curves or
+// projections, which are made available by name through Register.
+package curve
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Curve maps coordinates to hierarchical cells. A cell of resolution n is
+// an n character string whose prefixes are the cells containing it at
+// coarser resolutions, and the cells of one resolution form a regular grid
+// of Span-sized rectangles.
+type Curve interface {
+	// Name identifies the curve in the registry.
+	Name() string
+	// Encode returns the cell of the given resolution containing (lat, lon).
+	// Cells are half-open, spanning [south, north) x [west, east), so that a
+	// point on an edge belongs to the cell north or east of it at every
+	// resolution. Latitude 90 belongs to the northernmost cells. Longitudes
+	// are wrapped into [-180, 180), so that 180 is taken as -180 and
+	// longitudes in [0, 360) as given by many data sources map to their
+	// cells.
+	Encode(lat, lon float64, resolution int) string
+	// Bounds returns the south, west, north and east edges of cell.
+	Bounds(cell string) (south, west, north, east float64)
+	// Neighbor returns the cell of the same resolution adjacent to cell in
+	// direction (dx, dy), each -1, 0 or 1, or "" if there is none.
+	Neighbor(cell string, dx, dy int) string
+	// Span returns the latitude and longitude extent, in degrees, of cells
+	// at resolution.
+	Span(resolution int) (lat, lon float64)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Curve{}
+)
+
+// Register makes c available to Lookup under c.Name(). Registering a second
+// curve under the same name is an error.
+func Register(c Curve) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[c.Name()]; ok {
+		return fmt.Errorf("curve: %q already registered", c.Name())
+	}
+	registry[c.Name()] = c
+	return nil
+}
+
+// Lookup returns the curve registered under name.
+func Lookup(name string) (Curve, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[name]
+	return c, ok
+}
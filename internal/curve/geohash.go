@@ -0,0 +1,178 @@
+package curve
+
+import (
+	"math"
+	"strings"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash is the default curve: cells are geohashes, each character adding
+// five bits that alternately halve the longitude and latitude ranges.
+var Geohash Curve = geohash{}
+
+func init() {
+	Register(Geohash)
+}
+
+type geohash struct{}
+
+func (geohash) Name() string { return "geohash" }
+
+func (geohash) Encode(lat, lon float64, resolution int) string {
+	return string(AppendGeohash(make([]byte, 0, max(resolution, 0)), lat, lon, resolution))
+}
+
+// AppendGeohash appends the geohash of (lat, lon) at resolution to dst and
+// returns the extended buffer. It does not allocate if dst has room for
+// resolution more bytes. Ties at edges are broken as Curve.Encode documents:
+// each halving puts a point on the midpoint into the upper half.
+func AppendGeohash(dst []byte, lat, lon float64, resolution int) []byte {
+	if lon < -180 || lon >= 180 {
+		lon = WrapLon(lon)
+	}
+	north := 90.0
+	south := -90.0
+	east := 180.0
+	west := -180.0
+	isEven := true
+
+	for i := 0; i < resolution; i++ {
+		ch := 0
+		for bit := 4; bit >= 0; bit-- {
+			if isEven {
+				mid := (west + east) / 2
+				if lon >= mid {
+					ch |= 1 << uint(bit)
+					west = mid
+				} else {
+					east = mid
+				}
+			} else {
+				mid := (south + north) / 2
+				if lat >= mid {
+					ch |= 1 << uint(bit)
+					south = mid
+				} else {
+					north = mid
+				}
+			}
+			isEven = !isEven
+		}
+		dst = append(dst, geohashAlphabet[ch])
+	}
+
+	return dst
+}
+
+func (geohash) Bounds(cell string) (float64, float64, float64, float64) {
+	latMin := -90.0
+	latMax := 90.0
+	lonMin := -180.0
+	lonMax := 180.0
+	even := true
+	for i := 0; i < len(cell); i++ {
+		index := strings.IndexByte(geohashAlphabet, cell[i])
+
+		for n := 4; n >= 0; n-- {
+			bitN := index >> uint(n) & 1
+			if even {
+				lonMid := (lonMin + lonMax) / 2
+				if bitN == 1 {
+					lonMin = lonMid
+				} else {
+					lonMax = lonMid
+				}
+			} else {
+				latMid := (latMin + latMax) / 2
+				if bitN == 1 {
+					latMin = latMid
+				} else {
+					latMax = latMid
+				}
+			}
+			even = !even
+		}
+	}
+
+	return latMin, lonMin, latMax, lonMax
+}
+
+// WrapLon maps lon into [-180, 180).
+func WrapLon(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// Neighbor steps through the cell from its last character to its first,
+// moving each character one step in the grid of its level. A character
+// stepping off its grid wraps to the opposite side and carries the step to
+// the previous character. A latitude step carried past the first character
+// crosses a pole and has no neighbor; a longitude step wraps around the
+// globe.
+func (geohash) Neighbor(cell string, dx, dy int) string {
+	var buf []byte = []byte(cell)
+
+	for i := len(buf) - 1; i >= 0 && (dx != 0 || dy != 0); i-- {
+		var index int = strings.IndexByte(geohashAlphabet, buf[i])
+		if index < 0 {
+			return ""
+		}
+
+		var x, y, width, height int = geohashXY(index, i)
+		x, dx = step(x, dx, width)
+		y, dy = step(y, dy, height)
+		buf[i] = geohashAlphabet[geohashIndex(x, y, i)]
+	}
+
+	if dy != 0 {
+		return ""
+	}
+	return string(buf)
+}
+
+// step moves v by d within [0, size), returning the new value and the carry
+// to propagate to the enclosing level.
+func step(v, d, size int) (int, int) {
+	v += d
+	if v < 0 {
+		return v + size, d
+	}
+	if v >= size {
+		return v - size, d
+	}
+	return v, 0
+}
+
+// geohashXY returns the column and row of the character with alphabet
+// index index at position pos, and the width and height of that level's
+// grid. Characters at even positions interleave three longitude bits with
+// two latitude bits, starting with longitude; at odd positions the roles are
+// swapped.
+func geohashXY(index, pos int) (int, int, int, int) {
+	var a int = (index>>4&1)<<2 | (index>>2&1)<<1 | index&1
+	var b int = (index>>3&1)<<1 | index>>1&1
+	if pos%2 == 0 {
+		return a, b, 8, 4
+	}
+	return b, a, 4, 8
+}
+
+// geohashIndex is the inverse of geohashXY.
+func geohashIndex(x, y, pos int) int {
+	var a, b int = x, y
+	if pos%2 != 0 {
+		a, b = y, x
+	}
+	return (a>>2&1)<<4 | (b>>1&1)<<3 | (a>>1&1)<<2 | (b&1)<<1 | a&1
+}
+
+func (geohash) Span(resolution int) (float64, float64) {
+	var bits int = 5 * resolution
+	var latBits int = bits / 2
+	var lonBits int = bits - latBits
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+}
@@ -0,0 +1,136 @@
+package curve
+
+import "testing"
+
+// allCells returns every geohash cell of resolution.
+func allCells(resolution int) []string {
+	var cells = []string{""}
+	for i := 0; i < resolution; i++ {
+		var next []string
+		for _, cell := range cells {
+			for j := 0; j < len(geohashAlphabet); j++ {
+				next = append(next, cell+string(geohashAlphabet[j]))
+			}
+		}
+		cells = next
+	}
+	return cells
+}
+
+func TestGeohashNeighborExhaustive(t *testing.T) {
+	for resolution := 1; resolution <= 3; resolution++ {
+		var latSpan, lonSpan = Geohash.Span(resolution)
+		for _, cell := range allCells(resolution) {
+			var south, west, north, east = Geohash.Bounds(cell)
+			var lat, lon = (south + north) / 2, (west + east) / 2
+
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					var want string
+					var nLat, nLon = lat + float64(dy)*latSpan, lon + float64(dx)*lonSpan
+					if nLon > 180 {
+						nLon -= 360
+					} else if nLon < -180 {
+						nLon += 360
+					}
+					if nLat > -90 && nLat < 90 {
+						want = Geohash.Encode(nLat, nLon, resolution)
+					}
+
+					if got := Geohash.Neighbor(cell, dx, dy); got != want {
+						t.Fatalf("Neighbor(%q, %d, %d) = %q, want %q", cell, dx, dy, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGeohashNeighbor(t *testing.T) {
+	var cases = []struct {
+		cell   string
+		dx, dy int
+		want   string
+	}{
+		{"u1my4r", 1, 0, "u1my4x"},
+		{"u1my4r", 0, 1, "u1my62"},
+		{"u1my4r", -1, -1, "u1my4n"},
+		// Carry through every level.
+		{"bpbpbp", 0, 1, ""},
+		{"zzzzzz", 1, 0, "bpbpbp"},
+		{"00000", -1, 0, "pbpbp"},
+		{"00000", 0, -1, ""},
+		{"", 1, 0, ""},
+	}
+	for _, c := range cases {
+		if got := Geohash.Neighbor(c.cell, c.dx, c.dy); got != c.want {
+			t.Errorf("Neighbor(%q, %d, %d) = %q, want %q", c.cell, c.dx, c.dy, got, c.want)
+		}
+	}
+}
+
+func TestGeohashEncodeBoundaries(t *testing.T) {
+	for resolution := 1; resolution <= 3; resolution++ {
+		var latSpan, lonSpan = Geohash.Span(resolution)
+		for _, cell := range allCells(resolution) {
+			var south, west, north, east = Geohash.Bounds(cell)
+			var lat, lon = (south + north) / 2, (west + east) / 2
+
+			// Cells own their south and west edges and corner.
+			for _, p := range [][2]float64{{south, west}, {south, lon}, {lat, west}} {
+				if got := Geohash.Encode(p[0], p[1], resolution); got != cell {
+					t.Fatalf("Encode(%v, %v, %d) = %q, want %q", p[0], p[1], resolution, got, cell)
+				}
+			}
+			// Their north and east edges belong to the neighbors.
+			if north < 90 {
+				if got, want := Geohash.Encode(north, lon, resolution), Geohash.Encode(lat+latSpan, lon, resolution); got != want {
+					t.Fatalf("Encode(%v, %v, %d) = %q, want %q", north, lon, resolution, got, want)
+				}
+			}
+			if east < 180 {
+				if got, want := Geohash.Encode(lat, east, resolution), Geohash.Encode(lat, lon+lonSpan, resolution); got != want {
+					t.Fatalf("Encode(%v, %v, %d) = %q, want %q", lat, east, resolution, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestGeohashEncodeBoundaryConsistency(t *testing.T) {
+	var cases = []struct {
+		lat, lon float64
+		want     string
+	}{
+		{0, 0, "s0000"},
+		{-90, -180, "00000"},
+		{90, 0, "upbpb"},
+		{90, -180, "bpbpb"},
+		{0, 180, "80000"},
+		{0, -180, "80000"},
+		{45, 90, "y0000"},
+	}
+	for _, c := range cases {
+		var cell = Geohash.Encode(c.lat, c.lon, len(c.want))
+		if cell != c.want {
+			t.Errorf("Encode(%v, %v) = %q, want %q", c.lat, c.lon, cell, c.want)
+		}
+		// Coarser encodings are prefixes of finer ones.
+		for resolution := 1; resolution < len(cell); resolution++ {
+			if got := Geohash.Encode(c.lat, c.lon, resolution); got != cell[:resolution] {
+				t.Errorf("Encode(%v, %v, %d) = %q, want %q", c.lat, c.lon, resolution, got, cell[:resolution])
+			}
+		}
+	}
+}
+
+func TestWrapLon(t *testing.T) {
+	for _, c := range [][2]float64{{0, 0}, {180, -180}, {-180, -180}, {190, -170}, {350, -10}, {360, 0}, {-190, 170}, {540, -180}} {
+		if got := WrapLon(c[0]); got != c[1] {
+			t.Errorf("WrapLon(%v) = %v, want %v", c[0], got, c[1])
+		}
+	}
+	if got, want := Geohash.Encode(50, 350, 6), Geohash.Encode(50, -10, 6); got != want {
+		t.Errorf("Encode(50, 350) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+// Package firsterr keeps the first error of a series of searches, for the
+// Query types of the storage adapters: geomodel.RepositorySearch cannot
+// return errors, so adapters keep the first one for their Err method and
+// return no entities once a search has failed.
+package firsterr
+
+import (
+	"sync"
+
+	"github.com/alternaDev/geomodel"
+)
+
+// Keeper keeps the first error of the searches run through it. The zero
+// value is ready to use, and a Keeper is safe for concurrent use.
+type Keeper struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Run returns the entities found by search, or none if search fails or an
+// earlier search failed, keeping the first error. search is not called
+// once an error is kept.
+func (k *Keeper) Run(search func() ([]geomodel.LocationCapable, error)) []geomodel.LocationCapable {
+	if k.Err() != nil {
+		return nil
+	}
+	results, err := search()
+	if err != nil {
+		k.mu.Lock()
+		if k.err == nil {
+			k.err = err
+		}
+		k.mu.Unlock()
+		return nil
+	}
+	return results
+}
+
+// Err returns the first error kept.
+func (k *Keeper) Err() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.err
+}
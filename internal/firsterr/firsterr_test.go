@@ -0,0 +1,26 @@
+package firsterr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+)
+
+func TestKeeper(t *testing.T) {
+	var k Keeper
+	var first, second = errors.New("first"), errors.New("second")
+	var found = []geomodel.LocationCapable{&geomodel.IndexRecord{ID: "a"}}
+
+	if got := k.Run(func() ([]geomodel.LocationCapable, error) { return found, nil }); len(got) != 1 || k.Err() != nil {
+		t.Fatalf("Run = %v with Err %v", got, k.Err())
+	}
+	k.Run(func() ([]geomodel.LocationCapable, error) { return found, first })
+	var called bool
+	if got := k.Run(func() ([]geomodel.LocationCapable, error) { called = true; return nil, second }); got != nil || called {
+		t.Errorf("Run after an error returned %v, called search %v", got, called)
+	}
+	if k.Err() != first {
+		t.Errorf("Err() = %v, want %v", k.Err(), first)
+	}
+}
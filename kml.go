@@ -0,0 +1,86 @@
+package geomodel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// kmlPalette holds the colors of cells by resolution, cycling for
+// resolutions past its end, as KML aabbggrr hex strings.
+var kmlPalette = []string{
+	"ff0000ff", // red
+	"ff0080ff", // orange
+	"ff00ffff", // yellow
+	"ff00ff00", // green
+	"ffffff00", // cyan
+	"ffff0000", // blue
+	"ffff00ff", // magenta
+	"ffffffff", // white
+}
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Namespace  string         `xml:"xmlns,attr"`
+	Name       string         `xml:"Document>name"`
+	Styles     []kmlStyle     `xml:"Document>Style"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlStyle struct {
+	ID        string `xml:"id,attr"`
+	LineColor string `xml:"LineStyle>color"`
+	LineWidth int    `xml:"LineStyle>width"`
+	PolyColor string `xml:"PolyStyle>color"`
+}
+
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	StyleURL    string `xml:"styleUrl"`
+	Coordinates string `xml:"Polygon>outerBoundaryIs>LinearRing>coordinates"`
+}
+
+// WriteCellsKML writes cells as a KML document of polygons, for viewing
+// coverings in Google Earth. Each polygon is named after its cell and
+// styled by resolution, with a solid outline and a translucent fill of the
+// same color.
+func WriteCellsKML(w io.Writer, cells []string) error {
+	var doc kmlDocument = kmlDocument{Namespace: "http://www.opengis.net/kml/2.2", Name: "geocells"}
+	var styled map[int]bool = make(map[int]bool)
+	for _, cell := range cells {
+		var resolution int = len(cell)
+		if !styled[resolution] {
+			styled[resolution] = true
+			var color string = kmlPalette[(resolution-1+len(kmlPalette))%len(kmlPalette)]
+			doc.Styles = append(doc.Styles, kmlStyle{
+				ID:        fmt.Sprintf("resolution-%d", resolution),
+				LineColor: color,
+				LineWidth: 2,
+				PolyColor: "40" + color[2:],
+			})
+		}
+
+		var coordinates []string
+		for _, p := range ComputeBox(cell).ring() {
+			coordinates = append(coordinates, strconv.FormatFloat(p[0], 'f', -1, 64)+","+strconv.FormatFloat(p[1], 'f', -1, 64)+",0")
+		}
+		doc.Placemarks = append(doc.Placemarks, kmlPlacemark{
+			Name:        cell,
+			StyleURL:    fmt.Sprintf("#resolution-%d", resolution),
+			Coordinates: strings.Join(coordinates, " "),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	var encoder *xml.Encoder = xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,33 @@
+package geomodel
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteCellsKML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCellsKML(&buf, []string{"s", "u1", "u2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc kmlDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("%v in %s", err, buf.String())
+	}
+	if len(doc.Styles) != 2 || len(doc.Placemarks) != 3 {
+		t.Fatalf("got %d styles and %d placemarks, want 2 and 3", len(doc.Styles), len(doc.Placemarks))
+	}
+	if doc.Styles[0].ID != "resolution-1" || doc.Styles[0].LineColor == doc.Styles[1].LineColor {
+		t.Errorf("unexpected styles %+v", doc.Styles)
+	}
+	var s = doc.Placemarks[0]
+	if s.Name != "s" || s.StyleURL != "#resolution-1" || s.Coordinates != "0,0,0 45,0,0 45,45,0 0,45,0 0,0,0" {
+		t.Errorf("unexpected placemark %+v", s)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("missing XML header")
+	}
+}
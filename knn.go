@@ -0,0 +1,65 @@
+package geomodel
+
+import "strconv"
+
+// Neighbor is an edge of a neighborhood graph: the index of the neighboring
+// entity and its distance in meters.
+type Neighbor struct {
+	Index    int
+	Distance float64
+}
+
+// indexedEntity is an entity stored in a GeoIndex under its position in a
+// slice, so that entities sharing a key stay apart.
+type indexedEntity struct {
+	LocationCapable
+	index    int
+	key      string
+	geocells []string
+}
+
+func (e *indexedEntity) Key() string        { return e.key }
+func (e *indexedEntity) Geocells() []string { return e.geocells }
+
+// indexEntities returns an index of the valid entities, keyed by their
+// positions in entities and holding every geocell of their locations.
+func indexEntities(entities []LocationCapable) *GeoIndex {
+	var index *GeoIndex = NewGeoIndex()
+	for i, entity := range entities {
+		var lat, lon float64 = entity.Latitude(), entity.Longitude()
+		if (Point{Lat: lat, Lon: lon}).Validate() != nil {
+			continue
+		}
+		index.Insert(&indexedEntity{entity, i, strconv.Itoa(i), GeoCells(lat, lon, MAX_GEOCELL_RESOLUTION)})
+	}
+	return index
+}
+
+// KNNGraph returns, for each of entities, its k nearest other entities,
+// nearest first. Entities are found through a GeoIndex of their cells, so
+// each search only visits the cells around the entity. Entities with an
+// invalid location have no neighbors and are nobody's neighbor.
+func KNNGraph(entities []LocationCapable, k int) [][]Neighbor {
+	var graph [][]Neighbor = make([][]Neighbor, len(entities))
+	if k <= 0 {
+		return graph
+	}
+	var index *GeoIndex = indexEntities(entities)
+	// An entity has at most every other indexed entity as neighbor, however
+	// large k is.
+	var capacity int = min(k, index.Len()-1)
+	for i, entity := range entities {
+		if _, ok := index.Get(strconv.Itoa(i)); !ok {
+			continue
+		}
+		var neighbors []Neighbor = make([]Neighbor, 0, capacity)
+		index.scan(entity.Latitude(), entity.Longitude(), func(r SearchResult) bool {
+			if j := r.Entity.(*indexedEntity).index; j != i {
+				neighbors = append(neighbors, Neighbor{j, r.Distance})
+			}
+			return len(neighbors) < k
+		})
+		graph[i] = neighbors
+	}
+	return graph
+}
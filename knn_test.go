@@ -0,0 +1,49 @@
+package geomodel
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestKNNGraph(t *testing.T) {
+	var entities []LocationCapable = randomPlaces(500)
+	// A duplicate key and an invalid location.
+	entities = append(entities, Place{50.5, 8.5, "0", nil}, Place{math.NaN(), 8, "nowhere", nil})
+
+	var graph [][]Neighbor = KNNGraph(entities, 5)
+	if len(graph) != len(entities) {
+		t.Fatalf("graph has %d rows, want %d", len(graph), len(entities))
+	}
+	for i, a := range entities[:len(entities)-1] {
+		var distances []float64
+		for j, b := range entities[:len(entities)-1] {
+			if j != i {
+				distances = append(distances, Distance(a.Latitude(), a.Longitude(), b.Latitude(), b.Longitude()))
+			}
+		}
+		sort.Float64s(distances)
+
+		if len(graph[i]) != 5 {
+			t.Fatalf("entity %d has %d neighbors, want 5", i, len(graph[i]))
+		}
+		for n, neighbor := range graph[i] {
+			var b LocationCapable = entities[neighbor.Index]
+			if neighbor.Index == i || neighbor.Distance != distances[n] || Distance(a.Latitude(), a.Longitude(), b.Latitude(), b.Longitude()) != neighbor.Distance {
+				t.Errorf("neighbor %d of entity %d = %+v, want distance %v", n, i, neighbor, distances[n])
+			}
+		}
+	}
+	if nowhere := graph[len(graph)-1]; len(nowhere) != 0 {
+		t.Errorf("invalid entity has neighbors %v", nowhere)
+	}
+}
+
+func TestKNNGraphHugeK(t *testing.T) {
+	var entities []LocationCapable = randomPlaces(3)
+	for i, neighbors := range KNNGraph(entities, math.MaxInt) {
+		if len(neighbors) != 2 {
+			t.Errorf("entity %d has %d neighbors, want 2", i, len(neighbors))
+		}
+	}
+}
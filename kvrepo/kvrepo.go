@@ -0,0 +1,294 @@
+// Package kvrepo implements geomodel storage over ordered embedded key-value
+// stores such as Bolt or Badger, for proximity services with no external
+// database. Each entity is stored once, under a composite key of its finest
+// geocell and its entity key, so that the entities of any cell are found by
+// a single prefix scan:
+//
+//	c<geocell>|<key>  ->  encoded entity
+//	k<key>            ->  geocell, to find the record again on moves
+//
+// Entities implementing geomodel.Partitioned are stored in a keyspace of
+// their partition, whose keys are those above prefixed with p<partition>|,
+// and are searched with SearchPartition.
+//
+// The package does not import any store; Store is small enough to implement
+// over a Bolt bucket or a Badger transaction in a few lines.
+package kvrepo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/internal/firsterr"
+)
+
+var (
+	// ErrCorruptRecord is returned when a stored value cannot be decoded.
+	ErrCorruptRecord = errors.New("kvrepo: corrupt record")
+	// ErrInvalidPartition is returned for partitions containing the
+	// separator of composite keys.
+	ErrInvalidPartition = errors.New("kvrepo: partition contains '|'")
+)
+
+// Store is an ordered key-value store. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Get returns the value stored under key, or nil if there is none.
+	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// Scan calls fn for each key starting with prefix, in key order, and
+	// stops at the first error fn returns. Keys and values may be reused
+	// after fn returns.
+	Scan(prefix []byte, fn func(key, value []byte) error) error
+}
+
+// Codec converts entities to and from stored values.
+type Codec interface {
+	Encode(entity geomodel.LocationCapable) ([]byte, error)
+	// Decode returns the entity stored with the given key and value in
+	// cell, the finest geocell it was stored under.
+	Decode(key, cell string, value []byte) (geomodel.LocationCapable, error)
+}
+
+// Key prefixes of the record and cell lookup key spaces, and the separator
+// of composite record keys, which is outside the geocell alphabet.
+const (
+	recordPrefix    = 'c'
+	cellPrefix      = 'k'
+	partitionPrefix = 'p'
+	separator       = '|'
+)
+
+// Repository stores entities in a Store. Its fields are read-only once it is
+// in use.
+type Repository struct {
+	Store Store
+	// Resolution is the geocell resolution entities are stored at. It
+	// defaults to geomodel.MAX_GEOCELL_RESOLUTION.
+	Resolution int
+	// Codec defaults to one storing the location only and decoding
+	// *geomodel.IndexRecord values.
+	Codec Codec
+
+	// writes serializes Put and Delete, each of which updates two keys.
+	writes sync.Mutex
+}
+
+// Put stores entity under its key in its partition, replacing any entity
+// with the same key there. Entities implementing geomodel.GeocellSetter get
+// their geocells attached before they are encoded. Entities whose location
+// has no geocells, such as NaN coordinates, are rejected with an error
+// wrapping geomodel.ErrInvalidPoint.
+func (r *Repository) Put(entity geomodel.LocationCapable) error {
+	space, err := keyspace(geomodel.PartitionOf(entity))
+	if err != nil {
+		return err
+	}
+	var indexer = geomodel.Indexer{Resolution: r.resolution()}
+	var cells []string = indexer.BeforePut(entity)
+	if len(cells) == 0 {
+		return fmt.Errorf("%w: %v, %v", geomodel.ErrInvalidPoint, entity.Latitude(), entity.Longitude())
+	}
+	value, err := r.codec().Encode(entity)
+	if err != nil {
+		return err
+	}
+	var key string = entity.Key()
+	var cell string = cells[len(cells)-1]
+
+	r.writes.Lock()
+	defer r.writes.Unlock()
+	old, err := r.Store.Get(cellKey(space, key))
+	if err != nil {
+		return err
+	}
+	if old != nil && string(old) != cell {
+		if err = r.Store.Delete(recordKey(space, string(old), key)); err != nil {
+			return err
+		}
+	}
+	if err = r.Store.Put(recordKey(space, cell, key), value); err != nil {
+		return err
+	}
+	return r.Store.Put(cellKey(space, key), []byte(cell))
+}
+
+// Delete removes the entity with the given key that is in no partition.
+// Deleting a missing key is not an error.
+func (r *Repository) Delete(key string) error {
+	return r.DeletePartition("", key)
+}
+
+// DeletePartition removes the entity with the given key from partition.
+// Deleting a missing key is not an error.
+func (r *Repository) DeletePartition(partition, key string) error {
+	space, err := keyspace(partition)
+	if err != nil {
+		return err
+	}
+	r.writes.Lock()
+	defer r.writes.Unlock()
+	cell, err := r.Store.Get(cellKey(space, key))
+	if err != nil || cell == nil {
+		return err
+	}
+	if err = r.Store.Delete(recordKey(space, string(cell), key)); err != nil {
+		return err
+	}
+	return r.Store.Delete(cellKey(space, key))
+}
+
+// Search returns the entities in no partition stored in any of cells, each
+// once, scanning the records of each cell by prefix.
+func (r *Repository) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	return r.SearchPartition("", cells)
+}
+
+// SearchPartition is Search over the keyspace of partition, so that the
+// records of other partitions are never read.
+func (r *Repository) SearchPartition(partition string, cells []string) ([]geomodel.LocationCapable, error) {
+	space, err := keyspace(partition)
+	if err != nil {
+		return nil, err
+	}
+	var results []geomodel.LocationCapable
+	var seen map[string]struct{} = make(map[string]struct{})
+	for _, cell := range cells {
+		var prefix []byte = append(append(space, recordPrefix), cell...)
+		var err error = r.Store.Scan(prefix, func(k, value []byte) error {
+			k = k[len(space):]
+			var i int = bytes.IndexByte(k, separator)
+			if i < 0 {
+				return ErrCorruptRecord
+			}
+			var key string = string(k[i+1:])
+			if _, ok := seen[key]; ok {
+				return nil
+			}
+			entity, err := r.codec().Decode(key, string(k[1:i]), value)
+			if err != nil {
+				return err
+			}
+			seen[key] = struct{}{}
+			results = append(results, entity)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Query returns a Query running searches on the repository.
+func (r *Repository) Query() *Query {
+	return &Query{repo: r}
+}
+
+func (r *Repository) resolution() int {
+	if r.Resolution <= 0 {
+		return geomodel.MAX_GEOCELL_RESOLUTION
+	}
+	return r.Resolution
+}
+
+func (r *Repository) codec() Codec {
+	if r.Codec == nil {
+		return locationCodec{}
+	}
+	return r.Codec
+}
+
+// keyspace returns the prefix of the keys of partition, which is empty for
+// the empty partition.
+func keyspace(partition string) ([]byte, error) {
+	if partition == "" {
+		return nil, nil
+	}
+	if strings.IndexByte(partition, separator) >= 0 {
+		return nil, ErrInvalidPartition
+	}
+	var space []byte = make([]byte, 0, len(partition)+2)
+	space = append(space, partitionPrefix)
+	space = append(space, partition...)
+	return append(space, separator), nil
+}
+
+func recordKey(space []byte, cell, key string) []byte {
+	var k []byte = make([]byte, 0, len(space)+len(cell)+len(key)+2)
+	k = append(k, space...)
+	k = append(k, recordPrefix)
+	k = append(k, cell...)
+	k = append(k, separator)
+	return append(k, key...)
+}
+
+func cellKey(space []byte, key string) []byte {
+	var k []byte = make([]byte, 0, len(space)+len(key)+1)
+	k = append(k, space...)
+	k = append(k, cellPrefix)
+	return append(k, key...)
+}
+
+// locationCodec stores the latitude and longitude as little-endian float64
+// bits and decodes *geomodel.IndexRecord values whose geocells are the
+// prefixes of the cell the entity is stored under.
+type locationCodec struct{}
+
+func (locationCodec) Encode(entity geomodel.LocationCapable) ([]byte, error) {
+	var value []byte = make([]byte, 0, 16)
+	value = binary.LittleEndian.AppendUint64(value, math.Float64bits(entity.Latitude()))
+	return binary.LittleEndian.AppendUint64(value, math.Float64bits(entity.Longitude())), nil
+}
+
+func (locationCodec) Decode(key, cell string, value []byte) (geomodel.LocationCapable, error) {
+	if len(value) != 16 {
+		return nil, ErrCorruptRecord
+	}
+	var cells []string = make([]string, len(cell))
+	for i := range cells {
+		cells[i] = cell[:i+1]
+	}
+	return &geomodel.IndexRecord{
+		ID:    key,
+		Lat:   math.Float64frombits(binary.LittleEndian.Uint64(value[:8])),
+		Lon:   math.Float64frombits(binary.LittleEndian.Uint64(value[8:])),
+		Cells: cells,
+	}, nil
+}
+
+// Query adapts the repository to geomodel.RepositorySearch, which cannot
+// return errors: the first error is kept for Err and makes later searches
+// return no entities.
+type Query struct {
+	repo  *Repository
+	first firsterr.Keeper
+}
+
+// Search implements geomodel.RepositorySearch. It is safe for concurrent
+// use, as with geomodel.WithParallelism.
+func (q *Query) Search(cells []string) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.Search(cells)
+	})
+}
+
+// SearchPartition implements geomodel.PartitionSearch, searching as
+// Repository.SearchPartition does.
+func (q *Query) SearchPartition(partition string, cells []string) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.SearchPartition(partition, cells)
+	})
+}
+
+// Err returns the first error of the searches run so far.
+func (q *Query) Err() error {
+	return q.first.Err()
+}
@@ -0,0 +1,172 @@
+package kvrepo
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/adaptertest"
+)
+
+// memStore is an ordered Store over a map, sorting keys on each scan.
+type memStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func newMemStore() *memStore { return &memStore{data: make(map[string][]byte)} }
+
+func (s *memStore) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data[string(key)], nil
+}
+
+func (s *memStore) Put(key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *memStore) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, string(key))
+	return nil
+}
+
+func (s *memStore) Scan(prefix []byte, fn func(key, value []byte) error) error {
+	s.mu.RLock()
+	var keys []string
+	for k := range s.data {
+		if strings.HasPrefix(k, string(prefix)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var values [][]byte = make([][]byte, len(keys))
+	for i, k := range keys {
+		values[i] = s.data[k]
+	}
+	s.mu.RUnlock()
+
+	for i, k := range keys {
+		if err := fn([]byte(k), values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cellRecord is an IndexRecord taking the geocells Put attaches.
+type cellRecord struct{ geomodel.IndexRecord }
+
+func (r *cellRecord) SetGeocells(cells []string) { r.Cells = cells }
+
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func() adaptertest.Index { return &Repository{Store: newMemStore()} })
+}
+
+func TestRepository(t *testing.T) {
+	var store = newMemStore()
+	var repo = &Repository{Store: store, Resolution: 9}
+	for _, r := range []*geomodel.IndexRecord{
+		{ID: "a", Lat: 50, Lon: 8},
+		{ID: "b", Lat: 50.001, Lon: 8.001},
+		{ID: "c", Lat: -33.87, Lon: 151.21},
+	} {
+		if err := repo.Put(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One record and one cell lookup per entity.
+	if len(store.data) != 6 {
+		t.Errorf("store holds %d keys, want 6", len(store.data))
+	}
+
+	var query = repo.Query()
+	var results = geomodel.ProximityFetch(50, 8, 5, 1000, query.Search, 9)
+	if err := query.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Key() != "a" || len(results[0].Geocells()) != 9 {
+		t.Errorf("ProximityFetch returned %v, want a and b", results)
+	}
+
+	if err := repo.Put(&geomodel.IndexRecord{ID: "a", Lat: -33.87, Lon: 151.2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.data) != 6 {
+		t.Errorf("moving an entity left %d keys, want 6", len(store.data))
+	}
+
+	var r = &cellRecord{geomodel.IndexRecord{ID: "d", Lat: 50, Lon: 8}}
+	if err := repo.Put(r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Cells) != 9 || r.Cells[8] != geomodel.GeoCell(50, 8, 9) {
+		t.Errorf("Put attached geocells %v", r.Cells)
+	}
+
+	if err := repo.Put(&geomodel.IndexRecord{ID: "nan", Lat: math.NaN(), Lon: 8}); !errors.Is(err, geomodel.ErrInvalidPoint) {
+		t.Errorf("Put at NaN returned %v, want ErrInvalidPoint", err)
+	}
+
+	store.data["c"+geomodel.GeoCell(50, 8, 9)+"|bad"] = []byte("short")
+	if _, err := repo.Search([]string{geomodel.GeoCell(50, 8, 4)}); !errors.Is(err, ErrCorruptRecord) {
+		t.Errorf("Search over a corrupt record returned %v, want ErrCorruptRecord", err)
+	}
+}
+
+// tenantRecord is an IndexRecord of a partition.
+type tenantRecord struct {
+	geomodel.IndexRecord
+	tenant string
+}
+
+func (r *tenantRecord) Partition() string { return r.tenant }
+
+func TestRepositoryPartition(t *testing.T) {
+	var store = newMemStore()
+	var repo = &Repository{Store: store, Resolution: 9}
+	for _, r := range []geomodel.LocationCapable{
+		&tenantRecord{geomodel.IndexRecord{ID: "x", Lat: 50, Lon: 8}, "acme"},
+		&tenantRecord{geomodel.IndexRecord{ID: "x", Lat: 50.001, Lon: 8}, "globex"},
+		&geomodel.IndexRecord{ID: "x", Lat: 50.002, Lon: 8},
+	} {
+		if err := repo.Put(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cells = []string{geomodel.GeoCell(50, 8, 4)}
+	for partition, lat := range map[string]float64{"acme": 50, "globex": 50.001, "": 50.002} {
+		found, err := repo.SearchPartition(partition, cells)
+		if err != nil || len(found) != 1 || found[0].Latitude() != lat {
+			t.Errorf("SearchPartition(%q) returned %v (err %v), want the entity at %v", partition, found, err, lat)
+		}
+	}
+
+	var search, scope = geomodel.PartitionSearch(repo.Query().SearchPartition).In("globex")
+	if found := geomodel.ProximityFetch(50, 8, 5, 1000, search, 9, scope); len(found) != 1 || found[0].Latitude() != 50.001 {
+		t.Errorf("ProximityFetch in globex returned %v", found)
+	}
+
+	if err := repo.DeletePartition("acme", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := repo.SearchPartition("acme", cells); len(found) != 0 {
+		t.Errorf("SearchPartition after DeletePartition returned %v", found)
+	}
+	if found, _ := repo.Search(cells); len(found) != 1 {
+		t.Errorf("DeletePartition removed entities of other partitions: Search returned %v", found)
+	}
+	if _, err := repo.SearchPartition("a|b", cells); !errors.Is(err, ErrInvalidPartition) {
+		t.Errorf("SearchPartition(a|b) returned %v, want ErrInvalidPartition", err)
+	}
+}
@@ -0,0 +1,45 @@
+package geomodel
+
+import "strconv"
+
+// Length is a distance stored in meters, like the float64 distances taken
+// and returned by searches, but carrying its unit in its type:
+//
+//	var radius geomodel.Length = 5 * geomodel.Mile
+//	var results = geomodel.ProximityFetchResults(lat, lon, 10, radius.Meters(), search, geomodel.MAX_GEOCELL_RESOLUTION)
+//	fmt.Println(results[0].Length().Kilometers())
+//
+// The function Distance predates it and keeps its name.
+type Length float64
+
+// Common units of Length.
+const (
+	Meter        Length = 1
+	Kilometer    Length = 1000
+	Mile         Length = 1609.344
+	NauticalMile Length = 1852
+)
+
+// Meters returns l in meters, as passed as maxDistance to searches.
+func (l Length) Meters() float64 { return float64(l) }
+
+// Kilometers returns l in kilometers.
+func (l Length) Kilometers() float64 { return float64(l / Kilometer) }
+
+// Miles returns l in statute miles.
+func (l Length) Miles() float64 { return float64(l / Mile) }
+
+// NauticalMiles returns l in nautical miles.
+func (l Length) NauticalMiles() float64 { return float64(l / NauticalMile) }
+
+// String formats l in meters below one kilometer and in kilometers
+// otherwise, such as "850m" or "12.5km".
+func (l Length) String() string {
+	if l > -Kilometer && l < Kilometer {
+		return strconv.FormatFloat(l.Meters(), 'f', -1, 64) + "m"
+	}
+	return strconv.FormatFloat(l.Kilometers(), 'f', -1, 64) + "km"
+}
+
+// Length returns the distance of the result from the search origin.
+func (r SearchResult) Length() Length { return Length(r.Distance) }
@@ -0,0 +1,28 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	var l Length = 10 * Mile
+	if l.Meters() != 16093.44 || math.Abs(l.Kilometers()-16.09344) > 1e-12 || math.Abs(l.Miles()-10) > 1e-12 {
+		t.Errorf("10 miles = %v m, %v km, %v mi", l.Meters(), l.Kilometers(), l.Miles())
+	}
+	if n := (3 * NauticalMile).NauticalMiles(); n != 3 {
+		t.Errorf("3 nautical miles = %v nautical miles", n)
+	}
+
+	for l, want := range map[Length]string{850 * Meter: "850m", 12.5 * Kilometer: "12.5km", 0: "0m", -2 * Kilometer: "-2km"} {
+		if got := l.String(); got != want {
+			t.Errorf("Length(%v).String() = %q, want %q", float64(l), got, want)
+		}
+	}
+
+	var places = []LocationCapable{Place{50.01, 8, "1", GeoCells(50.01, 8, 10)}}
+	var results = ProximityFetchResults(50, 8, 1, (2 * Kilometer).Meters(), searchPlaces(places), 10)
+	if len(results) != 1 || math.Abs(results[0].Length().Kilometers()-1.113) > 0.001 {
+		t.Errorf("got %v, want the place about 1.1 km away", results)
+	}
+}
@@ -0,0 +1,24 @@
+package geomodel
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+var packageLogger atomic.Pointer[slog.Logger]
+
+// SetLogger installs the logger used by searches that do not specify one
+// with WithLogger. The package is silent by default; passing nil restores
+// that behaviour. Search progress is traced at slog.LevelDebug.
+func SetLogger(l *slog.Logger) {
+	packageLogger.Store(l)
+}
+
+func currentLogger() *slog.Logger {
+	if l := packageLogger.Load(); l != nil {
+		return l
+	}
+	return discardLogger
+}
@@ -0,0 +1,148 @@
+package geomodel
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// matrixLeafPairs is the number of point pairs below which DistanceMatrix
+// compares two groups of points directly instead of splitting them further.
+const matrixLeafPairs = 256
+
+// MatrixEntry is the distance in meters between the origin and destination
+// with the given indexes.
+type MatrixEntry struct {
+	Origin, Destination int
+	Distance            float64
+}
+
+// SparseMatrix holds the distances of the pairs of points within a cutoff,
+// as returned by DistanceMatrix. Pairs farther apart have no entry.
+type SparseMatrix struct {
+	Rows, Cols int
+	// Entries are ordered by Origin, then Destination.
+	Entries []MatrixEntry
+}
+
+// At returns the distance between origin and destination, or false if they
+// are beyond the cutoff.
+func (m *SparseMatrix) At(origin, destination int) (float64, bool) {
+	var i, found = slices.BinarySearchFunc(m.Entries, [2]int{origin, destination}, func(e MatrixEntry, key [2]int) int {
+		return cmp.Or(cmp.Compare(e.Origin, key[0]), cmp.Compare(e.Destination, key[1]))
+	})
+	if !found {
+		return 0, false
+	}
+	return m.Entries[i].Distance, true
+}
+
+// Row returns the entries of origin, by destination.
+func (m *SparseMatrix) Row(origin int) []MatrixEntry {
+	var start int = sort.Search(len(m.Entries), func(i int) bool { return m.Entries[i].Origin >= origin })
+	var end int = sort.Search(len(m.Entries), func(i int) bool { return m.Entries[i].Origin > origin })
+	return m.Entries[start:end]
+}
+
+// matrixPoint is a point of DistanceMatrix with its index and finest cell.
+type matrixPoint struct {
+	Point
+	index int
+	cell  string
+}
+
+// DistanceMatrix returns the great-circle distances, computed by Distance, of
+// the pairs of an origin and a destination at most cutoff meters apart.
+// Invalid points have no entries.
+//
+// Both sets are sorted by geocell and split along shared cell prefixes
+// together, and pairs of groups whose cells are farther apart than cutoff
+// are skipped whole, so that only pairs near each other are measured. The
+// cost grows with the number of pairs within reach of the cutoff rather
+// than with len(origins)*len(destinations).
+func DistanceMatrix(origins, destinations []Point, cutoff float64) *SparseMatrix {
+	var matrix *SparseMatrix = &SparseMatrix{Rows: len(origins), Cols: len(destinations)}
+	var from, to []matrixPoint = matrixPoints(origins), matrixPoints(destinations)
+
+	// pair adds the entries of from and to, whose points share their first
+	// fromDepth and toDepth cell characters and lie in fromBox and toBox.
+	var pair func(from []matrixPoint, fromDepth int, fromBox BoundingBox, to []matrixPoint, toDepth int, toBox BoundingBox)
+	pair = func(from []matrixPoint, fromDepth int, fromBox BoundingBox, to []matrixPoint, toDepth int, toBox BoundingBox) {
+		if len(from)*len(to) <= matrixLeafPairs || fromDepth == MAX_GEOCELL_RESOLUTION && toDepth == MAX_GEOCELL_RESOLUTION {
+			for _, o := range from {
+				for _, d := range to {
+					if distance := Distance(o.Lat, o.Lon, d.Lat, d.Lon); distance <= cutoff {
+						matrix.Entries = append(matrix.Entries, MatrixEntry{o.index, d.index, distance})
+					}
+				}
+			}
+			return
+		}
+
+		// Split the larger group, keeping the other whole.
+		if toDepth == MAX_GEOCELL_RESOLUTION || fromDepth < MAX_GEOCELL_RESOLUTION && len(from) >= len(to) {
+			for _, group := range splitMatrixPoints(from, fromDepth) {
+				var box BoundingBox = ComputeBox(group[0].cell[:fromDepth+1])
+				if boxesWithin(box, toBox, cutoff) {
+					pair(group, fromDepth+1, box, to, toDepth, toBox)
+				}
+			}
+			return
+		}
+		for _, group := range splitMatrixPoints(to, toDepth) {
+			var box BoundingBox = ComputeBox(group[0].cell[:toDepth+1])
+			if boxesWithin(fromBox, box, cutoff) {
+				pair(from, fromDepth, fromBox, group, toDepth+1, box)
+			}
+		}
+	}
+	if len(from) > 0 && len(to) > 0 {
+		var world BoundingBox = BoundingBox{90, 180, -90, -180}
+		pair(from, 0, world, to, 0, world)
+	}
+
+	slices.SortFunc(matrix.Entries, func(a, b MatrixEntry) int {
+		return cmp.Or(cmp.Compare(a.Origin, b.Origin), cmp.Compare(a.Destination, b.Destination))
+	})
+	return matrix
+}
+
+// boxesWithin reports whether a and b hold points at most cutoff meters
+// apart. The gap between their latitudes, a lower bound of the distance,
+// rules out most boxes before the exact distance is computed.
+func boxesWithin(a, b BoundingBox, cutoff float64) bool {
+	var gap float64 = max(a.latSW-b.latNE, b.latSW-a.latNE, 0)
+	if DegToRad(gap)*EARTH_RADIUS > cutoff {
+		return false
+	}
+	return boxDistance(a, b) <= cutoff
+}
+
+// matrixPoints returns the valid points with their indexes and finest cells,
+// sorted by cell.
+func matrixPoints(points []Point) []matrixPoint {
+	var result []matrixPoint = make([]matrixPoint, 0, len(points))
+	for i, p := range points {
+		if p.Validate() != nil {
+			continue
+		}
+		result = append(result, matrixPoint{p, i, GeoCell(p.Lat, p.Lon, MAX_GEOCELL_RESOLUTION)})
+	}
+	slices.SortFunc(result, func(a, b matrixPoint) int { return cmp.Compare(a.cell, b.cell) })
+	return result
+}
+
+// splitMatrixPoints splits points, sorted by cell and sharing its first
+// depth characters, into runs sharing the next one.
+func splitMatrixPoints(points []matrixPoint, depth int) [][]matrixPoint {
+	var groups [][]matrixPoint
+	for start := 0; start < len(points); {
+		var end int = start + 1
+		for end < len(points) && points[end].cell[depth] == points[start].cell[depth] {
+			end++
+		}
+		groups = append(groups, points[start:end])
+		start = end
+	}
+	return groups
+}
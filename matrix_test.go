@@ -0,0 +1,68 @@
+package geomodel
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomPoints(rng *rand.Rand, n int, south, west, size float64) []Point {
+	var points []Point = make([]Point, n)
+	for i := range points {
+		points[i] = Point{Lat: south + rng.Float64()*size, Lon: wrapLon(west + rng.Float64()*size)}
+	}
+	return points
+}
+
+func TestDistanceMatrix(t *testing.T) {
+	var rng = rand.New(rand.NewSource(1))
+	var cases = []struct {
+		name                  string
+		origins, destinations []Point
+		cutoff                float64
+	}{
+		{"city", randomPoints(rng, 300, 52.4, 13.2, 0.3), randomPoints(rng, 200, 52.4, 13.2, 0.3), 2000},
+		{"across the antimeridian", randomPoints(rng, 200, -18, 179, 2), randomPoints(rng, 200, -18, 179, 2), 50000},
+		{"near the pole", randomPoints(rng, 100, 88, 0, 2), randomPoints(rng, 100, 88, 170, 2), 300000},
+	}
+	for _, c := range cases {
+		var matrix *SparseMatrix = DistanceMatrix(c.origins, c.destinations, c.cutoff)
+		var want int
+		for i, o := range c.origins {
+			for j, d := range c.destinations {
+				var distance float64 = Distance(o.Lat, o.Lon, d.Lat, d.Lon)
+				var got, ok = matrix.At(i, j)
+				if ok != (distance <= c.cutoff) || ok && got != distance {
+					t.Errorf("%s: At(%d, %d) = %v, %v; want %v within %v", c.name, i, j, got, ok, distance, c.cutoff)
+				}
+				if ok {
+					want++
+				}
+			}
+		}
+		if len(matrix.Entries) != want || want == 0 {
+			t.Errorf("%s: %d entries, want %d", c.name, len(matrix.Entries), want)
+		}
+		for _, e := range matrix.Row(7) {
+			if e.Origin != 7 {
+				t.Errorf("%s: Row(7) holds %+v", c.name, e)
+			}
+		}
+	}
+}
+
+func TestDistanceMatrixSkipsInvalidPoints(t *testing.T) {
+	var matrix = DistanceMatrix([]Point{{Lat: math.NaN()}, {Lat: 1, Lon: 1}}, []Point{{Lat: 1, Lon: 1}}, 10)
+	if matrix.Rows != 2 || matrix.Cols != 1 || len(matrix.Entries) != 1 || matrix.Entries[0].Origin != 1 {
+		t.Errorf("DistanceMatrix = %+v, want the valid pair only", matrix)
+	}
+}
+
+func BenchmarkDistanceMatrix(b *testing.B) {
+	var rng = rand.New(rand.NewSource(1))
+	var origins, destinations = randomPoints(rng, 10000, 50, 8, 1), randomPoints(rng, 10000, 50, 8, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DistanceMatrix(origins, destinations, 1000)
+	}
+}
@@ -0,0 +1,262 @@
+// Package mongorepo implements geomodel searches over MongoDB collections
+// whose documents carry an array of their geocells, matched with a multikey
+// index instead of a 2dsphere index:
+//
+//	var repo = &mongorepo.Repository{
+//		Find: func(ctx context.Context, filter map[string]any) (mongorepo.Cursor, error) {
+//			return collection.Find(ctx, filter)
+//		},
+//	}
+//	collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: repo.IndexKeys()})
+//	collection.InsertOne(ctx, repo.WithGeocells(bson.M{"_id": id, "name": name}, lat, lon))
+//
+//	var query = repo.Query(ctx)
+//	var nearby = geomodel.ProximityFetch(lat, lon, 10, 1000, query.Search, geomodel.MAX_GEOCELL_RESOLUTION)
+//	if err := query.Err(); err != nil {
+//		return err
+//	}
+//
+// Query also implements geomodel.FilterSearcher, adding a filter on other
+// fields to the query:
+//
+//	var restaurants = geomodel.Filtered(query, map[string]any{"category": "restaurant"})
+//
+// The package does not import the MongoDB driver; the official driver's
+// *mongo.Cursor implements Cursor, and a one-line Find function adapts a
+// collection.
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/internal/firsterr"
+)
+
+// Cursor iterates the documents found by a query; *mongo.Cursor implements
+// it.
+type Cursor interface {
+	Next(ctx context.Context) bool
+	Decode(v any) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// FindFunc runs a find query with filter on the collection holding the
+// entities.
+type FindFunc func(ctx context.Context, filter map[string]any) (Cursor, error)
+
+// ErrNotPartitioned is returned by partition searches of a repository with
+// no partition field.
+var ErrNotPartitioned = errors.New("mongorepo: repository has no partition field")
+
+// Default field names, used for the Repository fields left empty.
+const (
+	DEFAULT_CELLS_FIELD = "geocells"
+	DEFAULT_KEY_FIELD   = "_id"
+	DEFAULT_LAT_FIELD   = "lat"
+	DEFAULT_LON_FIELD   = "lon"
+)
+
+// Repository searches a collection for the documents in given cells. Its
+// fields are read-only once searches start.
+type Repository struct {
+	Find FindFunc
+	// Field names of the geocell array, key, latitude and longitude, each
+	// defaulting to the matching DEFAULT_ constant.
+	CellsField string
+	KeyField   string
+	LatField   string
+	LonField   string
+	// PartitionField is the field holding the partition of each document,
+	// such as a tenant id, for SearchPartition. It is optional.
+	PartitionField string
+	// Resolution is the finest resolution stored by WithGeocells. It
+	// defaults to geomodel.MAX_GEOCELL_RESOLUTION.
+	Resolution int
+	// Decode reads the current document of a cursor as an entity. If nil,
+	// documents are read into *geomodel.IndexRecord values from the key,
+	// latitude, longitude and geocell fields; non-string keys such as
+	// ObjectIDs are formatted with fmt.Sprint.
+	Decode func(cursor Cursor) (geomodel.LocationCapable, error)
+}
+
+// Filter returns the query filter matching documents with any of cells.
+func (r *Repository) Filter(cells []string) map[string]any {
+	return map[string]any{r.cellsField(): map[string]any{"$in": cells}}
+}
+
+// IndexKeys returns the keys of the recommended index, an ascending multikey
+// index on the geocell array.
+func (r *Repository) IndexKeys() map[string]any {
+	return map[string]any{r.cellsField(): 1}
+}
+
+// WithGeocells sets the geocell array and location fields of doc for an
+// entity at (lat, lon) and returns doc, for use on insert and whenever the
+// entity moves.
+func (r *Repository) WithGeocells(doc map[string]any, lat, lon float64) map[string]any {
+	var resolution int = r.Resolution
+	if resolution <= 0 {
+		resolution = geomodel.MAX_GEOCELL_RESOLUTION
+	}
+	doc[r.cellsField()] = geomodel.GeoCells(lat, lon, resolution)
+	doc[orDefault(r.LatField, DEFAULT_LAT_FIELD)] = lat
+	doc[orDefault(r.LonField, DEFAULT_LON_FIELD)] = lon
+	return doc
+}
+
+// Search returns the documents having any of cells, each once.
+func (r *Repository) Search(ctx context.Context, cells []string) ([]geomodel.LocationCapable, error) {
+	return r.SearchWithFilter(ctx, cells, nil)
+}
+
+// SearchPartition returns the documents of partition having any of cells,
+// each once, with a condition on the partition field in the query. It
+// returns ErrNotPartitioned if the repository has no partition field.
+func (r *Repository) SearchPartition(ctx context.Context, partition string, cells []string) ([]geomodel.LocationCapable, error) {
+	if r.PartitionField == "" {
+		return nil, ErrNotPartitioned
+	}
+	return r.SearchWithFilter(ctx, cells, map[string]any{r.PartitionField: partition})
+}
+
+// SearchWithFilter returns the documents having any of cells and matching
+// filter, a query filter on other fields, each once. A nil or empty filter
+// matches every document.
+func (r *Repository) SearchWithFilter(ctx context.Context, cells []string, filter map[string]any) ([]geomodel.LocationCapable, error) {
+	if len(cells) == 0 {
+		return nil, nil
+	}
+	var query map[string]any = r.Filter(cells)
+	if len(filter) > 0 {
+		// Combined with $and, so that filter cannot replace the condition
+		// on the cells.
+		query = map[string]any{"$and": []any{query, filter}}
+	}
+	cursor, err := r.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []geomodel.LocationCapable
+	var seen map[string]struct{} = make(map[string]struct{})
+	for cursor.Next(ctx) {
+		entity, err := r.decode(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[entity.Key()]; !ok {
+			seen[entity.Key()] = struct{}{}
+			results = append(results, entity)
+		}
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *Repository) decode(cursor Cursor) (geomodel.LocationCapable, error) {
+	if r.Decode != nil {
+		return r.Decode(cursor)
+	}
+	var doc map[string]any
+	if err := cursor.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var record *geomodel.IndexRecord = &geomodel.IndexRecord{}
+	var ok bool
+	var key any = doc[orDefault(r.KeyField, DEFAULT_KEY_FIELD)]
+	if record.ID, ok = key.(string); !ok {
+		record.ID = fmt.Sprint(key)
+	}
+	if record.Lat, ok = number(doc[orDefault(r.LatField, DEFAULT_LAT_FIELD)]); !ok {
+		return nil, fmt.Errorf("mongorepo: document %s has no numeric %s field", record.ID, orDefault(r.LatField, DEFAULT_LAT_FIELD))
+	}
+	if record.Lon, ok = number(doc[orDefault(r.LonField, DEFAULT_LON_FIELD)]); !ok {
+		return nil, fmt.Errorf("mongorepo: document %s has no numeric %s field", record.ID, orDefault(r.LonField, DEFAULT_LON_FIELD))
+	}
+	// The driver decodes arrays as its own named slice type, primitive.A,
+	// so any slice of strings is accepted.
+	if cells := reflect.ValueOf(doc[r.cellsField()]); cells.Kind() == reflect.Slice || cells.Kind() == reflect.Array {
+		for i := 0; i < cells.Len(); i++ {
+			if s, ok := cells.Index(i).Interface().(string); ok {
+				record.Cells = append(record.Cells, s)
+			}
+		}
+	}
+	return record, nil
+}
+
+// number converts the BSON numeric types the driver decodes to float64.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func (r *Repository) cellsField() string {
+	return orDefault(r.CellsField, DEFAULT_CELLS_FIELD)
+}
+
+func orDefault(field, def string) string {
+	if field == "" {
+		return def
+	}
+	return field
+}
+
+// Query binds a context to the repository for use as a
+// geomodel.RepositorySearch, which cannot return errors: the first error is
+// kept for Err and makes later searches return no entities.
+type Query struct {
+	repo  *Repository
+	ctx   context.Context
+	first firsterr.Keeper
+}
+
+// Query returns a Query running searches with ctx.
+func (r *Repository) Query(ctx context.Context) *Query {
+	return &Query{repo: r, ctx: ctx}
+}
+
+// Search implements geomodel.RepositorySearch. It is safe for concurrent
+// use, as with geomodel.WithParallelism.
+func (q *Query) Search(cells []string) []geomodel.LocationCapable {
+	return q.SearchWithFilter(cells, nil)
+}
+
+// SearchWithFilter implements geomodel.FilterSearcher, taking filter as a
+// query filter on other fields as Repository.SearchWithFilter does.
+func (q *Query) SearchWithFilter(cells []string, filter map[string]any) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.SearchWithFilter(q.ctx, cells, filter)
+	})
+}
+
+// SearchPartition implements geomodel.PartitionSearch, searching as
+// Repository.SearchPartition does.
+func (q *Query) SearchPartition(partition string, cells []string) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.SearchPartition(q.ctx, partition, cells)
+	})
+}
+
+// Err returns the first error of the searches run so far.
+func (q *Query) Err() error {
+	return q.first.Err()
+}
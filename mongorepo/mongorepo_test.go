@@ -0,0 +1,212 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/adaptertest"
+)
+
+// bsonA mirrors primitive.A in go.mongodb.org/mongo-driver/bson/primitive,
+// the named slice type the driver decodes arrays into.
+type bsonA []any
+
+// fakeCursor iterates documents the way the driver decodes them into maps,
+// with arrays as bsonA.
+type fakeCursor struct {
+	docs []map[string]any
+	cur  map[string]any
+}
+
+func (c *fakeCursor) Next(context.Context) bool {
+	if len(c.docs) == 0 {
+		return false
+	}
+	c.cur, c.docs = c.docs[0], c.docs[1:]
+	return true
+}
+
+func (c *fakeCursor) Decode(v any) error {
+	*v.(*map[string]any) = c.cur
+	return nil
+}
+
+func (c *fakeCursor) Err() error                  { return nil }
+func (c *fakeCursor) Close(context.Context) error { return nil }
+
+// fakeCollection evaluates $in filters over stored documents.
+func fakeCollection(docs []map[string]any) FindFunc {
+	var c = &memCollection{docs: make(map[any]map[string]any)}
+	for _, doc := range docs {
+		c.docs[doc["_id"]] = doc
+	}
+	return c.find
+}
+
+// memCollection holds documents by _id and evaluates $in filters over them.
+type memCollection struct {
+	mu   sync.Mutex
+	docs map[any]map[string]any
+}
+
+func (c *memCollection) find(ctx context.Context, filter map[string]any) (Cursor, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var field string
+	var cells []string
+	for f, cond := range filter {
+		field, cells = f, cond.(map[string]any)["$in"].([]string)
+	}
+	var found []map[string]any
+	for _, doc := range c.docs {
+	match:
+		for _, have := range doc[field].(bsonA) {
+			for _, cell := range cells {
+				if have == cell {
+					found = append(found, doc)
+					break match
+				}
+			}
+		}
+	}
+	return &fakeCursor{docs: found}, nil
+}
+
+// collectionIndex writes entities into a memCollection as the documents of
+// Repository.WithGeocells.
+type collectionIndex struct {
+	c    *memCollection
+	repo *Repository
+}
+
+func (x *collectionIndex) Put(entity geomodel.LocationCapable) error {
+	var doc = x.repo.WithGeocells(map[string]any{"_id": entity.Key()}, entity.Latitude(), entity.Longitude())
+	var cells bsonA
+	for _, cell := range doc["geocells"].([]string) {
+		cells = append(cells, cell)
+	}
+	doc["geocells"] = cells
+	x.c.mu.Lock()
+	defer x.c.mu.Unlock()
+	x.c.docs[entity.Key()] = doc
+	return nil
+}
+
+func (x *collectionIndex) Delete(key string) error {
+	x.c.mu.Lock()
+	defer x.c.mu.Unlock()
+	delete(x.c.docs, key)
+	return nil
+}
+
+func (x *collectionIndex) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	return x.repo.Search(context.Background(), cells)
+}
+
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func() adaptertest.Index {
+		var c = &memCollection{docs: make(map[any]map[string]any)}
+		return &collectionIndex{c, &Repository{Find: c.find}}
+	})
+}
+
+func TestRepository(t *testing.T) {
+	var repo = &Repository{Resolution: 8}
+	var docs []map[string]any
+	for i, p := range []geomodel.Point{{Lat: 50, Lon: 8}, {Lat: 50.001, Lon: 8.001}, {Lat: -33.87, Lon: 151.21}} {
+		var doc = repo.WithGeocells(map[string]any{"_id": i}, p.Lat, p.Lon)
+		// Stored arrays come back from the driver as primitive.A.
+		var cells bsonA
+		for _, cell := range doc["geocells"].([]string) {
+			cells = append(cells, cell)
+		}
+		doc["geocells"] = cells
+		docs = append(docs, doc)
+	}
+	repo.Find = fakeCollection(docs)
+
+	if want := map[string]any{"geocells": 1}; !reflect.DeepEqual(repo.IndexKeys(), want) {
+		t.Errorf("IndexKeys() = %v, want %v", repo.IndexKeys(), want)
+	}
+
+	var query = repo.Query(context.Background())
+	var results = geomodel.ProximityFetch(50, 8, 5, 1000, query.Search, 8)
+	if err := query.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Key() != "0" || results[1].Key() != "1" {
+		t.Errorf("ProximityFetch returned %v, want documents 0 and 1", results)
+	}
+	if cells := results[0].Geocells(); len(cells) != 8 || cells[7] != geomodel.GeoCell(50, 8, 8) {
+		t.Errorf("decoded geocells %v", cells)
+	}
+}
+
+func TestQueryErr(t *testing.T) {
+	var failure = errors.New("server selection timeout")
+	var repo = &Repository{Find: func(context.Context, map[string]any) (Cursor, error) { return nil, failure }}
+	var query = repo.Query(context.Background())
+	geomodel.ProximityFetch(50, 8, 5, 1000, query.Search, 8)
+	if err := query.Err(); !errors.Is(err, failure) {
+		t.Errorf("Err() = %v, want %v", err, failure)
+	}
+}
+
+func TestQuerySearchWithFilter(t *testing.T) {
+	var filters []map[string]any
+	var repo = &Repository{Find: func(_ context.Context, filter map[string]any) (Cursor, error) {
+		filters = append(filters, filter)
+		return &fakeCursor{}, nil
+	}}
+	var query = repo.Query(context.Background())
+	var _ geomodel.FilterSearcher = query
+
+	var category = map[string]any{"category": "restaurant"}
+	geomodel.Filtered(query, category)([]string{"u0"})
+	query.Search([]string{"u0"})
+
+	var want = []map[string]any{
+		{"$and": []any{repo.Filter([]string{"u0"}), category}},
+		repo.Filter([]string{"u0"}),
+	}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("queries %v, want %v", filters, want)
+	}
+}
+
+func TestQuerySearchPartition(t *testing.T) {
+	var filters []map[string]any
+	var repo = &Repository{PartitionField: "tenant", Find: func(_ context.Context, filter map[string]any) (Cursor, error) {
+		filters = append(filters, filter)
+		return &fakeCursor{}, nil
+	}}
+	var search, _ = geomodel.PartitionSearch(repo.Query(context.Background()).SearchPartition).In("acme")
+	search([]string{"u0"})
+	var want = []map[string]any{{"$and": []any{repo.Filter([]string{"u0"}), map[string]any{"tenant": "acme"}}}}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("queries %v, want %v", filters, want)
+	}
+
+	var query = (&Repository{}).Query(context.Background())
+	query.SearchPartition("acme", []string{"u0"})
+	if err := query.Err(); !errors.Is(err, ErrNotPartitioned) {
+		t.Errorf("Err() = %v, want ErrNotPartitioned", err)
+	}
+}
+
+func TestDecodeDriverTypes(t *testing.T) {
+	// Whole-degree coordinates stored from integers come back as int32.
+	var repo = &Repository{}
+	var cursor = &fakeCursor{cur: map[string]any{"_id": int64(7), "lat": int32(50), "lon": int64(8), "geocells": bsonA{"a", "ab"}}}
+	var entity, err = repo.decode(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.Key() != "7" || entity.Latitude() != 50 || entity.Longitude() != 8 || !reflect.DeepEqual(entity.Geocells(), []string{"a", "ab"}) {
+		t.Errorf("decode() = %+v", entity)
+	}
+}
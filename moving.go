@@ -0,0 +1,161 @@
+package geomodel
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// DEFAULT_MAX_SPEED is the speed limit of a MovingIndex created with a
+	// non-positive maxSpeed, in meters per second: about 250 km/h.
+	DEFAULT_MAX_SPEED = 70.0
+	// DEFAULT_MAX_REPORT_AGE is the report age limit of a MovingIndex
+	// created with a non-positive maxAge.
+	DEFAULT_MAX_REPORT_AGE = 5 * time.Minute
+)
+
+// MovingObject is the last report of a moving object: where it was, when,
+// and how it was moving then.
+type MovingObject struct {
+	ID       string
+	Position Point
+	// Speed is the ground speed in meters per second.
+	Speed float64
+	// Heading is the direction of travel in degrees clockwise from north.
+	Heading float64
+	// Time is when the object was at Position.
+	Time time.Time
+}
+
+// Predict returns where the object is at the given time by dead-reckoning:
+// travelling from Position at Speed along the great circle starting at
+// Heading. Times before the report reckon backwards.
+func (o MovingObject) Predict(at time.Time) Point {
+	return Destination(o.Position, o.Heading, o.Speed*at.Sub(o.Time).Seconds())
+}
+
+// Prediction is a moving object at its predicted position, as returned by
+// MovingIndex.PredictNearby. It has no geocells.
+type Prediction struct {
+	MovingObject
+	Predicted Point
+}
+
+func (p *Prediction) Latitude() float64  { return p.Predicted.Lat }
+func (p *Prediction) Longitude() float64 { return p.Predicted.Lon }
+func (p *Prediction) Key() string        { return p.ID }
+func (p *Prediction) Geocells() []string { return nil }
+
+// movingEntry is a MovingObject stored in a GeoIndex at its reported
+// position.
+type movingEntry struct {
+	object   MovingObject
+	geocells []string
+}
+
+func (e *movingEntry) Latitude() float64  { return e.object.Position.Lat }
+func (e *movingEntry) Longitude() float64 { return e.object.Position.Lon }
+func (e *movingEntry) Key() string        { return e.object.ID }
+func (e *movingEntry) Geocells() []string { return e.geocells }
+
+// MovingIndex is an in-memory index of moving objects, for searching where
+// objects will be rather than where they were last reported:
+//
+//	var fleet = geomodel.NewMovingIndex(40, 2*time.Minute)
+//	fleet.Update(geomodel.MovingObject{ID: "car-7", Position: p, Speed: 12, Heading: 90, Time: reported})
+//	var nearby = fleet.PredictNearby(lat, lon, time.Now().Add(time.Minute), 5, 3000)
+//
+// Objects are indexed by their reported positions. Speeds are capped and
+// reports too far from the predicted time are ignored, which bounds how far
+// any object can have moved and so the cells searched around a query.
+// A MovingIndex is safe for concurrent use. The zero value is not usable;
+// create indexes with NewMovingIndex.
+type MovingIndex struct {
+	index    *GeoIndex
+	maxSpeed float64
+	maxAge   time.Duration
+}
+
+// NewMovingIndex returns an empty index whose objects travel at most
+// maxSpeed meters per second, and whose reports are used for predictions at
+// most maxAge before or after them. Non-positive limits take
+// DEFAULT_MAX_SPEED and DEFAULT_MAX_REPORT_AGE.
+func NewMovingIndex(maxSpeed float64, maxAge time.Duration) *MovingIndex {
+	if maxSpeed <= 0 {
+		maxSpeed = DEFAULT_MAX_SPEED
+	}
+	if maxAge <= 0 {
+		maxAge = DEFAULT_MAX_REPORT_AGE
+	}
+	return &MovingIndex{index: NewGeoIndex(), maxSpeed: maxSpeed, maxAge: maxAge}
+}
+
+// Update stores the report o, replacing any earlier report of the same
+// object. Speeds above the index's limit are capped to it, and negative
+// speeds count as travelling the opposite way.
+func (x *MovingIndex) Update(o MovingObject) {
+	if o.Speed < 0 {
+		o.Speed, o.Heading = -o.Speed, o.Heading+180
+	}
+	o.Speed = math.Min(o.Speed, x.maxSpeed)
+	x.index.Insert(&movingEntry{object: o, geocells: GeoCells(o.Position.Lat, o.Position.Lon, MAX_GEOCELL_RESOLUTION)})
+}
+
+// Get returns the last report of the object with the given id.
+func (x *MovingIndex) Get(id string) (MovingObject, bool) {
+	var entity, ok = x.index.Get(id)
+	if !ok {
+		return MovingObject{}, false
+	}
+	return entity.(*movingEntry).object, true
+}
+
+// Remove deletes the object with the given id and reports whether there was
+// one.
+func (x *MovingIndex) Remove(id string) bool {
+	return x.index.Remove(id)
+}
+
+// Len returns the number of objects in the index.
+func (x *MovingIndex) Len() int {
+	return x.index.Len()
+}
+
+// PredictNearby returns up to maxResults objects whose predicted positions
+// at the given time are within maxDistance meters of (lat, lon), nearest
+// first, like ProximityFetchResults. Each entity is a *Prediction. Objects
+// whose last report is more than the index's age limit away from at are
+// left out.
+func (x *MovingIndex) PredictNearby(lat, lon float64, at time.Time, maxResults int, maxDistance float64, opts ...Option) []SearchResult {
+	return ProximityFetchResults(lat, lon, maxResults, maxDistance, x.predictedSearch(at, opts), MAX_GEOCELL_RESOLUTION, opts...)
+}
+
+// predictedSearch returns a search for the objects predicted at the given
+// time to be in the searched cells. Objects reported within reach of the
+// cells are dead-reckoned; a few predicted outside them may be returned too.
+func (x *MovingIndex) predictedSearch(at time.Time, opts []Option) RepositorySearch {
+	var options = newSearchOptions(opts)
+	var reach float64 = x.maxSpeed * x.maxAge.Seconds()
+	return func(cells []string) []LocationCapable {
+		if len(cells) == 0 {
+			return nil
+		}
+		var box BoundingBox = options.box(cells[0])
+		for _, cell := range cells[1:] {
+			box = box.Union(options.box(cell))
+		}
+
+		var results []LocationCapable
+		for _, entity := range x.index.Search(CoverBoundingBox(box.Expand(reach), MAX_GEOCELL_RESOLUTION)) {
+			var object MovingObject = entity.(*movingEntry).object
+			if age := at.Sub(object.Time); age > x.maxAge || age < -x.maxAge {
+				continue
+			}
+			var predicted Point = object.Predict(at)
+			if box.Contains(predicted.Lat, predicted.Lon) {
+				results = append(results, &Prediction{MovingObject: object, Predicted: predicted})
+			}
+		}
+		return results
+	}
+}
@@ -0,0 +1,67 @@
+package geomodel
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMovingObjectPredict(t *testing.T) {
+	var reported = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var o = MovingObject{ID: "car", Position: Point{0, 0}, Speed: 10, Heading: 90, Time: reported}
+
+	var p = o.Predict(reported.Add(100 * time.Second))
+	if d := DistanceHaversine(0, 0, p.Lat, p.Lon); math.Abs(d-1000) > 1e-6 || p.Lon <= 0 {
+		t.Errorf("Predict 100s ahead = %v, %.3fm away, want 1000m east", p, d)
+	}
+	if p := o.Predict(reported.Add(-100 * time.Second)); p.Lon >= 0 {
+		t.Errorf("Predict 100s before = %v, want west of the report", p)
+	}
+	if p := o.Predict(reported); p != o.Position {
+		t.Errorf("Predict at the report time = %v, want %v", p, o.Position)
+	}
+}
+
+func TestMovingIndexPredictNearby(t *testing.T) {
+	var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var pickup = Point{52.52, 13.405}
+	var fleet = NewMovingIndex(30, 2*time.Minute)
+
+	// Two kilometers west heading east at 20 m/s, it reaches the pickup in
+	// 100 seconds.
+	fleet.Update(MovingObject{ID: "approaching", Position: Destination(pickup, 270, 2000), Speed: 20, Heading: 90, Time: now})
+	// Beside the pickup now, but driving away.
+	fleet.Update(MovingObject{ID: "leaving", Position: Destination(pickup, 0, 200), Speed: 20, Heading: 0, Time: now})
+	// Parked nearby, but its report is too old to trust.
+	fleet.Update(MovingObject{ID: "stale", Position: pickup, Time: now.Add(-time.Hour)})
+
+	if results := fleet.PredictNearby(pickup.Lat, pickup.Lon, now, 10, 500); len(results) != 1 || results[0].Entity.Key() != "leaving" {
+		t.Errorf("PredictNearby now = %v, want leaving only", results)
+	}
+
+	var results = fleet.PredictNearby(pickup.Lat, pickup.Lon, now.Add(100*time.Second), 10, 500)
+	if len(results) != 1 || results[0].Entity.Key() != "approaching" {
+		t.Fatalf("PredictNearby in 100s = %v, want approaching only", results)
+	}
+	var prediction = results[0].Entity.(*Prediction)
+	if results[0].Distance > 1 || prediction.Time != now {
+		t.Errorf("prediction = %+v at %.3fm, want the report at the pickup", prediction, results[0].Distance)
+	}
+}
+
+func TestMovingIndexCapsSpeed(t *testing.T) {
+	var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var fleet = NewMovingIndex(10, time.Minute)
+	fleet.Update(MovingObject{ID: "fast", Position: Point{10, 10}, Speed: 100, Heading: 90, Time: now})
+	fleet.Update(MovingObject{ID: "reversing", Position: Point{10, 10}, Speed: -5, Heading: 0, Time: now})
+
+	if o, _ := fleet.Get("fast"); o.Speed != 10 {
+		t.Errorf("stored speed = %v, want the 10 m/s limit", o.Speed)
+	}
+	if o, _ := fleet.Get("reversing"); o.Speed != 5 || o.Heading != 180 {
+		t.Errorf("negative speed stored as %v at %v, want 5 at 180", o.Speed, o.Heading)
+	}
+	if !fleet.Remove("fast") || fleet.Len() != 1 {
+		t.Errorf("Remove left %d objects, want 1", fleet.Len())
+	}
+}
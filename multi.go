@@ -0,0 +1,95 @@
+package geomodel
+
+import (
+	"math"
+	"slices"
+	"sync"
+)
+
+// ProximityFetchMulti returns up to maxResults entities nearest to any of
+// origins, within maxDistance meters of at least one of them. Each result's
+// distance is to its closest origin.
+//
+// One search runs per origin, but cells already searched for an earlier
+// origin are answered from the entities found then instead of querying the
+// repository again. Entities are attributed to cells through their
+// Geocells, which must therefore be populated. The searches share their
+// options: a stats hook sees a single search adding up all of them, and a
+// trace records the steps of every origin under the first.
+func ProximityFetchMulti(origins []Point, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) []SearchResult {
+	var buf *searchBuffers = new(searchBuffers)
+	var options *searchOptions = &buf.options
+	options.reset(opts)
+	defer options.finish()
+	if options.trace != nil && len(origins) > 0 {
+		options.trace.start(origins[0], maxResults, maxDistance, options)
+	}
+	if maxDistance <= Unlimited {
+		maxDistance = math.Inf(1)
+	}
+
+	var mu sync.Mutex
+	var byCell map[string][]LocationCapable = make(map[string][]LocationCapable)
+	var shared RepositorySearch = func(cells []string) []LocationCapable {
+		// The repository is called without mu held, so that the batches of
+		// WithParallelism run at once. They are batches of one search, which
+		// never share a cell, so no batch reads a cell another has claimed
+		// but not filled yet.
+		mu.Lock()
+		var found []LocationCapable
+		var unsearched []string
+		for _, cell := range cells {
+			if entities, ok := byCell[cell]; ok {
+				found = append(found, entities...)
+			} else {
+				byCell[cell] = nil
+				unsearched = append(unsearched, cell)
+			}
+		}
+		mu.Unlock()
+		if len(unsearched) == 0 {
+			return found
+		}
+
+		var fresh []LocationCapable = search(unsearched)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, entity := range fresh {
+			for _, cell := range entity.Geocells() {
+				if entities, ok := byCell[cell]; ok {
+					byCell[cell] = append(entities, entity)
+				}
+			}
+		}
+		return append(found, fresh...)
+	}
+
+	var candidates []SearchResult
+	for _, origin := range origins {
+		candidates = proximitySearch(candidates, buf, options, origin.Lat, origin.Lon, maxResults, maxDistance, shared, maxResolution)
+	}
+
+	// Keep the maxResults candidates nearest to any origin, each once.
+	var nearest *topK = newTopK(max(maxResults, 0))
+	defer nearest.release()
+	nearest.tiebreaker = options.tiebreaker
+	for _, r := range candidates {
+		var d float64 = math.Inf(1)
+		for _, origin := range origins {
+			d = math.Min(d, options.entityDistance(origin.Lat, origin.Lon, r.Entity))
+		}
+		if d <= maxDistance {
+			nearest.offer(LocationComparableTuple{r.Entity, d})
+		}
+	}
+
+	var results []SearchResult = make([]SearchResult, 0, nearest.Len())
+	for _, entry := range nearest.sorted() {
+		results = append(results, SearchResult{entry.first, entry.second})
+	}
+	sortResults(results, options)
+	if options.trace != nil {
+		options.trace.Results = slices.Clone(results)
+	}
+	return results
+}
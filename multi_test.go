@@ -0,0 +1,97 @@
+package geomodel
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProximityFetchMulti(t *testing.T) {
+	var places = []LocationCapable{
+		Place{50, 8, "home", GeoCells(50, 8, 10)},
+		Place{50.0005, 8, "near-home", GeoCells(50.0005, 8, 10)},
+		Place{52, 13, "work", GeoCells(52, 13, 10)},
+		Place{51, 10.5, "between", GeoCells(51, 10.5, 10)},
+	}
+
+	var calls map[string]int = make(map[string]int)
+	var search = searchPlaces(places)
+	var counting RepositorySearch = func(cells []string) []LocationCapable {
+		for _, cell := range cells {
+			calls[cell]++
+		}
+		return search(cells)
+	}
+
+	var results = ProximityFetchMulti([]Point{{50, 8}, {52, 13}, {50.0001, 8}}, 3, 10000, counting, 10)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	var keys = map[string]bool{}
+	for _, r := range results {
+		keys[r.Entity.Key()] = true
+	}
+	if !keys["home"] || !keys["near-home"] || !keys["work"] {
+		t.Errorf("got %v, want home, near-home and work", results)
+	}
+	for cell, n := range calls {
+		if n > 1 {
+			t.Errorf("cell %q searched %d times", cell, n)
+		}
+	}
+}
+
+func TestProximityFetchMultiOptions(t *testing.T) {
+	var places = []LocationCapable{
+		Place{50, 8, "a", GeoCells(50, 8, 10)},
+		Place{50.001, 8, "b", GeoCells(50.001, 8, 10)},
+		Place{52, 13, "c", GeoCells(52, 13, 10)},
+	}
+	var hooks []SearchStats
+	var results = ProximityFetchMulti([]Point{{50, 8}, {52, 13}}, 3, 10000, searchPlaces(places), 10,
+		WithResultOrder(Descending), WithStatsHook(func(s SearchStats) { hooks = append(hooks, s) }))
+
+	// c is at distance 0 from the second origin, like a from the first.
+	var keys []string
+	for _, r := range results {
+		keys = append(keys, r.Entity.Key())
+	}
+	if len(keys) != 3 || keys[0] != "b" {
+		t.Errorf("descending results %v, want b first", keys)
+	}
+	if len(hooks) != 1 || hooks[0].RepositoryCalls == 0 {
+		t.Errorf("stats hook called %d times with %+v, want once for the whole search", len(hooks), hooks)
+	}
+}
+
+func TestProximityFetchMultiParallelism(t *testing.T) {
+	var places = []LocationCapable{
+		Place{50, 8, "a", GeoCells(50, 8, 10)},
+		Place{50.001, 8.001, "b", GeoCells(50.001, 8.001, 10)},
+		Place{52, 13, "c", GeoCells(52, 13, 10)},
+	}
+	var origins = []Point{{50, 8}, {52, 13}}
+	var trace SearchTrace
+	ProximityFetchMulti(origins, 3, 10000, searchPlaces(places), 10, WithTrace(&trace))
+	var step = slices.IndexFunc(trace.Steps, func(s TraceStep) bool { return len(s.Searched) >= 2 })
+	if step < 0 {
+		t.Fatal("no step searched several cells")
+	}
+
+	// The repository is called without the cache locked, so that the
+	// batches of a step run at once.
+	var held = rendezvous(t, searchPlaces(places), 2, trace.Steps[step].Searched)
+	if got := ProximityFetchMulti(origins, 3, 10000, held, 10, WithParallelism(2), WithMaxCellsPerQuery(1)); len(got) != 3 {
+		t.Errorf("got %d results, want 3", len(got))
+	}
+}
+
+func TestProximityFetchMultiAltitude(t *testing.T) {
+	var places = []LocationCapable{
+		floor{Place{50, 8, "ground", GeoCells(50, 8, 10)}, 0},
+		floor{Place{50, 8, "roof", GeoCells(50, 8, 10)}, 30},
+	}
+	var results = ProximityFetchMulti([]Point{{50, 8}, {52, 13}}, 2, 0, searchPlaces(places), 10, WithAltitude(30, 1))
+	if len(results) != 2 || results[0].Entity.Key() != "roof" || results[1].Distance != 30 {
+		t.Errorf("results %v, want roof then ground at 30 m", results)
+	}
+}
@@ -0,0 +1,171 @@
+// Package mvt renders per-cell counts as Mapbox Vector Tiles, so dashboards
+// can draw density layers of indexed entities straight from geocell
+// aggregates:
+//
+//	http.HandleFunc("/tiles/{z}/{x}/{y}", func(w http.ResponseWriter, r *http.Request) {
+//		...
+//		w.Header().Set("Content-Type", mvt.CONTENT_TYPE)
+//		w.Write(mvt.DensityTile(counts, z, x, y))
+//	})
+//
+// Each cell intersecting the requested tile becomes a polygon feature of the
+// layer LAYER_NAME with the properties "cell" and "count".
+package mvt
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/alternaDev/geomodel"
+)
+
+const (
+	// CONTENT_TYPE is the media type of Mapbox Vector Tiles.
+	CONTENT_TYPE = "application/vnd.mapbox-vector-tile"
+	// LAYER_NAME names the layer of density tiles.
+	LAYER_NAME = "density"
+	// EXTENT is the size of tiles in tile coordinates.
+	EXTENT = 4096
+	// BUFFER is how far, in tile coordinates, polygons extend past the tile
+	// edges before being clipped, so that renderers draw no seams.
+	BUFFER = 64
+)
+
+// maxMercatorLat is the latitude at which Web Mercator tiles end.
+var maxMercatorLat = 180 / math.Pi * math.Atan(math.Sinh(math.Pi))
+
+// Geometry commands and feature types of the vector tile specification.
+const (
+	cmdMoveTo    = 1
+	cmdLineTo    = 2
+	cmdClosePath = 7
+	typePolygon  = 3
+)
+
+// DensityTile returns the tile z/x/y, in the XYZ scheme of Web Mercator
+// tiles, drawing each cell of counts that intersects it. Cells are drawn in
+// lexicographic order, so that equal counts give equal tiles. A tile with no
+// cell is empty, which renderers accept.
+func DensityTile(counts map[string]int, z, x, y int) []byte {
+	var tileBox geomodel.BoundingBox = TileBox(z, x, y)
+	var cells []string = make([]string, 0, len(counts))
+	for cell := range counts {
+		if cell != "" && geomodel.ComputeBox(cell).Intersects(tileBox) {
+			cells = append(cells, cell)
+		}
+	}
+	sort.Strings(cells)
+
+	var n float64 = math.Exp2(float64(z))
+	var drawn []string
+	var features [][]byte
+	for _, cell := range cells {
+		var geometry []uint32 = cellGeometry(cell, n, x, y)
+		if geometry == nil {
+			continue
+		}
+		var i int = len(drawn)
+		var feature []byte
+		feature = appendUvarintField(feature, 1, uint64(i+1))
+		// Tags pair the keys "cell" and "count" with the feature's two
+		// values.
+		feature = appendPacked(feature, 2, []uint32{0, uint32(2 * i), 1, uint32(2*i + 1)})
+		feature = appendUvarintField(feature, 3, typePolygon)
+		feature = appendPacked(feature, 4, geometry)
+		drawn = append(drawn, cell)
+		features = append(features, feature)
+	}
+	if len(drawn) == 0 {
+		return nil
+	}
+
+	var layer []byte
+	layer = appendUvarintField(layer, 15, 2) // version
+	layer = appendStringField(layer, 1, LAYER_NAME)
+	for _, feature := range features {
+		layer = appendBytesField(layer, 2, feature)
+	}
+	layer = appendStringField(layer, 3, "cell")
+	layer = appendStringField(layer, 3, "count")
+	for _, cell := range drawn {
+		layer = appendBytesField(layer, 4, appendStringField(nil, 1, cell))
+		var count int = max(counts[cell], 0)
+		layer = appendBytesField(layer, 4, appendUvarintField(nil, 5, uint64(count)))
+	}
+	layer = appendUvarintField(layer, 5, EXTENT)
+
+	return appendBytesField(nil, 3, layer)
+}
+
+// TileBox returns the bounding box of tile z/x/y.
+func TileBox(z, x, y int) geomodel.BoundingBox {
+	var n float64 = math.Exp2(float64(z))
+	var west float64 = float64(x)/n*360 - 180
+	var east float64 = float64(x+1)/n*360 - 180
+	var north float64 = 180 / math.Pi * math.Atan(math.Sinh(math.Pi*(1-2*float64(y)/n)))
+	var south float64 = 180 / math.Pi * math.Atan(math.Sinh(math.Pi*(1-2*float64(y+1)/n)))
+	return geomodel.NewBoundingBox(north, east, south, west)
+}
+
+// cellGeometry returns the encoded polygon of cell in the coordinates of
+// tile x/y of the n×n tiles of its zoom level, clipped to the tile and its
+// buffer, or nil if nothing of it remains. Cells are rectangles in Web
+// Mercator, so clamping their corners clips them exactly. The ring runs
+// clockwise on screen, as exterior rings must.
+func cellGeometry(cell string, n float64, x, y int) []uint32 {
+	var bbox geomodel.BoundingBox = geomodel.ComputeBox(cell)
+	var corner = func(lat, lon float64) (int32, int32) {
+		lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+		var px float64 = ((lon+180)/360*n - float64(x)) * EXTENT
+		var py float64 = ((1-math.Log(math.Tan(lat*math.Pi/180)+1/math.Cos(lat*math.Pi/180))/math.Pi)/2*n - float64(y)) * EXTENT
+		var clamp = func(v float64) int32 {
+			return int32(math.Round(math.Max(-BUFFER, math.Min(EXTENT+BUFFER, v))))
+		}
+		return clamp(px), clamp(py)
+	}
+
+	var west, north = corner(bbox.North(), bbox.West())
+	var east, south = corner(bbox.South(), bbox.East())
+	if east == west || south == north {
+		return nil
+	}
+
+	return []uint32{
+		command(cmdMoveTo, 1), zigzag(west), zigzag(north),
+		command(cmdLineTo, 3),
+		zigzag(east - west), 0,
+		0, zigzag(south - north),
+		zigzag(west - east), 0,
+		command(cmdClosePath, 1),
+	}
+}
+
+func command(id, count uint32) uint32 { return id&7 | count<<3 }
+
+func zigzag(v int32) uint32 { return uint32(v<<1) ^ uint32(v>>31) }
+
+func appendTag(buf []byte, field, wireType uint64) []byte {
+	return binary.AppendUvarint(buf, field<<3|wireType)
+}
+
+func appendUvarintField(buf []byte, field, v uint64) []byte {
+	return binary.AppendUvarint(appendTag(buf, field, 0), v)
+}
+
+func appendBytesField(buf []byte, field uint64, b []byte) []byte {
+	buf = binary.AppendUvarint(appendTag(buf, field, 2), uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendStringField(buf []byte, field uint64, s string) []byte {
+	return appendBytesField(buf, field, []byte(s))
+}
+
+func appendPacked(buf []byte, field uint64, values []uint32) []byte {
+	var packed []byte
+	for _, v := range values {
+		packed = binary.AppendUvarint(packed, uint64(v))
+	}
+	return appendBytesField(buf, field, packed)
+}
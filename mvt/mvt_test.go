@@ -0,0 +1,87 @@
+package mvt
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// fields decodes a protobuf message into its varint and length-delimited
+// fields by number, which is all vector tiles use.
+func fields(t *testing.T, data []byte) map[uint64][]interface{} {
+	var out = make(map[uint64][]interface{})
+	for len(data) > 0 {
+		var tag, n = binary.Uvarint(data)
+		data = data[n:]
+		switch tag & 7 {
+		case 0:
+			var v, n = binary.Uvarint(data)
+			data = data[n:]
+			out[tag>>3] = append(out[tag>>3], v)
+		case 2:
+			var l, n = binary.Uvarint(data)
+			out[tag>>3] = append(out[tag>>3], data[n:n+int(l)])
+			data = data[n+int(l):]
+		default:
+			t.Fatalf("unexpected wire type in tag %d", tag)
+		}
+	}
+	return out
+}
+
+func packed(data []byte) []uint64 {
+	var values []uint64
+	for len(data) > 0 {
+		var v, n = binary.Uvarint(data)
+		values = append(values, v)
+		data = data[n:]
+	}
+	return values
+}
+
+func TestDensityTile(t *testing.T) {
+	var counts = map[string]int{"s": 3, "u": 5, "6": 7}
+	var tile = fields(t, DensityTile(counts, 1, 1, 0))
+	if len(tile[3]) != 1 {
+		t.Fatalf("tile has %d layers, want 1", len(tile[3]))
+	}
+	var layer = fields(t, tile[3][0].([]byte))
+	if string(layer[1][0].([]byte)) != LAYER_NAME || layer[5][0] != uint64(EXTENT) || layer[15][0] != uint64(2) {
+		t.Errorf("unexpected layer header %v", layer)
+	}
+	if len(layer[2]) != 2 || len(layer[4]) != 4 {
+		t.Fatalf("layer has %d features and %d values, want 2 and 4", len(layer[2]), len(layer[4]))
+	}
+
+	var first = fields(t, layer[2][0].([]byte))
+	if first[3][0] != uint64(typePolygon) || !reflect.DeepEqual(packed(first[2][0].([]byte)), []uint64{0, 0, 1, 1}) {
+		t.Errorf("unexpected feature %v", first)
+	}
+	var value = fields(t, layer[4][1].([]byte))
+	if value[5][0] != uint64(3) {
+		t.Errorf("count of %q is %v, want 3", "s", value)
+	}
+}
+
+func TestCellGeometry(t *testing.T) {
+	// Cell "s" spans longitudes 0 to 45 and latitudes 0 to 45, which in the
+	// single tile of zoom 0 is x 2048 to 2560 and y 1473 to 2048.
+	var want = []uint32{
+		command(cmdMoveTo, 1), zigzag(2048), zigzag(1473),
+		command(cmdLineTo, 3), zigzag(512), 0, 0, zigzag(575), zigzag(-512), 0,
+		command(cmdClosePath, 1),
+	}
+	if got := cellGeometry("s", 1, 0, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("cellGeometry(%q) = %v, want %v", "s", got, want)
+	}
+
+	// In a deep tile, the cell is clipped to the tile and its buffer.
+	var clipped = cellGeometry("s", 1024, 600, 400)
+	if clipped[1] != zigzag(-BUFFER) || clipped[4] != zigzag(EXTENT+2*BUFFER) {
+		t.Errorf("clipped geometry %v", clipped)
+	}
+
+	if tile := DensityTile(map[string]int{"6": 1}, 1, 1, 0); tile != nil {
+		t.Errorf("tile without cells has %d bytes", len(tile))
+	}
+}
@@ -0,0 +1,142 @@
+package geomodel
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrNoResults is returned when a search finds no entity at all.
+	ErrNoResults = errors.New("geomodel: no results")
+	// ErrSearchLimit is returned when a search stops at a limit set with
+	// WithMaxIterations or WithMaxCellsSearched before completing.
+	ErrSearchLimit = errors.New("geomodel: search limit reached")
+)
+
+// Nearest returns the entity closest to (lat, lon) and its distance in
+// meters.
+//
+// Starting at the finest resolution, Nearest searches the cell containing
+// the point and then the eight cells around it, stopping as soon as the best
+// entity found is closer than the edge of the searched area, which proves no
+// unsearched entity can be closer. Otherwise it repeats one resolution
+// coarser, finally scanning every top-level cell. It returns ErrNoResults if
+// the repository holds no entity.
+//
+// WithStartResolution and WithMinResolution bound the resolutions searched.
+// A search stopped by the minimum resolution does not scan the top-level
+// cells and returns the closest entity found so far, which is then not
+// guaranteed to be the nearest. A search stopped by WithMaxIterations or
+// WithMaxCellsSearched returns the closest entity found so far, possibly nil,
+// with ErrSearchLimit.
+//
+// Entities are ranked by the distance of WithDistanceFunc and WithAltitude.
+// The edges of the searched area are still measured on the sphere, so a
+// distance function below Distance may end the search before the nearest
+// entity by its measure is found.
+func Nearest(lat, lon float64, search RepositorySearch, opts ...Option) (LocationCapable, float64, error) {
+	var options = newSearchOptions(opts)
+	defer options.finish()
+	var logger = options.logger
+
+	var best LocationCapable
+	var bestDistance float64 = math.Inf(1)
+	var searched map[string]struct{} = make(map[string]struct{})
+
+	var consider = func(cells []string) {
+		var unsearched []string = make([]string, 0, len(cells))
+		for _, cell := range cells {
+			if _, ok := searched[cell]; !ok {
+				searched[cell] = struct{}{}
+				unsearched = append(unsearched, cell)
+			}
+		}
+		if len(unsearched) == 0 || options.exhausted(len(unsearched)) {
+			return
+		}
+
+		if options.debug {
+			logger.Debug("geomodel: searching cells", "cells", unsearched)
+		}
+		for _, entity := range runSearch(search, unsearched, options) {
+			var d float64 = options.entityDistance(lat, lon, entity)
+			if d < bestDistance {
+				best, bestDistance = entity, d
+			}
+		}
+	}
+
+	var minResolution int = max(options.minResolution, 1)
+	for resolution := options.initialResolution(lat, lon, 1, MAX_GEOCELL_RESOLUTION); resolution >= minResolution && !options.exhausted(0); resolution-- {
+		options.stats.Iterations++
+		var cell string = options.curve.Encode(lat, lon, resolution)
+		var bbox BoundingBox = options.box(cell)
+
+		consider([]string{cell})
+		if best != nil && bestDistance <= edgeDistance(lat, lon, bbox) {
+			return best, bestDistance, nil
+		}
+
+		var latSpan, lonSpan = options.curve.Span(resolution)
+		var centerLat float64 = (bbox.latSW + bbox.latNE) / 2
+		var centerLon float64 = (bbox.lonSW + bbox.lonNE) / 2
+		var neighbors []string = make([]string, 0, 8)
+		for dy := -1; dy <= 1; dy++ {
+			var neighborLat float64 = centerLat + float64(dy)*latSpan
+			if neighborLat < -90 || neighborLat > 90 {
+				continue
+			}
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					neighbors = append(neighbors, options.curve.Encode(neighborLat, wrapLon(centerLon+float64(dx)*lonSpan), resolution))
+				}
+			}
+		}
+		consider(neighbors)
+
+		var block BoundingBox = BoundingBox{bbox.latNE + latSpan, bbox.lonNE + lonSpan, bbox.latSW - latSpan, bbox.lonSW - lonSpan}
+		if best != nil && bestDistance <= edgeDistance(lat, lon, block) {
+			return best, bestDistance, nil
+		}
+		if options.debug {
+			logger.Debug("geomodel: nearest not proven, coarsening", "resolution", resolution-1)
+		}
+	}
+
+	if options.stats.LimitReached {
+		return best, bestDistance, ErrSearchLimit
+	}
+	if minResolution == 1 {
+		consider(cellsInBox(options.curve, NewBoundingBox(90, 180, -90, -180), 1))
+	}
+	if options.stats.LimitReached {
+		return best, bestDistance, ErrSearchLimit
+	}
+	if best == nil {
+		return nil, 0, ErrNoResults
+	}
+	return best, bestDistance, nil
+}
+
+// edgeDistance returns a lower bound on the distance in meters from
+// (lat, lon), inside bbox, to any point outside it. Edges beyond the poles or
+// a full turn of longitude do not bound the box.
+func edgeDistance(lat, lon float64, bbox BoundingBox) float64 {
+	var d float64 = math.Inf(1)
+	if bbox.latNE < 90 {
+		d = math.Min(d, DegToRad(bbox.latNE-lat)*EARTH_RADIUS)
+	}
+	if bbox.latSW > -90 {
+		d = math.Min(d, DegToRad(lat-bbox.latSW)*EARTH_RADIUS)
+	}
+	if bbox.lonNE-bbox.lonSW < 360 {
+		// Shortest great-circle distance from the point to each bounding
+		// meridian.
+		for _, dLon := range []float64{bbox.lonNE - lon, lon - bbox.lonSW} {
+			if dLon < 90 {
+				d = math.Min(d, EARTH_RADIUS*math.Asin(math.Cos(DegToRad(lat))*math.Sin(DegToRad(dLon))))
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,56 @@
+package geomodel
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestNearest(t *testing.T) {
+	var rng = rand.New(rand.NewSource(1))
+	var places []LocationCapable
+	for i := 0; i < 200; i++ {
+		var lat, lon = rng.Float64()*20 + 40, rng.Float64()*20 - 10
+		places = append(places, Place{lat, lon, fmt.Sprint(i), GeoCells(lat, lon, MAX_GEOCELL_RESOLUTION)})
+	}
+
+	for i := 0; i < 20; i++ {
+		var lat, lon = rng.Float64()*20 + 40, rng.Float64()*20 - 10
+
+		var want LocationCapable
+		var wantDistance float64
+		for _, p := range places {
+			if d := Distance(lat, lon, p.Latitude(), p.Longitude()); want == nil || d < wantDistance {
+				want, wantDistance = p, d
+			}
+		}
+
+		got, distance, err := Nearest(lat, lon, searchPlaces(places))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Key() != want.Key() || distance != wantDistance {
+			t.Errorf("Nearest(%v, %v) = %q at %v, want %q at %v", lat, lon, got.Key(), distance, want.Key(), wantDistance)
+		}
+	}
+
+	if _, _, err := Nearest(50, 8, searchPlaces(nil)); err != ErrNoResults {
+		t.Errorf("Nearest on an empty repository returned %v, want ErrNoResults", err)
+	}
+}
+
+func TestNearestDistanceOptions(t *testing.T) {
+	var places = []LocationCapable{
+		floor{Place{50, 8, "ground", GeoCells(50, 8, 10)}, 0},
+		floor{Place{50, 8, "roof", GeoCells(50, 8, 10)}, 30},
+	}
+	if found, d, err := Nearest(50, 8, searchPlaces(places), WithAltitude(30, 1)); err != nil || found.Key() != "roof" || d != 0 {
+		t.Errorf("Nearest at altitude 30 = %v at %v (err %v), want roof at 0", found, d, err)
+	}
+
+	var double DistanceFunc = func(lat1, lon1, lat2, lon2 float64) float64 { return 2 * Distance(lat1, lon1, lat2, lon2) }
+	var want = 2 * Distance(50.001, 8, 50, 8)
+	if _, d, err := Nearest(50.001, 8, searchPlaces(places), WithDistanceFunc(double)); err != nil || d != want {
+		t.Errorf("Nearest with a distance function at %v (err %v), want %v", d, err, want)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package geomodel
+
+const raceEnabled = false
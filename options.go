@@ -0,0 +1,179 @@
+package geomodel
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// Option configures a single search, such as a call to ProximityFetch.
+type Option func(*searchOptions)
+
+type searchOptions struct {
+	curve            Curve
+	distance         DistanceFunc
+	logger           *slog.Logger
+	maxCellsPerQuery int
+	parallelism      int
+	startResolution  int
+	minResolution    int
+	densityStats     DensityStats
+	order            ResultOrder
+	tiebreaker       func(a, b LocationCapable, da, db float64) bool
+	scorer           func(entity LocationCapable, distance float64) float64
+	statsHook        func(SearchStats)
+	maxIterations    int
+	maxCellsSearched int
+	strictDistance   bool
+	region           Region
+	excludeKeys      map[string]struct{}
+	cellCache        *CellCache
+	altitude         float64
+	altitudeWeight   float64
+	timeWindow       bool
+	from, to         time.Time
+	partitioned      bool
+	partition        string
+	trace            *SearchTrace
+
+	adaptiveDensity   func(cell string) int
+	adaptiveThreshold int
+
+	started time.Time
+	stats   SearchStats
+	// debug caches whether logger is enabled at slog.LevelDebug, so that
+	// searches only build the arguments of debug messages when they are
+	// logged.
+	debug bool
+}
+
+func newSearchOptions(opts []Option) *searchOptions {
+	o := new(searchOptions)
+	o.reset(opts)
+	return o
+}
+
+// reset sets o to the defaults configured by opts, so that the options of a
+// finished search can be reused.
+func (o *searchOptions) reset(opts []Option) {
+	*o = searchOptions{
+		curve:    curve.Geohash,
+		distance: Distance,
+		logger:   currentLogger(),
+		started:  time.Now(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.debug = o.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// initialResolution returns the resolution a search around (lat, lon) for
+// want results, limited to maxResolution, begins at.
+func (o *searchOptions) initialResolution(lat, lon float64, want, maxResolution int) int {
+	if o.startResolution > 0 && o.startResolution < maxResolution {
+		return o.startResolution
+	}
+	if o.densityStats != nil {
+		return densityResolution(o.densityStats, o.curve, lat, lon, want, maxResolution)
+	}
+	return maxResolution
+}
+
+// WithLogger sets the logger used to trace a single search, overriding the
+// package logger installed with SetLogger. A nil logger silences the search.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *searchOptions) {
+		if l == nil {
+			l = discardLogger
+		}
+		o.logger = l
+	}
+}
+
+// WithMaxCellsPerQuery caps the number of geocells passed to a single
+// RepositorySearch call. Larger cell sets are split into several calls whose
+// results are merged, for backends limiting the size of IN queries.
+func WithMaxCellsPerQuery(n int) Option {
+	return func(o *searchOptions) {
+		o.maxCellsPerQuery = n
+	}
+}
+
+// WithParallelism issues up to n RepositorySearch calls concurrently when a
+// search step spans several cells or batches, merging their results. The
+// search function must then be safe for concurrent use.
+func WithParallelism(n int) Option {
+	return func(o *searchOptions) {
+		o.parallelism = n
+	}
+}
+
+// WithStartResolution makes a search begin at resolution n instead of its
+// maximum resolution, skipping the finest levels when data is known to be
+// sparse. It has no effect if n exceeds the maximum resolution.
+func WithStartResolution(n int) Option {
+	return func(o *searchOptions) {
+		o.startResolution = n
+	}
+}
+
+// WithMinResolution stops a search from coarsening below resolution n, so
+// that a query in an empty region gives up instead of widening to cells
+// spanning a large part of the globe.
+func WithMinResolution(n int) Option {
+	return func(o *searchOptions) {
+		o.minResolution = n
+	}
+}
+
+// WithDensityStats records the number of entities found per searched cell in
+// stats, and starts the search at the finest resolution whose cell around
+// the origin has typically held the requested number of results, so that
+// repeated queries skip fruitless levels in sparse regions. An explicit
+// WithStartResolution takes precedence.
+func WithDensityStats(stats DensityStats) Option {
+	return func(o *searchOptions) {
+		o.densityStats = stats
+	}
+}
+
+// WithStrictDistance discards entities farther than maxDistance as soon as
+// the repository returns them. By default they are only removed from the
+// final results, so a repository returning far-away records can fill the
+// result set and end the search before nearer entities within maxDistance
+// are found.
+func WithStrictDistance() Option {
+	return func(o *searchOptions) {
+		o.strictDistance = true
+	}
+}
+
+// WithExcludeKeys skips entities with the given keys, such as ones already
+// shown or blocked, as the repository returns them. Excluded entities do not
+// count towards maxResults, so the search still returns the nearest
+// remaining entities.
+func WithExcludeKeys(keys []string) Option {
+	return func(o *searchOptions) {
+		if o.excludeKeys == nil {
+			o.excludeKeys = make(map[string]struct{}, len(keys))
+		}
+		for _, key := range keys {
+			o.excludeKeys[key] = struct{}{}
+		}
+	}
+}
+
+// WithDistanceFunc ranks entities by the distance computed with f instead of
+// Distance, and measures maxDistance and the extent of searched cells with it
+// too. The cells covering the circle searched by ProximityFetchAll are still
+// computed on the sphere. A nil f keeps Distance.
+func WithDistanceFunc(f DistanceFunc) Option {
+	return func(o *searchOptions) {
+		if f != nil {
+			o.distance = f
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package geomodel
+
+import (
+	"cmp"
+	"slices"
+)
+
+// ResultOrder is the order in which a search returns its results.
+type ResultOrder int
+
+const (
+	Ascending  ResultOrder = iota // Nearest first; the default.
+	Descending                    // Farthest first.
+)
+
+// WithResultOrder sets the order of the returned results. It does not change
+// which results are returned: a search for the k nearest entities still
+// selects by proximity and only then orders them.
+func WithResultOrder(order ResultOrder) Option {
+	return func(o *searchOptions) {
+		o.order = order
+	}
+}
+
+// WithTiebreaker orders results at equal distances by less, which is given
+// the two entities and their distances, for instance to rank by rating or
+// recency. When more entities than the results asked for are at the
+// distance of the farthest result, less also decides which of them are
+// returned. Without a tiebreaker their relative order, and which are
+// returned, is unspecified.
+func WithTiebreaker(less func(a, b LocationCapable, da, db float64) bool) Option {
+	return func(o *searchOptions) {
+		o.tiebreaker = less
+	}
+}
+
+// WithScorer re-sorts the results by increasing score(entity,
+// distance) instead of distance, so that ranking can blend distance with
+// rating, price or recency, for instance with distance * (1 - rating/10).
+// It is a re-sort only: which entities are returned, and when a search
+// stops, is still decided by distance alone, as only distance bounds what
+// the unsearched cells may hold. To let score choose among more candidates,
+// search for more results than needed and keep the first. Results with
+// equal scores are ordered by distance, then by the tiebreaker if one is
+// set, and WithResultOrder(Descending) puts the highest scores first.
+func WithScorer(score func(entity LocationCapable, distance float64) float64) Option {
+	return func(o *searchOptions) {
+		o.scorer = score
+	}
+}
+
+// scoredResult is a result with its score, computed once per sort.
+type scoredResult struct {
+	SearchResult
+	score float64
+}
+
+// sortResults orders results as configured by options.
+func sortResults(results []SearchResult, options *searchOptions) {
+	if options.scorer != nil {
+		sortScored(results, options)
+		return
+	}
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		if a.Distance != b.Distance {
+			if (a.Distance < b.Distance) == (options.order == Descending) {
+				return 1
+			}
+			return -1
+		}
+		if options.tiebreaker == nil {
+			return 0
+		}
+		if options.tiebreaker(a.Entity, b.Entity, a.Distance, b.Distance) {
+			return -1
+		}
+		if options.tiebreaker(b.Entity, a.Entity, b.Distance, a.Distance) {
+			return 1
+		}
+		return 0
+	})
+}
+
+// sortScored orders results by the scores of options.scorer.
+func sortScored(results []SearchResult, options *searchOptions) {
+	var scored []scoredResult = make([]scoredResult, len(results))
+	for i, r := range results {
+		scored[i] = scoredResult{r, options.scorer(r.Entity, r.Distance)}
+	}
+	slices.SortStableFunc(scored, func(a, b scoredResult) int {
+		var c int = cmp.Compare(a.score, b.score)
+		if c == 0 {
+			c = cmp.Compare(a.Distance, b.Distance)
+		}
+		if options.order == Descending {
+			c = -c
+		}
+		if c == 0 && options.tiebreaker != nil {
+			if options.tiebreaker(a.Entity, b.Entity, a.Distance, b.Distance) {
+				return -1
+			}
+			if options.tiebreaker(b.Entity, a.Entity, b.Distance, a.Distance) {
+				return 1
+			}
+		}
+		return c
+	})
+	for i, r := range scored {
+		results[i] = r.SearchResult
+	}
+}
@@ -0,0 +1,57 @@
+package geomodel
+
+// Partitioned is implemented by entities belonging to a partition (a
+// tenant, group or other isolation unit). GeoIndex and the storage adapters
+// keep the entities of each partition apart.
+type Partitioned interface {
+	Partition() string
+}
+
+// PartitionOf returns the partition of entity, or "" if it does not
+// implement Partitioned.
+func PartitionOf(entity LocationCapable) string {
+	if partitioned, ok := entity.(Partitioned); ok {
+		return partitioned.Partition()
+	}
+	return ""
+}
+
+// PartitionSearch looks up the entities of one partition having any of the
+// given cells among their geocells. Adapters implement it by translating
+// partition into a storage-level filter such as a per-tenant table,
+// keyspace or sorted set, so that entities of other partitions are never
+// read; GeoIndex.SearchPartition is one over an in-memory index.
+type PartitionSearch func(partition string, cells []string) []LocationCapable
+
+// In returns a RepositorySearch restricted to partition, and the option
+// restricting a search to it as WithPartition does, so that the two cannot
+// disagree:
+//
+//	search, scope := s.In("acme")
+//	ProximityFetch(lat, lon, 10, 1000, search, 13, scope)
+func (s PartitionSearch) In(partition string) (RepositorySearch, Option) {
+	return func(cells []string) []LocationCapable {
+		return s(partition, cells)
+	}, WithPartition(partition)
+}
+
+// WithPartition restricts a search to the entities of partition, dropping
+// Partitioned entities of any other partition. Entities that are not
+// Partitioned are kept, taken to be filtered by the repository; see
+// PartitionSearch. The check guards against a repository search that is
+// not scoped to the partition, and does not replace one that is.
+func WithPartition(partition string) Option {
+	return func(o *searchOptions) {
+		o.partitioned = true
+		o.partition = partition
+	}
+}
+
+// inPartition reports whether entity passes the partition of a search.
+func (o *searchOptions) inPartition(entity LocationCapable) bool {
+	if !o.partitioned {
+		return true
+	}
+	partitioned, ok := entity.(Partitioned)
+	return !ok || partitioned.Partition() == o.partition
+}
@@ -0,0 +1,80 @@
+package geomodel
+
+import (
+	"slices"
+	"testing"
+)
+
+// tenantPlace is a Place of a partition that GeoIndex.Move can relocate.
+type tenantPlace struct {
+	Place
+	tenant string
+}
+
+func (p tenantPlace) Partition() string { return p.tenant }
+
+func (p tenantPlace) Relocate(lat, lon float64, geocells []string) LocationCapable {
+	return tenantPlace{Place{lat, lon, p.key, geocells}, p.tenant}
+}
+
+func TestGeoIndexSearchPartition(t *testing.T) {
+	var index = NewGeoIndex(
+		tenantPlace{Place{50, 8, "a1", GeoCells(50, 8, 10)}, "a"},
+		tenantPlace{Place{50.001, 8.001, "a2", GeoCells(50.001, 8.001, 10)}, "a"},
+		tenantPlace{Place{50, 8, "b1", GeoCells(50, 8, 10)}, "b"},
+		Place{50, 8, "plain", GeoCells(50, 8, 10)},
+	)
+	var cells = []string{GeoCell(50, 8, 4)}
+	for _, test := range []struct {
+		partition string
+		want      []string
+	}{
+		{"a", []string{"a1", "a2"}},
+		{"b", []string{"b1"}},
+		{"", []string{"plain"}},
+		{"c", nil},
+	} {
+		if got := keysOf(index.SearchPartition(test.partition, cells)); !equalKeys(got, test.want) {
+			t.Errorf("SearchPartition(%q) = %v, want %v", test.partition, got, test.want)
+		}
+	}
+	if got := keysOf(index.Search(cells)); len(got) != 4 {
+		t.Errorf("Search = %v, want every partition", got)
+	}
+
+	// Moves and removals keep the partition's cells in step.
+	if err := index.Move("a2", -33.87, 151.21); err != nil {
+		t.Fatal(err)
+	}
+	index.Remove("a1")
+	if got := keysOf(index.SearchPartition("a", cells)); len(got) != 0 {
+		t.Errorf("SearchPartition after moving and removing = %v, want none", got)
+	}
+	if got := keysOf(index.SearchPartition("a", []string{GeoCell(-33.87, 151.21, 4)})); !equalKeys(got, []string{"a2"}) {
+		t.Errorf("SearchPartition at the new location = %v, want a2", got)
+	}
+}
+
+func TestWithPartition(t *testing.T) {
+	// A repository search that ignores the partition.
+	var index = NewGeoIndex(
+		tenantPlace{Place{50, 8, "a1", GeoCells(50, 8, 10)}, "a"},
+		tenantPlace{Place{50, 8.0001, "b1", GeoCells(50, 8.0001, 10)}, "b"},
+		Place{50, 8.0002, "plain", GeoCells(50, 8.0002, 10)},
+	)
+	var got = keysOf(ProximityFetch(50, 8, 10, 1000, index.Search, 10, WithPartition("a")))
+	if !equalKeys(got, []string{"a1", "plain"}) {
+		t.Errorf("ProximityFetch with WithPartition(a) = %v, want a1 and plain", got)
+	}
+
+	var search, scope = PartitionSearch(index.SearchPartition).In("b")
+	if got := keysOf(ProximityFetch(50, 8, 10, 1000, search, 10, scope)); !equalKeys(got, []string{"b1"}) {
+		t.Errorf("ProximityFetch in partition b = %v, want b1", got)
+	}
+}
+
+// equalKeys reports whether got holds the keys of want, in any order.
+func equalKeys(got, want []string) bool {
+	slices.Sort(got)
+	return slices.Equal(got, want)
+}
@@ -0,0 +1,30 @@
+package geomodel
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidPoint is wrapped by the errors of Point.Validate.
+var ErrInvalidPoint = errors.New("geomodel: invalid point")
+
+// Validate returns an error wrapping ErrInvalidPoint if the latitude of p is
+// outside [-90, 90] or its longitude outside [-180, 180], or either is NaN.
+// A latitude beyond 90 often means the coordinates were swapped.
+func (p Point) Validate() error {
+	if !(p.Lat >= -90 && p.Lat <= 90) {
+		return fmt.Errorf("%w: latitude %v outside [-90, 90]", ErrInvalidPoint, p.Lat)
+	}
+	if !(p.Lon >= -180 && p.Lon <= 180) {
+		return fmt.Errorf("%w: longitude %v outside [-180, 180]", ErrInvalidPoint, p.Lon)
+	}
+	return nil
+}
+
+// NormalizeLon returns p with its longitude wrapped into [-180, 180), so
+// that 190 becomes -170 and 180 becomes -180. Infinite and NaN longitudes
+// become NaN.
+func (p Point) NormalizeLon() Point {
+	p.Lon = wrapLon(p.Lon)
+	return p
+}
@@ -0,0 +1,32 @@
+package geomodel
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPointValidate(t *testing.T) {
+	for _, p := range []Point{{Lat: 0, Lon: 0}, {Lat: 90, Lon: 180}, {Lat: -90, Lon: -180}, {Lat: 53.1, Lon: 8.2}} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%v.Validate() = %v", p, err)
+		}
+	}
+	for _, p := range []Point{{Lat: 91, Lon: 0}, {Lat: 8.2, Lon: 180.5}, {Lat: math.NaN(), Lon: 0}, {Lat: 0, Lon: math.Inf(-1)}} {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPoint) {
+			t.Errorf("%v.Validate() = %v, want ErrInvalidPoint", p, err)
+		}
+	}
+}
+
+func TestPointNormalizeLon(t *testing.T) {
+	var tests = map[float64]float64{0: 0, 190: -170, 180: -180, -180: -180, -190: 170, 540: -180, 725: 5}
+	for lon, want := range tests {
+		if got := (Point{Lat: 10, Lon: lon}).NormalizeLon(); got != (Point{Lat: 10, Lon: want}) {
+			t.Errorf("NormalizeLon of %v = %v, want %v", lon, got.Lon, want)
+		}
+	}
+	if got := (Point{Lon: math.Inf(1)}).NormalizeLon(); !math.IsNaN(got.Lon) {
+		t.Errorf("NormalizeLon of +Inf = %v, want NaN", got.Lon)
+	}
+}
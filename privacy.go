@@ -0,0 +1,44 @@
+package geomodel
+
+import (
+	"math"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// Obfuscate truncates (lat, lon) to a cell whose sides are at least
+// minCellSizeMeters long, and returns the cell with its center, so that apps
+// can store or share coarse positions on the same grid they query.
+//
+// The cell is chosen from the top level down: a cell is refined only if all
+// of its children are large enough. Since cells narrow towards the poles,
+// the east-west side of a cell is measured through its center, and the
+// narrowest children of a cell are those farthest from the equator.
+// Top-level cells are returned when no cell is large enough.
+//
+// The choice depends only on the cells containing the point, so all points
+// of the returned cell yield the same result, and the exact position cannot
+// be recovered from it beyond the cell. Like GeoCell, Obfuscate returns an
+// empty cell, and the zero Point, for NaN coordinates or infinite
+// longitudes.
+func Obfuscate(lat, lon float64, minCellSizeMeters float64) (cell string, center Point) {
+	cell = GeoCell(lat, lon, MAX_GEOCELL_RESOLUTION)
+	if cell == "" {
+		return "", Point{}
+	}
+	var resolution int = 1
+	for ; resolution < MAX_GEOCELL_RESOLUTION; resolution++ {
+		var bbox BoundingBox = ComputeBox(cell[:resolution])
+		var latSpan, lonSpan float64 = curve.Geohash.Span(resolution + 1)
+		// Cells lie on one side of the equator, so the children farthest
+		// from it are centered half a child inside the farthest edge.
+		var farthest float64 = math.Max(math.Abs(bbox.latNE), math.Abs(bbox.latSW)) - latSpan/2
+		var height float64 = DegToRad(latSpan) * EARTH_RADIUS
+		var width float64 = DegToRad(lonSpan) * EARTH_RADIUS * math.Cos(DegToRad(farthest))
+		if math.Min(height, width) < minCellSizeMeters {
+			break
+		}
+	}
+	cell = cell[:resolution]
+	return cell, ComputeBox(cell).Center()
+}
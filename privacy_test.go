@@ -0,0 +1,76 @@
+package geomodel
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestObfuscate(t *testing.T) {
+	for _, size := range []float64{0, 10, 500, 5000, 100000} {
+		cell, center := Obfuscate(53.12869, 8.18976, size)
+		var bbox BoundingBox = ComputeBox(cell)
+		if cell != GeoCell(53.12869, 8.18976, len(cell)) || center != bbox.Center() {
+			t.Errorf("size %v: cell %q with center %v", size, cell, center)
+		}
+		if height, width := cellSides(bbox); math.Min(height, width) < size*0.999 && len(cell) > 1 {
+			t.Errorf("size %v: cell %q is %.0fm by %.0fm", size, cell, width, height)
+		}
+		// Some child of the cell would be too small.
+		if len(cell) < MAX_GEOCELL_RESOLUTION {
+			var children []Cell = Cell(cell).Children()
+			var narrowest float64 = math.Inf(1)
+			for _, child := range children {
+				var height, width = cellSides(child.Box())
+				narrowest = math.Min(narrowest, math.Min(height, width))
+			}
+			if narrowest >= size*1.001 {
+				t.Errorf("size %v: cell %q is coarser than needed", size, cell)
+			}
+		}
+		if again, _ := Obfuscate(center.Lat, center.Lon, size); again != cell {
+			t.Errorf("size %v: center obfuscates to %q, not %q", size, again, cell)
+		}
+	}
+
+	// The probe of a reported leak: both points lie in "uj".
+	var p, _ = Obfuscate(76.6, 3.55, 37225)
+	var q, _ = Obfuscate(75.9, 0.74, 37225)
+	if p != q {
+		t.Errorf("points of one cell obfuscate to %q and %q", p, q)
+	}
+
+	if cell, _ := Obfuscate(50, 8, 1e8); len(cell) != 1 {
+		t.Errorf("huge size gives %q, want a top-level cell", cell)
+	}
+
+	for _, p := range []Point{{math.NaN(), 8}, {50, math.NaN()}, {50, math.Inf(-1)}} {
+		if cell, center := Obfuscate(p.Lat, p.Lon, 500); cell != "" || center != (Point{}) {
+			t.Errorf("Obfuscate(%v, %v) = %q, %v, want no cell", p.Lat, p.Lon, cell, center)
+		}
+	}
+}
+
+// cellSides returns the north-south side of bbox and its east-west side
+// through its center, in meters.
+func cellSides(bbox BoundingBox) (height, width float64) {
+	var center Point = bbox.Center()
+	return DegToRad(bbox.North()-bbox.South()) * EARTH_RADIUS, DegToRad(bbox.East()-bbox.West()) * EARTH_RADIUS * math.Cos(DegToRad(center.Lat))
+}
+
+// TestObfuscateCellwise checks that every point of the returned cell
+// obfuscates to it, so that the result reveals nothing finer.
+func TestObfuscateCellwise(t *testing.T) {
+	var rng = rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		var lat, lon = rng.Float64()*180 - 90, rng.Float64()*360 - 180
+		var size float64 = math.Pow(10, rng.Float64()*6)
+		var cell, _ = Obfuscate(lat, lon, size)
+		for j := 0; j < 10; j++ {
+			var p Point = RandomPointInCell(cell, rng)
+			if again, _ := Obfuscate(p.Lat, p.Lon, size); again != cell {
+				t.Fatalf("size %v: (%v, %v) obfuscates to %q, but %v in it to %q", size, lat, lon, cell, p, again)
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package geomodel
+
+// raceEnabled reports whether tests run under the race detector, whose
+// instrumentation allocates and so breaks allocation counts.
+const raceEnabled = true
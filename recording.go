@@ -0,0 +1,152 @@
+package geomodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// searchRecordingVersion is the version of the format of SearchRecording.
+const searchRecordingVersion = 1
+
+var (
+	// ErrInvalidRecording is returned by ReadSearchRecording for input that
+	// is not a search recording of a supported version.
+	ErrInvalidRecording = errors.New("geomodel: invalid search recording")
+	// ErrReplayDiverged is returned by SearchRecording.Replay when the search
+	// queries cells the recorded search did not.
+	ErrReplayDiverged = errors.New("geomodel: replay diverged from recording")
+)
+
+// SearchRecording is a complete record of a ProximityFetch: its inputs,
+// every repository call with its response, and its results. Written to a
+// file, it lets a search be replayed without the repository that served it,
+// so that ranking anomalies can be reported and tested reproducibly:
+//
+//	var results, recording = geomodel.RecordProximityFetch(lat, lon, 10, 5000, repo.Search, geomodel.MAX_GEOCELL_RESOLUTION)
+//	recording.Write(file)
+//
+// and in a test:
+//
+//	recording, err := geomodel.ReadSearchRecording(file)
+//	...
+//	results, err := recording.Replay()
+//
+// Entities are recorded as IndexRecords, by key, location and geocells, and
+// are replayed as such; options are not recorded and must be passed to
+// Replay again.
+type SearchRecording struct {
+	Version       int
+	Lat, Lon      float64
+	MaxResults    int
+	MaxDistance   float64
+	MaxResolution int
+	// Calls are the repository calls of the search, in the order they
+	// returned.
+	Calls []RecordedCall
+	// Results are the keys and distances of the results of the search.
+	Results []RecordedResult
+}
+
+// RecordedCall is a repository call of a recorded search.
+type RecordedCall struct {
+	Cells    []string
+	Entities []IndexRecord
+}
+
+// RecordedResult is a result of a recorded search.
+type RecordedResult struct {
+	Key      string
+	Distance float64
+}
+
+// RecordProximityFetch runs ProximityFetchResults, recording the search as
+// it goes.
+func RecordProximityFetch(lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) ([]SearchResult, *SearchRecording) {
+	var recording *SearchRecording = &SearchRecording{
+		Version:       searchRecordingVersion,
+		Lat:           lat,
+		Lon:           lon,
+		MaxResults:    maxResults,
+		MaxDistance:   maxDistance,
+		MaxResolution: maxResolution,
+	}
+	// Batches may be searched in parallel.
+	var mu sync.Mutex
+	var recorded RepositorySearch = func(cells []string) []LocationCapable {
+		var entities []LocationCapable = search(cells)
+		var call RecordedCall = RecordedCall{Cells: append([]string(nil), cells...), Entities: make([]IndexRecord, 0, len(entities))}
+		for _, e := range entities {
+			call.Entities = append(call.Entities, IndexRecord{e.Key(), e.Latitude(), e.Longitude(), append([]string(nil), e.Geocells()...)})
+		}
+		mu.Lock()
+		recording.Calls = append(recording.Calls, call)
+		mu.Unlock()
+		return entities
+	}
+
+	var results []SearchResult = ProximityFetchResults(lat, lon, maxResults, maxDistance, recorded, maxResolution, opts...)
+	for _, r := range results {
+		recording.Results = append(recording.Results, RecordedResult{r.Entity.Key(), r.Distance})
+	}
+	return results, recording
+}
+
+// Replay runs the recorded search again with opts, answering each
+// repository call with the entities recorded for the same cells, and
+// returns its results for comparison with Results. It fails with
+// ErrReplayDiverged, along with the results, if the search queries cells
+// that were not recorded, as happens when the search algorithm or the
+// options differ from those recorded.
+func (r *SearchRecording) Replay(opts ...Option) ([]SearchResult, error) {
+	var responses map[string][]IndexRecord = make(map[string][]IndexRecord, len(r.Calls))
+	for _, call := range r.Calls {
+		responses[strings.Join(call.Cells, ",")] = call.Entities
+	}
+
+	var mu sync.Mutex
+	var diverged []string
+	var replayed RepositorySearch = func(cells []string) []LocationCapable {
+		var records, ok = responses[strings.Join(cells, ",")]
+		if !ok {
+			mu.Lock()
+			diverged = append([]string(nil), cells...)
+			mu.Unlock()
+			return nil
+		}
+		var entities []LocationCapable = make([]LocationCapable, len(records))
+		for i := range records {
+			var record IndexRecord = records[i]
+			entities[i] = &record
+		}
+		return entities
+	}
+
+	var results []SearchResult = ProximityFetchResults(r.Lat, r.Lon, r.MaxResults, r.MaxDistance, replayed, r.MaxResolution, opts...)
+	if diverged != nil {
+		return results, fmt.Errorf("%w: cells %v not recorded", ErrReplayDiverged, diverged)
+	}
+	return results, nil
+}
+
+// Write writes the recording to w as JSON.
+func (r *SearchRecording) Write(w io.Writer) error {
+	var encoder *json.Encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// ReadSearchRecording reads a recording written by SearchRecording.Write.
+func ReadSearchRecording(r io.Reader) (*SearchRecording, error) {
+	var recording SearchRecording
+	if err := json.NewDecoder(r).Decode(&recording); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecording, err)
+	}
+	if recording.Version != searchRecordingVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidRecording, recording.Version)
+	}
+	return &recording, nil
+}
@@ -0,0 +1,54 @@
+package geomodel
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordProximityFetch(t *testing.T) {
+	var places []LocationCapable = randomPlaces(500)
+	var results, recording = RecordProximityFetch(50.5, 8.5, 7, 20000, reusingSearch(places), 10, WithMaxCellsPerQuery(2))
+	if len(recording.Calls) < 2 || len(recording.Results) != len(results) {
+		t.Fatalf("recorded %d calls and %d results for %d results", len(recording.Calls), len(recording.Results), len(results))
+	}
+
+	var buf bytes.Buffer
+	if err := recording.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var read, err = ReadSearchRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, recording) {
+		t.Errorf("read recording differs from the written one")
+	}
+
+	replayed, err := read.Replay(WithMaxCellsPerQuery(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []RecordedResult
+	for _, r := range replayed {
+		got = append(got, RecordedResult{r.Entity.Key(), r.Distance})
+	}
+	if !reflect.DeepEqual(got, read.Results) {
+		t.Errorf("replay returned %v, recorded %v", got, read.Results)
+	}
+
+	// With other options the search batches its cells differently.
+	if _, err := read.Replay(WithMaxCellsPerQuery(1)); !errors.Is(err, ErrReplayDiverged) {
+		t.Errorf("Replay with other options: err = %v, want ErrReplayDiverged", err)
+	}
+}
+
+func TestReadSearchRecordingInvalid(t *testing.T) {
+	for _, input := range []string{"", "not json", `{"Version": 99}`} {
+		if _, err := ReadSearchRecording(strings.NewReader(input)); !errors.Is(err, ErrInvalidRecording) {
+			t.Errorf("ReadSearchRecording(%q): err = %v, want ErrInvalidRecording", input, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package geomodel
+
+// Region is an area a search can be restricted to with WithinRegion.
+// BoundingBox, Polygon and Circle are regions.
+type Region interface {
+	// Contains reports whether (lat, lon) lies inside the region.
+	Contains(lat, lon float64) bool
+	// Intersects reports whether the region shares any point with bbox.
+	Intersects(bbox BoundingBox) bool
+}
+
+// Polygon is a simple polygon given by its vertices in order; the last
+// vertex connects back to the first. Edges are straight lines in
+// latitude/longitude space, and polygons must not cross the antimeridian.
+type Polygon []Point
+
+// Contains reports whether (lat, lon) lies inside p, using the even-odd rule.
+func (p Polygon) Contains(lat, lon float64) bool {
+	var inside bool
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		var a, b Point = p[i], p[j]
+		if (a.Lat > lat) != (b.Lat > lat) && lon < (b.Lon-a.Lon)*(lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Intersects reports whether p shares any point with bbox.
+func (p Polygon) Intersects(bbox BoundingBox) bool {
+	if len(p) == 0 {
+		return false
+	}
+	for _, v := range p {
+		if bbox.Contains(v.Lat, v.Lon) {
+			return true
+		}
+	}
+	var corners []Point = []Point{{bbox.latSW, bbox.lonSW}, {bbox.latSW, bbox.lonNE}, {bbox.latNE, bbox.lonNE}, {bbox.latNE, bbox.lonSW}}
+	for _, c := range corners {
+		if p.Contains(c.Lat, c.Lon) {
+			return true
+		}
+	}
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		for k, l := 0, len(corners)-1; k < len(corners); l, k = k, k+1 {
+			if segmentsIntersect(p[j], p[i], corners[l], corners[k]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Circle is the region within Radius meters of Center.
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// Contains reports whether (lat, lon) lies inside c, edge included.
+func (c Circle) Contains(lat, lon float64) bool {
+	return Distance(c.Center.Lat, c.Center.Lon, lat, lon) <= c.Radius
+}
+
+// Intersects reports whether c shares any point with bbox.
+func (c Circle) Intersects(bbox BoundingBox) bool {
+	return distanceToBox(c.Center.Lat, c.Center.Lon, bbox) <= c.Radius
+}
+
+// segmentsIntersect reports whether segments ab and cd cross in
+// latitude/longitude space.
+func segmentsIntersect(a, b, c, d Point) bool {
+	var orientation = func(p, q, r Point) float64 {
+		return (q.Lon-p.Lon)*(r.Lat-p.Lat) - (q.Lat-p.Lat)*(r.Lon-p.Lon)
+	}
+	var d1, d2 float64 = orientation(c, d, a), orientation(c, d, b)
+	var d3, d4 float64 = orientation(a, b, c), orientation(a, b, d)
+	return ((d1 > 0) != (d2 > 0)) && ((d3 > 0) != (d4 > 0))
+}
+
+// WithinRegion restricts a search to entities inside region. Cells not
+// intersecting the region are not passed to the repository, and entities
+// outside it are discarded as they are returned, so they never count
+// towards maxResults.
+func WithinRegion(region Region) Option {
+	return func(o *searchOptions) {
+		o.region = region
+	}
+}
+
+// clipCells returns the cells intersecting region, box returning the
+// bounding box of a cell.
+func clipCells(box func(cell string) BoundingBox, cells []string, region Region) []string {
+	var clipped []string = make([]string, 0, len(cells))
+	for _, cell := range cells {
+		if region.Intersects(box(cell)) {
+			clipped = append(clipped, cell)
+		}
+	}
+	return clipped
+}
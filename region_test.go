@@ -0,0 +1,45 @@
+package geomodel
+
+import "testing"
+
+func TestPolygon(t *testing.T) {
+	var triangle = Polygon{{0, 0}, {10, 0}, {0, 10}}
+
+	if !triangle.Contains(2, 2) || triangle.Contains(8, 8) || triangle.Contains(-1, 1) {
+		t.Error("Contains misclassifies points")
+	}
+	if !triangle.Intersects(NewBoundingBox(3, 3, 1, 1)) {
+		t.Error("box inside the polygon does not intersect it")
+	}
+	if !triangle.Intersects(NewBoundingBox(20, 20, -20, -20)) {
+		t.Error("box enclosing the polygon does not intersect it")
+	}
+	if !triangle.Intersects(NewBoundingBox(6, 6, 4, -1)) {
+		t.Error("box crossing an edge does not intersect the polygon")
+	}
+	if triangle.Intersects(NewBoundingBox(9, 9, 7, 7)) {
+		t.Error("box beyond the hypotenuse intersects the polygon")
+	}
+}
+
+func TestProximityFetchWithinRegion(t *testing.T) {
+	var places = []LocationCapable{Place{50, 8, "outside", GeoCells(50, 8, 10)}, Place{50.01, 8.01, "inside", GeoCells(50.01, 8.01, 10)}}
+	var region = NewBoundingBox(50.02, 8.02, 50.005, 8.005)
+
+	var searched []string
+	var search = searchPlaces(places)
+	var recording RepositorySearch = func(cells []string) []LocationCapable {
+		searched = append(searched, cells...)
+		return search(cells)
+	}
+
+	var result = ProximityFetch(50, 8, 1, 0, recording, 10, WithinRegion(region))
+	if len(result) != 1 || result[0].Key() != "inside" {
+		t.Errorf("got %v, want only the place inside the region", result)
+	}
+	for _, cell := range searched {
+		if !region.Intersects(ComputeBox(cell)) {
+			t.Errorf("searched cell %q outside the region", cell)
+		}
+	}
+}
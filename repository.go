@@ -0,0 +1,154 @@
+package geomodel
+
+import "sync"
+
+// runSearch passes cells to search and merges the results so that each
+// entity is returned once. Cells are split into batches of at most
+// options.maxCellsPerQuery cells when a limit is set, and spread over
+// options.parallelism concurrent calls when parallelism is enabled. Hits per
+// cell are recorded in options.densityStats if set. With options.region set,
+// cells outside the region are skipped and entities outside it dropped;
+// entities with excluded keys or outside the time window are dropped as
+// well.
+func runSearch(search RepositorySearch, cells []string, options *searchOptions) []LocationCapable {
+	if options.region != nil {
+		cells = clipCells(options.box, cells, options.region)
+		if len(cells) == 0 {
+			return nil
+		}
+	}
+
+	var results []LocationCapable = searchBatches(search, cells, options)
+	if options.densityStats != nil {
+		observeDensity(options.densityStats, cells, results)
+	}
+
+	if options.region != nil || len(options.excludeKeys) > 0 || options.timeWindow || options.partitioned {
+		// results may be the slice returned by search, which is not ours to
+		// overwrite.
+		var accepted []LocationCapable = make([]LocationCapable, 0, len(results))
+		for _, entity := range results {
+			if options.accepts(entity) {
+				accepted = append(accepted, entity)
+			}
+		}
+		results = accepted
+	}
+	return results
+}
+
+// accepts reports whether entity passes the region, excluded-keys, time
+// window and partition filters of a search.
+func (o *searchOptions) accepts(entity LocationCapable) bool {
+	if _, ok := o.excludeKeys[entity.Key()]; ok {
+		return false
+	}
+	if !o.inTimeWindow(entity) || !o.inPartition(entity) {
+		return false
+	}
+	return o.region == nil || o.region.Contains(entity.Latitude(), entity.Longitude())
+}
+
+func searchBatches(search RepositorySearch, cells []string, options *searchOptions) []LocationCapable {
+	var seen map[string]struct{} = getKeySet()
+	defer putKeySet(seen)
+
+	if size := batchSize(len(cells), options); size <= 0 || len(cells) <= size {
+		// A single call, whose results are returned as they are unless they
+		// hold duplicates.
+		var entities []LocationCapable = search(cells)
+		options.stats.RepositoryCalls++
+		options.stats.CellsQueried += len(cells)
+		options.stats.EntitiesScanned += len(entities)
+		var results []LocationCapable = entities
+		var copied bool
+		for i, entity := range entities {
+			if _, ok := seen[entity.Key()]; ok {
+				options.stats.DuplicatesDropped++
+				if !copied {
+					results, copied = append(make([]LocationCapable, 0, len(entities)), entities[:i]...), true
+				}
+				continue
+			}
+			seen[entity.Key()] = struct{}{}
+			if copied {
+				results = append(results, entity)
+			}
+		}
+		return results
+	}
+
+	var batches [][]string = splitCells(cells, options)
+	var found [][]LocationCapable = make([][]LocationCapable, len(batches))
+	if options.parallelism > 1 {
+		var wg sync.WaitGroup
+		var workers chan struct{} = make(chan struct{}, options.parallelism)
+		for i, batch := range batches {
+			wg.Add(1)
+			workers <- struct{}{}
+			go func(i int, batch []string) {
+				defer wg.Done()
+				found[i] = search(batch)
+				<-workers
+			}(i, batch)
+		}
+		wg.Wait()
+	} else {
+		for i, batch := range batches {
+			found[i] = search(batch)
+		}
+	}
+
+	options.stats.RepositoryCalls += len(batches)
+	options.stats.CellsQueried += len(cells)
+
+	var total int
+	for _, entities := range found {
+		total += len(entities)
+	}
+	var results []LocationCapable = make([]LocationCapable, 0, total)
+	for _, entities := range found {
+		options.stats.EntitiesScanned += len(entities)
+		for _, entity := range entities {
+			if _, ok := seen[entity.Key()]; ok {
+				options.stats.DuplicatesDropped++
+				continue
+			}
+			seen[entity.Key()] = struct{}{}
+			results = append(results, entity)
+		}
+	}
+	return results
+}
+
+// batchSize returns the number of cells passed to each RepositorySearch
+// call for n cells, or 0 for no limit.
+func batchSize(n int, options *searchOptions) int {
+	var size int = options.maxCellsPerQuery
+	if options.parallelism > 1 {
+		var perWorker int = (n + options.parallelism - 1) / options.parallelism
+		if size <= 0 || perWorker < size {
+			size = perWorker
+		}
+	}
+	return size
+}
+
+// splitCells divides cells into the batches passed to individual
+// RepositorySearch calls.
+func splitCells(cells []string, options *searchOptions) [][]string {
+	var size int = batchSize(len(cells), options)
+	if size <= 0 || len(cells) <= size {
+		return [][]string{cells}
+	}
+
+	var batches [][]string = make([][]string, 0, (len(cells)+size-1)/size)
+	for start := 0; start < len(cells); start += size {
+		var end int = start + size
+		if end > len(cells) {
+			end = len(cells)
+		}
+		batches = append(batches, cells[start:end])
+	}
+	return batches
+}
@@ -0,0 +1,79 @@
+package geomodel
+
+import "sync"
+
+// maxPooledResults bounds the result buffers kept for reuse, so that a search
+// for very many results does not pin its buffers in memory.
+const maxPooledResults = 1 << 12
+
+// searchBuffers holds the options and temporary slices of a proximity
+// search.
+type searchBuffers struct {
+	options searchOptions
+	results topK
+	unique  []string
+	// Two halves holding the current and the next frontier.
+	frontier [16]string
+	// The results of ReusableSearcher.ProximityFetch before they are
+	// converted.
+	found []SearchResult
+}
+
+// clearCells empties cells so that a reused buffer does not keep the cell
+// strings alive.
+func clearCells(cells []string) []string {
+	clear(cells)
+	return cells[:0]
+}
+
+// ReusableSearcher performs the searches of ProximityFetch and
+// ProximityFetchResults, reusing the options, frontiers and result buffers
+// of finished searches for later ones instead of leaving them to the
+// garbage collector. It suits servers answering many proximity queries per
+// second. A ReusableSearcher is safe for concurrent use and its zero value
+// is ready to use.
+type ReusableSearcher struct {
+	pool sync.Pool
+}
+
+func (s *ReusableSearcher) get() *searchBuffers {
+	if buf, ok := s.pool.Get().(*searchBuffers); ok {
+		return buf
+	}
+	return new(searchBuffers)
+}
+
+func (s *ReusableSearcher) put(buf *searchBuffers) {
+	if cap(buf.results.items) > maxPooledResults || cap(buf.found) > maxPooledResults {
+		return
+	}
+	buf.options = searchOptions{}
+	s.pool.Put(buf)
+}
+
+// ProximityFetch is like the ProximityFetch function.
+func (s *ReusableSearcher) ProximityFetch(lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) []LocationCapable {
+	var buf *searchBuffers = s.get()
+	defer s.put(buf)
+
+	buf.found = proximityFetch(buf.found[:0], buf, lat, lon, maxResults, maxDistance, search, maxResolution, opts)
+	var result []LocationCapable = make([]LocationCapable, 0, len(buf.found))
+	for _, r := range buf.found {
+		result = append(result, r.Entity)
+	}
+	clear(buf.found)
+	return result
+}
+
+// ProximityFetchResults is like the ProximityFetchResults function.
+func (s *ReusableSearcher) ProximityFetchResults(lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) []SearchResult {
+	return s.AppendProximityFetchResults(nil, lat, lon, maxResults, maxDistance, search, maxResolution, opts...)
+}
+
+// AppendProximityFetchResults is like ProximityFetchResults but appends the
+// results to dst, so that callers reusing dst search without allocating.
+func (s *ReusableSearcher) AppendProximityFetchResults(dst []SearchResult, lat, lon float64, maxResults int, maxDistance float64, search RepositorySearch, maxResolution int, opts ...Option) []SearchResult {
+	var buf *searchBuffers = s.get()
+	defer s.put(buf)
+	return proximityFetch(dst, buf, lat, lon, maxResults, maxDistance, search, maxResolution, opts)
+}
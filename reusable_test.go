@@ -0,0 +1,74 @@
+package geomodel
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestReusableSearcher(t *testing.T) {
+	var search = indexPlaces(randomPlaces(5000))
+	var s ReusableSearcher
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				var lat, lon = 50 + float64(i)/20, 8 + float64(g)/4
+				var want = ProximityFetchResults(lat, lon, 1+i, 5000, search, 10)
+				if got := s.ProximityFetchResults(lat, lon, 1+i, 5000, search, 10); !reflect.DeepEqual(got, want) {
+					t.Errorf("ProximityFetchResults(%v, %v) = %v, want %v", lat, lon, got, want)
+				}
+				if got := s.ProximityFetch(lat, lon, 1+i, 5000, search, 10); len(got) != len(want) {
+					t.Errorf("ProximityFetch(%v, %v) returned %d entities, want %d", lat, lon, len(got), len(want))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if got := s.ProximityFetchResults(50.5, 8.5, 0, 0, search, 10); got == nil || len(got) != 0 {
+		t.Errorf("ProximityFetchResults with no results wanted = %#v, want empty", got)
+	}
+}
+
+func TestAppendProximityFetchResults(t *testing.T) {
+	var search = indexPlaces(randomPlaces(5000))
+	var s ReusableSearcher
+	var first = SearchResult{Place{key: "first"}, 0}
+	var got = s.AppendProximityFetchResults([]SearchResult{first}, 50.5, 8.5, 5, 0, search, 10)
+	var want = ProximityFetchResults(50.5, 8.5, 5, 0, search, 10)
+	if !reflect.DeepEqual(got, append([]SearchResult{first}, want...)) {
+		t.Errorf("AppendProximityFetchResults = %v, want %v after %v", got, want, first)
+	}
+}
+
+func TestAppendProximityFetchResultsAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are unreliable under the race detector")
+	}
+	var search = reusingSearch(randomPlaces(20000))
+	var s ReusableSearcher
+	var dst []SearchResult
+	var plain float64 = testing.AllocsPerRun(20, func() {
+		ProximityFetchResults(50.5, 8.5, 200, 0, search, 10)
+	})
+	var reused float64 = testing.AllocsPerRun(20, func() {
+		dst = s.AppendProximityFetchResults(dst[:0], 50.5, 8.5, 200, 0, search, 10)
+	})
+	if reused >= plain {
+		t.Errorf("AppendProximityFetchResults made %v allocations per query, ProximityFetchResults %v", reused, plain)
+	}
+}
+
+func BenchmarkReusableSearcher(b *testing.B) {
+	var search = reusingSearch(randomPlaces(100000))
+	var s ReusableSearcher
+	var dst []SearchResult
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = s.AppendProximityFetchResults(dst[:0], 50.5, 8.5, 200, 0, search, 10)
+	}
+}
@@ -0,0 +1,90 @@
+package geomodel
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// sampleMaxCells bounds the covering SamplePoints draws cells from. Finer
+// coverings reject fewer points but take longer to compute.
+const sampleMaxCells = 1024
+
+// sampleMaxAttempts bounds the points SamplePoints draws per point requested,
+// so that regions intersecting cells without containing any point of them
+// cannot make it loop forever.
+const sampleMaxAttempts = 1000
+
+// RandomPointInCell returns a point drawn uniformly by area from the bounding
+// box of cell using rng, for generating synthetic entities in a cell.
+func RandomPointInCell(cell string, rng *rand.Rand) Point {
+	return randomPointInBox(ComputeBox(cell), rng)
+}
+
+// randomPointInBox returns a point drawn uniformly by area from bbox: the
+// sine of its latitude is uniform, since bands of latitude shrink towards
+// the poles.
+func randomPointInBox(bbox BoundingBox, rng *rand.Rand) Point {
+	var sinSouth, sinNorth float64 = math.Sin(DegToRad(bbox.latSW)), math.Sin(DegToRad(bbox.latNE))
+	var lat float64 = RadToDeg(math.Asin(sinSouth + rng.Float64()*(sinNorth-sinSouth)))
+	var lon float64 = bbox.lonSW + rng.Float64()*bbox.lonWidth()
+	if lon > 180 {
+		lon -= 360
+	}
+	return Point{Lat: lat, Lon: lon}
+}
+
+// SamplePoints returns n points drawn uniformly by area from region using
+// rng, for load tests and synthetic data over a service area. The region is
+// covered with cells of the finest resolution keeping the covering under
+// sampleMaxCells cells; points are drawn from the cells, weighted by their
+// area, and kept if region contains them. Fewer than n points are returned
+// only for regions containing no point, or almost none, of the cells they
+// intersect.
+func SamplePoints(region Region, n int, rng *rand.Rand) []Point {
+	var cells []string
+	for i := 0; i < len(GEOCELL_ALPHABET); i++ {
+		var cell string = GEOCELL_ALPHABET[i : i+1]
+		if region.Intersects(ComputeBox(cell)) {
+			cells = append(cells, cell)
+		}
+	}
+	for resolution := 2; resolution <= MAX_GEOCELL_RESOLUTION; resolution++ {
+		var children []string
+		for _, cell := range cells {
+			for i := 0; i < len(GEOCELL_ALPHABET) && len(children) <= sampleMaxCells; i++ {
+				var child string = cell + GEOCELL_ALPHABET[i:i+1]
+				if region.Intersects(ComputeBox(child)) {
+					children = append(children, child)
+				}
+			}
+		}
+		if len(children) > sampleMaxCells {
+			break
+		}
+		cells = children
+	}
+	if len(cells) == 0 || n <= 0 {
+		return nil
+	}
+
+	// The cumulative area of the cells, to pick them by area.
+	var boxes []BoundingBox = make([]BoundingBox, len(cells))
+	var areas []float64 = make([]float64, len(cells))
+	var total float64
+	for i, cell := range cells {
+		boxes[i] = ComputeBox(cell)
+		total += boxes[i].AreaM2()
+		areas[i] = total
+	}
+
+	var points []Point = make([]Point, 0, n)
+	for attempts := 0; len(points) < n && attempts < n*sampleMaxAttempts; attempts++ {
+		var i int = sort.SearchFloat64s(areas, rng.Float64()*total)
+		var p Point = randomPointInBox(boxes[min(i, len(boxes)-1)], rng)
+		if region.Contains(p.Lat, p.Lon) {
+			points = append(points, p)
+		}
+	}
+	return points
+}
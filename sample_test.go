@@ -0,0 +1,53 @@
+package geomodel
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRandomPointInCell(t *testing.T) {
+	var rng *rand.Rand = rand.New(rand.NewSource(1))
+	for _, cell := range []string{"u1my4r", "8", "zzz", "0"} {
+		var bbox BoundingBox = ComputeBox(cell)
+		for i := 0; i < 100; i++ {
+			if p := RandomPointInCell(cell, rng); !bbox.Contains(p.Lat, p.Lon) {
+				t.Fatalf("RandomPointInCell(%q) = %v, outside %v", cell, p, bbox)
+			}
+		}
+	}
+}
+
+func TestSamplePoints(t *testing.T) {
+	var rng *rand.Rand = rand.New(rand.NewSource(1))
+	var regions = map[string]Region{
+		"circle":       Circle{Center: Point{Lat: 53.14, Lon: 8.21}, Radius: 150},
+		"antimeridian": NewBoundingBox(-10, -175, -20, 175),
+		"polygon":      Polygon{{50, 8}, {50, 9}, {51, 8.5}},
+	}
+	for name, region := range regions {
+		var points []Point = SamplePoints(region, 500, rng)
+		if len(points) != 500 {
+			t.Errorf("%s: got %d points, want 500", name, len(points))
+		}
+		for _, p := range points {
+			if !region.Contains(p.Lat, p.Lon) {
+				t.Errorf("%s: sampled %v outside the region", name, p)
+			}
+		}
+	}
+
+	// Both halves of a box split by the antimeridian get points.
+	var east int
+	for _, p := range SamplePoints(regions["antimeridian"], 1000, rng) {
+		if p.Lon > 0 {
+			east++
+		}
+	}
+	if east < 350 || east > 650 {
+		t.Errorf("%d of 1000 points east of the antimeridian, want about half", east)
+	}
+
+	if points := SamplePoints(Polygon{}, 10, rng); len(points) != 0 {
+		t.Errorf("sampling an empty polygon returned %v", points)
+	}
+}
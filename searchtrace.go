@@ -0,0 +1,156 @@
+package geomodel
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// SearchTrace records the course of a ProximityFetch, for diagnosing why a
+// nearby entity was missed: whether its cell was ever searched, and whether
+// the repository returned it. Pass one to WithTrace; the search overwrites
+// it.
+type SearchTrace struct {
+	Origin      Point
+	MaxResults  int
+	MaxDistance float64
+	// Steps are the iterations of the search loop, in order.
+	Steps []TraceStep
+	// Results are the results returned by the search.
+	Results []SearchResult
+
+	// curve encodes the cells of the trace.
+	curve Curve
+}
+
+// TraceStep is one iteration of a traced search.
+type TraceStep struct {
+	// Frontier holds the cells the iteration covers.
+	Frontier []string
+	// Searched holds the cells of Frontier passed to the repository, those
+	// not searched by an earlier step.
+	Searched []string
+	// Found holds the entities the repository returned for Searched that
+	// passed the search's filters, with their distances from the origin.
+	Found []SearchResult
+}
+
+// WithTrace records the course of the search in trace, which is reset when
+// the search starts. Tracing copies every frontier and result, so it is
+// meant for debugging rather than for production traffic.
+func WithTrace(trace *SearchTrace) Option {
+	return func(o *searchOptions) {
+		o.trace = trace
+	}
+}
+
+// start resets t for a search by options around origin.
+func (t *SearchTrace) start(origin Point, maxResults int, maxDistance float64, options *searchOptions) {
+	*t = SearchTrace{Origin: origin, MaxResults: maxResults, MaxDistance: maxDistance, curve: options.curve}
+}
+
+// box returns the bounding box of cell on the curve of the traced search.
+func (t *SearchTrace) box(cell string) BoundingBox {
+	if t.curve == nil {
+		return computeBox(curve.Geohash, cell)
+	}
+	return computeBox(t.curve, cell)
+}
+
+// featureCollection returns the trace as GeoJSON features: the origin, the
+// cells of each step and the entities found, each with a "role" property.
+// Cells are "searched" in the step that passed them to the repository and
+// "frontier" in later ones; entities are "returned" if they are among the
+// results and "found" otherwise.
+func (t *SearchTrace) featureCollection() geoJSONFeatureCollection {
+	var collection geoJSONFeatureCollection = geoJSONFeatureCollection{"FeatureCollection", []geoJSONFeature{}}
+	collection.Features = append(collection.Features, geoJSONPoint(t.Origin.Lat, t.Origin.Lon, map[string]interface{}{"role": "origin"}))
+
+	var returned map[string]bool = make(map[string]bool, len(t.Results))
+	for _, r := range t.Results {
+		returned[r.Entity.Key()] = true
+	}
+	var seen map[string]bool = make(map[string]bool)
+	for i, step := range t.Steps {
+		for _, cell := range step.Frontier {
+			var role string = "frontier"
+			for _, searched := range step.Searched {
+				if searched == cell {
+					role = "searched"
+				}
+			}
+			collection.Features = append(collection.Features, geoJSONPolygon(t.box(cell), map[string]interface{}{"role": role, "cell": cell, "step": i}))
+		}
+		for _, r := range step.Found {
+			var key string = r.Entity.Key()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			var role string = "found"
+			if returned[key] {
+				role = "returned"
+			}
+			collection.Features = append(collection.Features, geoJSONPoint(r.Entity.Latitude(), r.Entity.Longitude(), map[string]interface{}{
+				"role":       role,
+				"key":        key,
+				"distance_m": r.Distance,
+				"step":       i,
+			}))
+		}
+	}
+	return collection
+}
+
+// WriteGeoJSON writes the trace as a GeoJSON FeatureCollection: the origin,
+// the frontier cells of each step as polygons and the entities found as
+// points, told apart by their "role" property as "origin", "searched",
+// "frontier", "found" or "returned", with the "step" finding them.
+func (t *SearchTrace) WriteGeoJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t.featureCollection())
+}
+
+// traceHTML is the page WriteHTML renders, drawing the trace with Leaflet
+// over OpenStreetMap tiles.
+var traceHTML = template.Must(template.New("trace").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>geomodel search trace</title>
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<style>html, body, #map { height: 100%; margin: 0; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+var trace = {{.}};
+var colors = {origin: "#000000", searched: "#3388ff", frontier: "#999999", found: "#ff8800", returned: "#22aa22"};
+var map = L.map("map");
+L.tileLayer("https://tile.openstreetmap.org/{z}/{x}/{y}.png", {
+	maxZoom: 19,
+	attribution: "&copy; OpenStreetMap contributors"
+}).addTo(map);
+var layer = L.geoJSON(trace, {
+	style: function (f) { return {color: colors[f.properties.role], weight: 1, fillOpacity: 0.1}; },
+	pointToLayer: function (f, latlng) {
+		return L.circleMarker(latlng, {radius: 5, color: colors[f.properties.role], fillOpacity: 0.8});
+	},
+	onEachFeature: function (f, l) {
+		l.bindPopup(Object.keys(f.properties).map(function (k) { return k + ": " + f.properties[k]; }).join("<br>"));
+	}
+}).addTo(map);
+map.fitBounds(layer.getBounds());
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML writes a standalone HTML page drawing the trace on a Leaflet
+// map, with the features of WriteGeoJSON colored by role. The page loads
+// Leaflet and its map tiles from the web.
+func (t *SearchTrace) WriteHTML(w io.Writer) error {
+	return traceHTML.Execute(w, t.featureCollection())
+}
@@ -0,0 +1,88 @@
+package geomodel
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithTrace(t *testing.T) {
+	var places []LocationCapable = randomPlaces(300)
+	var trace SearchTrace
+	var results = ProximityFetchResults(50.5, 8.5, 5, 0, reusingSearch(places), 10, WithTrace(&trace))
+
+	if trace.Origin != (Point{50.5, 8.5}) || trace.MaxResults != 5 || len(trace.Steps) == 0 {
+		t.Fatalf("trace = %+v", trace)
+	}
+	if !reflect.DeepEqual(trace.Results, results) {
+		t.Errorf("trace results %v, want %v", trace.Results, results)
+	}
+	if first := trace.Steps[0]; !reflect.DeepEqual(first.Frontier, []string{GeoCell(50.5, 8.5, 10)}) || !reflect.DeepEqual(first.Searched, first.Frontier) {
+		t.Errorf("first step = %+v, want the cell of the origin", first)
+	}
+
+	var searched []string
+	var found map[string]bool = make(map[string]bool)
+	for _, step := range trace.Steps {
+		for _, cell := range step.Searched {
+			if slices.Contains(searched, cell) || !slices.Contains(step.Frontier, cell) {
+				t.Errorf("cell %q searched twice or outside the frontier", cell)
+			}
+			searched = append(searched, cell)
+		}
+		for _, r := range step.Found {
+			found[r.Entity.Key()] = true
+		}
+	}
+	for _, r := range results {
+		if !found[r.Entity.Key()] {
+			t.Errorf("result %q not found by any step", r.Entity.Key())
+		}
+	}
+
+	// The trace is overwritten by the next search.
+	ProximityFetchResults(50.5, 8.5, 0, 0, reusingSearch(places), 10, WithTrace(&trace))
+	if len(trace.Steps) != 0 || trace.MaxResults != 0 {
+		t.Errorf("trace not reset: %+v", trace)
+	}
+}
+
+func TestSearchTraceWriteGeoJSON(t *testing.T) {
+	var places []LocationCapable = randomPlaces(300)
+	var trace SearchTrace
+	var results = ProximityFetchResults(50.5, 8.5, 3, 0, reusingSearch(places), 10, WithTrace(&trace))
+
+	var buf bytes.Buffer
+	if err := trace.WriteGeoJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Features []struct {
+			Geometry struct {
+				Type string
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	var roles map[string]int = make(map[string]int)
+	for _, f := range collection.Features {
+		roles[f.Properties["role"].(string)]++
+	}
+	if roles["origin"] != 1 || roles["returned"] != len(results) || roles["searched"] == 0 {
+		t.Errorf("features by role = %v", roles)
+	}
+
+	buf.Reset()
+	if err := trace.WriteHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if page := buf.String(); !strings.Contains(page, "L.geoJSON") || !strings.Contains(page, `"key":"`+results[0].Entity.Key()+`"`) {
+		t.Errorf("HTML page misses the map or the trace:\n%s", page)
+	}
+}
@@ -0,0 +1,299 @@
+// Package sqlrepo implements geomodel searches over database/sql, for tables
+// storing entities together with their geocells:
+//
+//	var repo = &sqlrepo.Repository{
+//		DB:          db,
+//		Table:       sqlrepo.Table{Name: "places", Key: "id", Lat: "lat", Lon: "lon", Cell: "geocell"},
+//		Placeholder: sqlrepo.Dollar,
+//	}
+//	var query = repo.Query(ctx)
+//	var nearby = geomodel.ProximityFetch(lat, lon, 10, 1000, query.Search, geomodel.MAX_GEOCELL_RESOLUTION)
+//	if err := query.Err(); err != nil {
+//		return err
+//	}
+//
+// With MatchExact the cell column holds one geocell per row, so that an
+// entity stored at several resolutions has several rows; with MatchPrefix it
+// holds the entity's finest geocell and searches match its prefixes with
+// LIKE; MatchRange stores the same but matches with range comparisons, which
+// every index on the column can serve regardless of its collation. Either
+// way the column should be indexed.
+//
+// Table and column names are inserted into queries verbatim and must come
+// from trusted configuration.
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/internal/firsterr"
+)
+
+// Querier runs queries; *sql.DB, *sql.Conn and *sql.Tx implement it.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Scanner reads the columns of a result row; *sql.Rows implements it.
+type Scanner interface {
+	Scan(dest ...any) error
+}
+
+// ErrNotPartitioned is returned by partition searches of a table with no
+// partition column.
+var ErrNotPartitioned = errors.New("sqlrepo: table has no partition column")
+
+// Table maps entities to a table: its name and the columns holding the
+// entity key, latitude, longitude and geocell.
+type Table struct {
+	Name string
+	Key  string
+	Lat  string
+	Lon  string
+	Cell string
+	// Partition is the column holding the partition of each row, such as a
+	// tenant id, for SearchPartition. It is optional.
+	Partition string
+}
+
+// MatchMode selects how cells are matched against the cell column.
+type MatchMode int
+
+const (
+	// MatchExact matches rows whose cell equals a searched cell, with
+	// WHERE cell IN (...).
+	MatchExact MatchMode = iota
+	// MatchPrefix matches rows whose cell starts with a searched cell, with
+	// WHERE cell LIKE ... OR cell LIKE ....
+	MatchPrefix
+	// MatchRange matches rows whose cell starts with a searched cell like
+	// MatchPrefix, with the ranges of geomodel.CellRange:
+	// WHERE (cell >= ... AND cell < ...) OR .... Each cell takes two
+	// placeholders.
+	MatchRange
+)
+
+// Placeholder returns the bind parameter for the n-th argument of a query,
+// counting from 1.
+type Placeholder func(n int) string
+
+// Question is the placeholder style of MySQL and SQLite.
+func Question(n int) string { return "?" }
+
+// Dollar is the placeholder style of PostgreSQL.
+func Dollar(n int) string { return "$" + strconv.Itoa(n) }
+
+// DEFAULT_MAX_PLACEHOLDERS is the number of cells per query used when
+// Repository.MaxPlaceholders is not set, below the lowest limit of common
+// databases.
+const DEFAULT_MAX_PLACEHOLDERS = 999
+
+// Repository searches a table for the entities in given cells. Its fields
+// are read-only once searches start.
+type Repository struct {
+	DB    Querier
+	Table Table
+	Match MatchMode
+	// Placeholder defaults to Question.
+	Placeholder Placeholder
+	// MaxPlaceholders bounds the placeholders of a single query, including
+	// the one of the partition condition; searches over more cells run
+	// several queries. It defaults to DEFAULT_MAX_PLACEHOLDERS.
+	MaxPlaceholders int
+	// Columns and Scan read entities of a custom type. Scan is passed each
+	// result row of a query selecting Columns. If Scan is nil, the key,
+	// latitude and longitude columns are read into *geomodel.IndexRecord
+	// values without geocells.
+	Columns []string
+	Scan    func(row Scanner) (geomodel.LocationCapable, error)
+}
+
+// Search returns the entities having any of cells, each once.
+func (r *Repository) Search(ctx context.Context, cells []string) ([]geomodel.LocationCapable, error) {
+	return r.search(ctx, cells, nil)
+}
+
+// SearchPartition returns the entities of partition having any of cells,
+// each once, with a condition on the partition column in every query. It
+// returns ErrNotPartitioned if the table has no partition column.
+func (r *Repository) SearchPartition(ctx context.Context, partition string, cells []string) ([]geomodel.LocationCapable, error) {
+	if r.Table.Partition == "" {
+		return nil, ErrNotPartitioned
+	}
+	return r.search(ctx, cells, &partition)
+}
+
+// search returns the entities having any of cells, restricted to the
+// partition if it is not nil.
+func (r *Repository) search(ctx context.Context, cells []string, partition *string) ([]geomodel.LocationCapable, error) {
+	var batchSize int = r.MaxPlaceholders
+	if batchSize <= 0 {
+		batchSize = DEFAULT_MAX_PLACEHOLDERS
+	}
+	// The partition condition takes a placeholder of its own.
+	if partition != nil {
+		batchSize--
+	}
+	if r.Match == MatchRange {
+		batchSize /= 2
+	}
+	batchSize = max(batchSize, 1)
+
+	var results []geomodel.LocationCapable
+	var seen map[string]struct{} = make(map[string]struct{})
+	for start := 0; start < len(cells); start += batchSize {
+		var batch []string = cells[start:min(start+batchSize, len(cells))]
+		var query, args = r.buildQuery(batch, partition)
+		rows, err := r.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			entity, err := r.scan(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if _, ok := seen[entity.Key()]; !ok {
+				seen[entity.Key()] = struct{}{}
+				results = append(results, entity)
+			}
+		}
+		if err = rows.Close(); err != nil {
+			return nil, err
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// buildQuery returns the query selecting the entities of cells, in the
+// partition if it is not nil, and its arguments.
+func (r *Repository) buildQuery(cells []string, partition *string) (string, []any) {
+	var placeholder Placeholder = r.Placeholder
+	if placeholder == nil {
+		placeholder = Question
+	}
+	var columns []string = r.Columns
+	if r.Scan == nil {
+		columns = []string{r.Table.Key, r.Table.Lat, r.Table.Lon}
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	b.WriteString(strings.Join(columns, ", "))
+	b.WriteString(" FROM ")
+	b.WriteString(r.Table.Name)
+	b.WriteString(" WHERE ")
+	if partition != nil {
+		b.WriteString("(")
+	}
+
+	var args []any = make([]any, len(cells))
+	if r.Match == MatchRange {
+		args = make([]any, 0, 2*len(cells))
+		for i, cell := range cells {
+			if i > 0 {
+				b.WriteString(" OR ")
+			}
+			start, end := geomodel.CellRange(cell)
+			b.WriteString("(")
+			b.WriteString(r.Table.Cell)
+			b.WriteString(" >= ")
+			args = append(args, start)
+			b.WriteString(placeholder(len(args)))
+			if end != "" {
+				b.WriteString(" AND ")
+				b.WriteString(r.Table.Cell)
+				b.WriteString(" < ")
+				args = append(args, end)
+				b.WriteString(placeholder(len(args)))
+			}
+			b.WriteString(")")
+		}
+	} else if r.Match == MatchPrefix {
+		for i, cell := range cells {
+			if i > 0 {
+				b.WriteString(" OR ")
+			}
+			b.WriteString(r.Table.Cell)
+			b.WriteString(" LIKE ")
+			b.WriteString(placeholder(i + 1))
+			// The geocell alphabet holds no LIKE wildcards, so cells need no
+			// escaping.
+			args[i] = cell + "%"
+		}
+	} else {
+		b.WriteString(r.Table.Cell)
+		b.WriteString(" IN (")
+		for i, cell := range cells {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(placeholder(i + 1))
+			args[i] = cell
+		}
+		b.WriteString(")")
+	}
+	if partition != nil {
+		b.WriteString(") AND ")
+		b.WriteString(r.Table.Partition)
+		b.WriteString(" = ")
+		args = append(args, *partition)
+		b.WriteString(placeholder(len(args)))
+	}
+	return b.String(), args
+}
+
+func (r *Repository) scan(row Scanner) (geomodel.LocationCapable, error) {
+	if r.Scan != nil {
+		return r.Scan(row)
+	}
+	var record *geomodel.IndexRecord = &geomodel.IndexRecord{}
+	if err := row.Scan(&record.ID, &record.Lat, &record.Lon); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Query binds a context to the repository for use as a
+// geomodel.RepositorySearch, which cannot return errors: the first error is
+// kept for Err and makes later searches return no entities.
+type Query struct {
+	repo  *Repository
+	ctx   context.Context
+	first firsterr.Keeper
+}
+
+// Query returns a Query running searches with ctx.
+func (r *Repository) Query(ctx context.Context) *Query {
+	return &Query{repo: r, ctx: ctx}
+}
+
+// Search implements geomodel.RepositorySearch. It is safe for concurrent
+// use, as with geomodel.WithParallelism.
+func (q *Query) Search(cells []string) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.Search(q.ctx, cells)
+	})
+}
+
+// SearchPartition implements geomodel.PartitionSearch, searching as
+// Repository.SearchPartition does.
+func (q *Query) SearchPartition(partition string, cells []string) []geomodel.LocationCapable {
+	return q.first.Run(func() ([]geomodel.LocationCapable, error) {
+		return q.repo.SearchPartition(q.ctx, partition, cells)
+	})
+}
+
+// Err returns the first error of the searches run so far.
+func (q *Query) Err() error {
+	return q.first.Err()
+}
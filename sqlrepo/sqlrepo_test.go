@@ -0,0 +1,314 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alternaDev/geomodel"
+	"github.com/alternaDev/geomodel/adaptertest"
+)
+
+// fakeRow is a row of the table served by fakeDriver.
+type fakeRow struct {
+	key       string
+	lat, lon  float64
+	cell      string
+	partition string
+}
+
+// fakeDriver serves queries built by Repository over an in-memory table,
+// matching the cell column with IN, LIKE or ranges depending on the query
+// text and the partition column given by the last argument of partition
+// queries, and records the queries it runs and the most arguments any of
+// them took.
+type fakeDriver struct {
+	mu      sync.Mutex
+	rows    []fakeRow
+	queries []string
+	maxArgs int
+	fail    error
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.d, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.fail != nil {
+		return nil, s.d.fail
+	}
+	s.d.queries = append(s.d.queries, s.query)
+	s.d.maxArgs = max(s.d.maxArgs, len(args))
+
+	var rows []fakeRow = s.d.rows
+	if strings.Contains(s.query, ") AND ") {
+		var partition string = args[len(args)-1].(string)
+		args, rows = args[:len(args)-1], nil
+		for _, row := range s.d.rows {
+			if row.partition == partition {
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	var prefix bool = strings.Contains(s.query, " LIKE ")
+	var matched [][]driver.Value
+	if strings.Contains(s.query, " >= ") {
+		for _, row := range rows {
+			if matchRanges(s.query, args, row.cell) {
+				matched = append(matched, []driver.Value{row.key, row.lat, row.lon})
+			}
+		}
+		return &fakeRows{matched}, nil
+	}
+	for _, row := range rows {
+		for _, arg := range args {
+			var cell string = arg.(string)
+			if prefix && strings.HasPrefix(row.cell, strings.TrimSuffix(cell, "%")) || !prefix && row.cell == cell {
+				matched = append(matched, []driver.Value{row.key, row.lat, row.lon})
+				break
+			}
+		}
+	}
+	return &fakeRows{matched}, nil
+}
+
+// matchRanges reports whether cell lies in any of the ranges of a
+// MatchRange query, each taking one argument for its start and another for
+// its end if it has one.
+func matchRanges(query string, args []driver.Value, cell string) bool {
+	for _, clause := range strings.Split(query[strings.Index(query, " WHERE ")+7:], " OR ") {
+		var start string = args[0].(string)
+		var end string
+		if strings.Contains(clause, " < ") {
+			end, args = args[1].(string), args[2:]
+		} else {
+			args = args[1:]
+		}
+		if cell >= start && (end == "" || cell < end) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"id", "lat", "lon"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openFake(t *testing.T, name string, d *fakeDriver) *sql.DB {
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var table = Table{Name: "places", Key: "id", Lat: "lat", Lon: "lon", Cell: "geocell"}
+
+func TestBuildQuery(t *testing.T) {
+	var repo = &Repository{Table: table, Placeholder: Dollar}
+	query, args := repo.buildQuery([]string{"u1", "u2"}, nil)
+	if want := "SELECT id, lat, lon FROM places WHERE geocell IN ($1, $2)"; query != want || len(args) != 2 {
+		t.Errorf("exact query = %q with %v, want %q", query, args, want)
+	}
+
+	repo = &Repository{Table: table, Match: MatchPrefix, Columns: []string{"id", "lat", "lon", "name"}, Scan: func(Scanner) (geomodel.LocationCapable, error) { return nil, nil }}
+	query, args = repo.buildQuery([]string{"u1", "u2"}, nil)
+	if want := "SELECT id, lat, lon, name FROM places WHERE geocell LIKE ? OR geocell LIKE ?"; query != want || args[1] != "u2%" {
+		t.Errorf("prefix query = %q with %v, want %q", query, args, want)
+	}
+
+	repo = &Repository{Table: table, Match: MatchRange, Placeholder: Dollar}
+	query, args = repo.buildQuery([]string{"u1", "zz"}, nil)
+	if want := "SELECT id, lat, lon FROM places WHERE (geocell >= $1 AND geocell < $2) OR (geocell >= $3)"; query != want || len(args) != 3 || args[1] != "u2" {
+		t.Errorf("range query = %q with %v, want %q", query, args, want)
+	}
+
+	repo = &Repository{Table: table, Placeholder: Dollar}
+	repo.Table.Partition = "tenant"
+	var partition = "acme"
+	query, args = repo.buildQuery([]string{"u1", "u2"}, &partition)
+	if want := "SELECT id, lat, lon FROM places WHERE (geocell IN ($1, $2)) AND tenant = $3"; query != want || len(args) != 3 || args[2] != "acme" {
+		t.Errorf("partition query = %q with %v, want %q", query, args, want)
+	}
+}
+
+func TestRepositoryExact(t *testing.T) {
+	var d = &fakeDriver{}
+	for _, p := range []geomodel.Point{{Lat: 50, Lon: 8}, {Lat: 50.001, Lon: 8.001}, {Lat: -33.87, Lon: 151.21}} {
+		for _, cell := range geomodel.GeoCells(p.Lat, p.Lon, 8) {
+			d.rows = append(d.rows, fakeRow{geomodel.GeoCell(p.Lat, p.Lon, 8), p.Lat, p.Lon, cell, ""})
+		}
+	}
+	var repo = &Repository{DB: openFake(t, "sqlrepo-exact", d), Table: table, MaxPlaceholders: 2}
+
+	var query = repo.Query(context.Background())
+	var results = geomodel.ProximityFetch(50, 8, 2, 1000, query.Search, 8)
+	if err := query.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Latitude() != 50 {
+		t.Errorf("ProximityFetch returned %v, want the two nearby entities", results)
+	}
+
+	d.queries = nil
+	found, err := repo.Search(context.Background(), geomodel.GeoCells(50, 8, 5))
+	if err != nil || len(found) != 2 {
+		t.Errorf("Search over five ancestors returned %d entities (err %v), want 2", len(found), err)
+	}
+	if len(d.queries) != 3 {
+		t.Errorf("Search over five cells ran %d queries, want 3", len(d.queries))
+	}
+}
+
+func TestRepositoryPrefix(t *testing.T) {
+	var d = &fakeDriver{rows: []fakeRow{
+		{"a", 50, 8, geomodel.GeoCell(50, 8, 10), ""},
+		{"b", -33.87, 151.21, geomodel.GeoCell(-33.87, 151.21, 10), ""},
+	}}
+	var repo = &Repository{DB: openFake(t, "sqlrepo-prefix", d), Table: table, Match: MatchPrefix}
+	found, err := repo.Search(context.Background(), []string{geomodel.GeoCell(50, 8, 3)})
+	if err != nil || len(found) != 1 || found[0].Key() != "a" {
+		t.Errorf("Search returned %v (err %v), want a", found, err)
+	}
+}
+
+func TestQueryErr(t *testing.T) {
+	var failure = errors.New("connection lost")
+	var d = &fakeDriver{fail: failure}
+	var repo = &Repository{DB: openFake(t, "sqlrepo-fail", d), Table: table}
+
+	var query = repo.Query(context.Background())
+	if results := geomodel.ProximityFetch(50, 8, 2, 1000, query.Search, 8); len(results) != 0 {
+		t.Errorf("ProximityFetch returned %d entities despite errors", len(results))
+	}
+	if err := query.Err(); !errors.Is(err, failure) {
+		t.Errorf("Err() = %v, want %v", err, failure)
+	}
+}
+
+func TestRepositoryRange(t *testing.T) {
+	var d = &fakeDriver{rows: []fakeRow{
+		{"a", 50, 8, geomodel.GeoCell(50, 8, 10), ""},
+		{"b", -33.87, 151.21, geomodel.GeoCell(-33.87, 151.21, 10), ""},
+		{"c", 89.9, 179.9, geomodel.GeoCell(89.9, 179.9, 10), ""},
+	}}
+	var repo = &Repository{DB: openFake(t, "sqlrepo-range", d), Table: table, Match: MatchRange, MaxPlaceholders: 3}
+	found, err := repo.Search(context.Background(), []string{geomodel.GeoCell(50, 8, 3), geomodel.GeoCell(89.9, 179.9, 2), "s"})
+	if err != nil || len(found) != 2 || found[0].Key() != "a" || found[1].Key() != "c" {
+		t.Errorf("Search returned %v (err %v), want a and c", found, err)
+	}
+	// One cell per query with three placeholders.
+	if len(d.queries) != 3 {
+		t.Errorf("Search over three cells ran %d queries, want 3", len(d.queries))
+	}
+}
+
+func TestRepositoryPartition(t *testing.T) {
+	var d = &fakeDriver{rows: []fakeRow{
+		{"a", 50, 8, geomodel.GeoCell(50, 8, 10), "acme"},
+		{"b", 50, 8, geomodel.GeoCell(50, 8, 10), "globex"},
+	}}
+	var partitioned = table
+	partitioned.Partition = "tenant"
+	var repo = &Repository{DB: openFake(t, "sqlrepo-partition", d), Table: partitioned, Match: MatchPrefix}
+
+	var search, scope = geomodel.PartitionSearch(repo.Query(context.Background()).SearchPartition).In("globex")
+	if found := geomodel.ProximityFetch(50, 8, 5, 1000, search, 10, scope); len(found) != 1 || found[0].Key() != "b" {
+		t.Errorf("ProximityFetch in globex returned %v, want b", found)
+	}
+
+	// The partition argument counts towards the placeholder limit.
+	var cells []string = make([]string, DEFAULT_MAX_PLACEHOLDERS)
+	for i := range cells {
+		cells[i] = fmt.Sprintf("%06x", i)
+	}
+	cells[len(cells)-1] = geomodel.GeoCell(50, 8, 10)
+	for _, match := range []MatchMode{MatchExact, MatchPrefix, MatchRange} {
+		d.queries, d.maxArgs = nil, 0
+		repo.Match = match
+		found, err := repo.SearchPartition(context.Background(), "acme", cells)
+		if err != nil || len(found) != 1 || found[0].Key() != "a" {
+			t.Errorf("SearchPartition over %d cells in mode %v returned %v (err %v), want a", len(cells), match, found, err)
+		}
+		if d.maxArgs > DEFAULT_MAX_PLACEHOLDERS {
+			t.Errorf("SearchPartition in mode %v bound %d arguments, over DEFAULT_MAX_PLACEHOLDERS", match, d.maxArgs)
+		}
+	}
+	if _, err := (&Repository{Table: table}).SearchPartition(context.Background(), "acme", []string{"u"}); !errors.Is(err, ErrNotPartitioned) {
+		t.Errorf("SearchPartition without a partition column returned %v, want ErrNotPartitioned", err)
+	}
+}
+
+// tableIndex writes entities into the rows of a fakeDriver, one row per
+// entity holding its finest geocell, and searches them with MatchPrefix.
+type tableIndex struct {
+	d    *fakeDriver
+	repo *Repository
+}
+
+func (x *tableIndex) Put(entity geomodel.LocationCapable) error {
+	x.d.mu.Lock()
+	defer x.d.mu.Unlock()
+	x.d.rows = slices.DeleteFunc(x.d.rows, func(row fakeRow) bool { return row.key == entity.Key() })
+	var cell = geomodel.GeoCell(entity.Latitude(), entity.Longitude(), geomodel.MAX_GEOCELL_RESOLUTION)
+	x.d.rows = append(x.d.rows, fakeRow{entity.Key(), entity.Latitude(), entity.Longitude(), cell, ""})
+	return nil
+}
+
+func (x *tableIndex) Delete(key string) error {
+	x.d.mu.Lock()
+	defer x.d.mu.Unlock()
+	x.d.rows = slices.DeleteFunc(x.d.rows, func(row fakeRow) bool { return row.key == key })
+	return nil
+}
+
+func (x *tableIndex) Search(cells []string) ([]geomodel.LocationCapable, error) {
+	return x.repo.Search(context.Background(), cells)
+}
+
+func TestConformance(t *testing.T) {
+	var n int
+	adaptertest.Run(t, func() adaptertest.Index {
+		n++
+		var d = &fakeDriver{}
+		return &tableIndex{d, &Repository{DB: openFake(t, fmt.Sprint("sqlrepo-conformance-", n), d), Table: table, Match: MatchPrefix}}
+	})
+}
@@ -0,0 +1,63 @@
+package geomodel
+
+import "time"
+
+// SearchStats describes the cost of a single search.
+type SearchStats struct {
+	CellsQueried      int           // Cells passed to the repository.
+	RepositoryCalls   int           // RepositorySearch invocations.
+	EntitiesScanned   int           // Entities returned by the repository.
+	DuplicatesDropped int           // Entities returned more than once.
+	Iterations        int           // Steps of the search loop.
+	WallTime          time.Duration // Duration of the whole search.
+	LimitReached      bool          // The search stopped at a WithMaxIterations or WithMaxCellsSearched limit.
+}
+
+// WithStatsHook calls hook with the statistics of the search once it
+// completes, for monitoring query cost.
+func WithStatsHook(hook func(SearchStats)) Option {
+	return func(o *searchOptions) {
+		o.statsHook = hook
+	}
+}
+
+// WithMaxIterations stops a search after n steps of its search loop, even if
+// it has not found all results, so that queries in empty regions do not keep
+// widening up to the top-level cells. SearchStats.LimitReached reports that
+// the limit was hit.
+func WithMaxIterations(n int) Option {
+	return func(o *searchOptions) {
+		o.maxIterations = n
+	}
+}
+
+// WithMaxCellsSearched stops a search before the total number of cells passed
+// to the repository would exceed n. SearchStats.LimitReached reports that
+// the limit was hit.
+func WithMaxCellsSearched(n int) Option {
+	return func(o *searchOptions) {
+		o.maxCellsSearched = n
+	}
+}
+
+// exhausted reports whether starting another iteration searching that many
+// more cells would exceed a search limit, recording it in the stats if so.
+func (o *searchOptions) exhausted(cells int) bool {
+	if (o.maxIterations > 0 && o.stats.Iterations >= o.maxIterations) ||
+		(o.maxCellsSearched > 0 && o.stats.CellsQueried+cells > o.maxCellsSearched) {
+		if !o.stats.LimitReached {
+			o.logger.Debug("geomodel: search limit reached", "iterations", o.stats.Iterations, "cellsQueried", o.stats.CellsQueried)
+		}
+		o.stats.LimitReached = true
+		return true
+	}
+	return false
+}
+
+// finish reports the search statistics to the stats hook, if any.
+func (o *searchOptions) finish() {
+	if o.statsHook != nil {
+		o.stats.WallTime = time.Since(o.started)
+		o.statsHook(o.stats)
+	}
+}
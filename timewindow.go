@@ -0,0 +1,53 @@
+package geomodel
+
+import "time"
+
+// Timestamped is implemented by entities that record when they were at
+// their location, such as the positions of moving objects.
+type Timestamped interface {
+	Timestamp() time.Time
+}
+
+// TimeWindowSearch looks up the entities having any of the given cells among
+// their geocells and a timestamp in [from, to]. Adapters for repositories
+// of moving objects implement it by adding the window to their storage-level
+// filter, so that positions outside it are never read. A zero from or to
+// leaves the window open on that side.
+type TimeWindowSearch func(cells []string, from, to time.Time) []LocationCapable
+
+// Between returns a RepositorySearch passing the window [from, to] to s,
+// and the option restricting a search to it as WithTimeWindow does, so that
+// the two cannot disagree:
+//
+//	search, window := s.Between(from, to)
+//	ProximityFetch(lat, lon, 10, 0, search, 13, window)
+func (s TimeWindowSearch) Between(from, to time.Time) (RepositorySearch, Option) {
+	return func(cells []string) []LocationCapable {
+		return s(cells, from, to)
+	}, WithTimeWindow(from, to)
+}
+
+// WithTimeWindow restricts a search to the entities near the center between
+// from and to, dropping Timestamped entities stamped outside [from, to]. A
+// zero from or to leaves the window open on that side. Entities that are
+// not Timestamped are kept, taken to be filtered by the repository; see
+// TimeWindowSearch.
+func WithTimeWindow(from, to time.Time) Option {
+	return func(o *searchOptions) {
+		o.timeWindow = true
+		o.from, o.to = from, to
+	}
+}
+
+// inTimeWindow reports whether entity passes the time window of a search.
+func (o *searchOptions) inTimeWindow(entity LocationCapable) bool {
+	if !o.timeWindow {
+		return true
+	}
+	stamped, ok := entity.(Timestamped)
+	if !ok {
+		return true
+	}
+	var t time.Time = stamped.Timestamp()
+	return (o.from.IsZero() || !t.Before(o.from)) && (o.to.IsZero() || !t.After(o.to))
+}
@@ -0,0 +1,61 @@
+package geomodel
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type position struct {
+	Place
+	at time.Time
+}
+
+func (p position) Timestamp() time.Time { return p.at }
+
+func TestTimeWindowSearch(t *testing.T) {
+	var start = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var places []LocationCapable
+	for i := 0; i < 10; i++ {
+		var lat = 50 + float64(i)/1000
+		places = append(places, position{Place{lat, 8, fmt.Sprint(i), GeoCells(lat, 8, 10)}, start.Add(time.Duration(i) * time.Minute)})
+	}
+	var index = indexPlaces(places)
+
+	var from, to = start.Add(3 * time.Minute), start.Add(5 * time.Minute)
+	var pushed [2]time.Time
+	var s TimeWindowSearch = func(cells []string, from, to time.Time) []LocationCapable {
+		// Ignores the window, which the option enforces.
+		pushed = [2]time.Time{from, to}
+		return index(cells)
+	}
+	search, window := s.Between(from, to)
+	var results = ProximityFetch(50, 8, 10, 0, search, 10, window)
+	if fmt.Sprint(keysOf(results)) != "[3 4 5]" {
+		t.Errorf("got %v in the window, want [3 4 5]", keysOf(results))
+	}
+	if pushed != [2]time.Time{from, to} {
+		t.Errorf("window passed to the search is %v, want [%v %v]", pushed, from, to)
+	}
+
+	// Open on one side.
+	results = ProximityFetch(50, 8, 10, 0, index, 10, WithTimeWindow(time.Time{}, start.Add(time.Minute)))
+	if fmt.Sprint(keysOf(results)) != "[0 1]" {
+		t.Errorf("got %v before the end of the window, want [0 1]", keysOf(results))
+	}
+
+	// Entities without a timestamp are kept.
+	var plain = Place{50, 8, "plain", GeoCells(50, 8, 10)}
+	results = ProximityFetch(50, 8, 10, 0, indexPlaces([]LocationCapable{plain}), 10, WithTimeWindow(from, to))
+	if len(results) != 1 {
+		t.Errorf("got %d results without timestamps, want 1", len(results))
+	}
+}
+
+func keysOf(entities []LocationCapable) []string {
+	var keys []string
+	for _, e := range entities {
+		keys = append(keys, e.Key())
+	}
+	return keys
+}
@@ -0,0 +1,156 @@
+package geomodel
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// maxPreallocatedResults bounds the buffer a topK allocates up front, so that
+// a search for a huge number of results grows its buffer as results arrive
+// rather than allocating for all of them at once.
+const maxPreallocatedResults = 256
+
+// maxPooledKeys bounds the key sets kept for reuse by later searches, so that
+// a search over a huge area does not pin its set in memory.
+const maxPooledKeys = 1 << 16
+
+// keySetPool holds the sets searches deduplicate entities and cells with,
+// which otherwise account for most of the allocations of a search.
+var keySetPool = sync.Pool{New: func() interface{} { return make(map[string]struct{}) }}
+
+func getKeySet() map[string]struct{} {
+	return keySetPool.Get().(map[string]struct{})
+}
+
+func putKeySet(keys map[string]struct{}) {
+	if len(keys) <= maxPooledKeys {
+		clear(keys)
+		keySetPool.Put(keys)
+	}
+}
+
+// topK keeps the k nearest results offered to it in a bounded max-heap, so
+// that each offer costs O(log k) and the farthest kept result is at the
+// root. Results at equal distances rank by the tiebreaker, if set, so that
+// it also decides which of them are kept at the cut.
+type topK struct {
+	k          int
+	items      []LocationComparableTuple
+	seen       map[string]struct{}
+	tiebreaker func(a, b LocationCapable, da, db float64) bool
+}
+
+// newTopK returns an empty topK, whose key set is returned to the pool by
+// release.
+func newTopK(k int) *topK {
+	var h *topK = new(topK)
+	h.reset(k, nil)
+	return h
+}
+
+// reset empties h to keep the k nearest results, ranked by tiebreaker at
+// equal distances, reusing its buffer when large enough.
+func (h *topK) reset(k int, tiebreaker func(a, b LocationCapable, da, db float64) bool) {
+	h.k = k
+	h.tiebreaker = tiebreaker
+	if cap(h.items) < min(k, maxPreallocatedResults) {
+		h.items = make([]LocationComparableTuple, 0, min(k, maxPreallocatedResults))
+	}
+	h.items = h.items[:0]
+	h.seen = getKeySet()
+}
+
+// release returns the key set of h for reuse and drops the kept results; h
+// must be reset before it is used again.
+func (h *topK) release() {
+	putKeySet(h.seen)
+	h.seen = nil
+	h.tiebreaker = nil
+	clear(h.items)
+}
+
+func (h *topK) Len() int { return len(h.items) }
+
+// offer considers t for the nearest k and reports false if an entity with
+// the same key was offered before.
+func (h *topK) offer(t LocationComparableTuple) bool {
+	if _, ok := h.seen[t.first.Key()]; ok {
+		return false
+	}
+	h.seen[t.first.Key()] = struct{}{}
+
+	if len(h.items) < h.k {
+		h.items = append(h.items, t)
+		h.up(len(h.items) - 1)
+	} else if h.k > 0 && h.after(h.items[0], t) {
+		h.items[0] = t
+		h.down(0)
+	}
+	return true
+}
+
+// after reports whether a ranks after b: it is farther, or as far and not
+// preferred by the tiebreaker.
+func (h *topK) after(a, b LocationComparableTuple) bool {
+	if a.second != b.second {
+		return a.second > b.second
+	}
+	return h.tiebreaker != nil && h.tiebreaker(b.first, a.first, b.second, a.second)
+}
+
+// up and down restore the heap order after the item at j was added or
+// replaced. Unlike container/heap they do not box items in interfaces,
+// which would allocate for every offer.
+func (h *topK) up(j int) {
+	for j > 0 {
+		var i int = (j - 1) / 2
+		if !h.after(h.items[j], h.items[i]) {
+			break
+		}
+		h.items[i], h.items[j] = h.items[j], h.items[i]
+		j = i
+	}
+}
+
+func (h *topK) down(i int) {
+	for {
+		var j int = 2*i + 1
+		if j >= len(h.items) {
+			break
+		}
+		if right := j + 1; right < len(h.items) && h.after(h.items[right], h.items[j]) {
+			j = right
+		}
+		if !h.after(h.items[j], h.items[i]) {
+			break
+		}
+		h.items[i], h.items[j] = h.items[j], h.items[i]
+		i = j
+	}
+}
+
+// full reports whether k results are kept.
+func (h *topK) full() bool {
+	return len(h.items) >= h.k
+}
+
+// farthest returns the distance of the farthest kept result.
+func (h *topK) farthest() float64 {
+	return h.items[0].second
+}
+
+// sorted returns the kept results nearest first, sorting them in place; h
+// must not be offered more results after.
+func (h *topK) sorted() []LocationComparableTuple {
+	slices.SortFunc(h.items, func(a, b LocationComparableTuple) int {
+		if h.after(a, b) {
+			return 1
+		}
+		if h.after(b, a) {
+			return -1
+		}
+		return cmp.Compare(a.second, b.second)
+	})
+	return h.items
+}
@@ -0,0 +1,117 @@
+package geomodel
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	var h = newTopK(3)
+	for i, d := range []float64{5, 1, 4, 2, 3} {
+		h.offer(LocationComparableTuple{Place{key: fmt.Sprint(i)}, d})
+	}
+	if h.offer(LocationComparableTuple{Place{key: "1"}, 1}) {
+		t.Error("duplicate key accepted")
+	}
+	if !h.full() || h.farthest() != 3 {
+		t.Errorf("full=%v farthest=%v, want true and 3", h.full(), h.farthest())
+	}
+	var sorted = h.sorted()
+	for i, want := range []float64{1, 2, 3} {
+		if sorted[i].second != want {
+			t.Errorf("sorted[%d] at %v, want %v", i, sorted[i].second, want)
+		}
+	}
+}
+
+func TestProximityFetchHugeMaxResults(t *testing.T) {
+	var places []LocationCapable = randomPlaces(300)
+	var results = ProximityFetch(50.5, 8.5, math.MaxInt, 20000, indexPlaces(places), 10)
+	if len(results) == 0 || len(results) > len(places) {
+		t.Errorf("ProximityFetch for math.MaxInt results returned %d", len(results))
+	}
+}
+
+// indexPlaces returns a RepositorySearch looking places up by cell.
+func indexPlaces(places []LocationCapable) RepositorySearch {
+	var byCell = make(map[string][]LocationCapable)
+	for _, p := range places {
+		for _, cell := range p.Geocells() {
+			byCell[cell] = append(byCell[cell], p)
+		}
+	}
+	return func(cells []string) []LocationCapable {
+		var result []LocationCapable
+		for _, cell := range cells {
+			result = append(result, byCell[cell]...)
+		}
+		return result
+	}
+}
+
+func BenchmarkProximityFetch(b *testing.B) {
+	var search = indexPlaces(randomPlaces(100000))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ProximityFetch(50.5, 8.5, 200, 0, search, 10)
+	}
+}
+
+// reusingSearch returns a RepositorySearch like indexPlaces that returns its
+// results in one reused buffer, so that benchmarks count the allocations of
+// the search alone.
+func reusingSearch(places []LocationCapable) RepositorySearch {
+	var byCell = make(map[string][]LocationCapable)
+	for _, p := range places {
+		for _, cell := range p.Geocells() {
+			byCell[cell] = append(byCell[cell], p)
+		}
+	}
+	var buf []LocationCapable
+	return func(cells []string) []LocationCapable {
+		buf = buf[:0]
+		for _, cell := range cells {
+			buf = append(buf, byCell[cell]...)
+		}
+		return buf
+	}
+}
+
+func randomPlaces(n int) []LocationCapable {
+	var rng = rand.New(rand.NewSource(1))
+	var places []LocationCapable
+	for i := 0; i < n; i++ {
+		var lat, lon = 50 + rng.Float64(), 8 + rng.Float64()
+		places = append(places, Place{lat, lon, fmt.Sprint(i), GeoCells(lat, lon, 10)})
+	}
+	return places
+}
+
+func BenchmarkProximityFetchReusedBuffer(b *testing.B) {
+	var search = reusingSearch(randomPlaces(100000))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ProximityFetch(50.5, 8.5, 200, 0, search, 10)
+	}
+}
+
+// TestProximityFetchAllocs guards the allocations of a search, which were
+// over 600 per query when results were kept with container/heap and
+// deduplicated with fresh maps.
+func TestProximityFetchAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are unreliable under the race detector")
+	}
+	var search = reusingSearch(randomPlaces(20000))
+	var allocs float64 = testing.AllocsPerRun(20, func() {
+		ProximityFetch(50.5, 8.5, 200, 0, search, 10)
+	})
+	if allocs > 60 {
+		t.Errorf("ProximityFetch made %v allocations per query, want at most 60", allocs)
+	}
+}
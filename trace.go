@@ -0,0 +1,84 @@
+package geomodel
+
+import (
+	"math"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// traceMaxSteps bounds the points TraceGreatCircle samples along a route
+// before refining between them.
+const traceMaxSteps = 1 << 20
+
+// TraceGreatCircle returns the cells of the given resolution crossed by the
+// great circle from p1 to p2, in the order the route enters them and each
+// once, for corridor queries along flight or shipping routes and for filling
+// the gaps between sparse GPS fixes. Routes between antipodal points, which
+// have no unique great circle, head north from p1.
+//
+// The route is sampled at intervals shorter than the cells it passes, and
+// bisected between samples in different cells until every cell entered is
+// found. Cells only touched at a corner, to within a fraction of a
+// millimeter, may be missed.
+func TraceGreatCircle(p1, p2 Point, resolution int) []string {
+	resolution = max(1, min(resolution, MAX_GEOCELL_RESOLUTION))
+	var meters float64 = DistanceHaversine(p1.Lat, p1.Lon, p2.Lat, p2.Lon)
+	var bearing float64 = InitialBearing(p1, p2)
+	var at = func(f float64) Point {
+		if f == 1 {
+			return p2
+		}
+		return Destination(p1, bearing, f*meters)
+	}
+
+	var cells []string
+	var seen map[string]bool = make(map[string]bool)
+	var add = func(cell string) {
+		if !seen[cell] {
+			seen[cell] = true
+			cells = append(cells, cell)
+		}
+	}
+	add(GeoCell(p1.Lat, p1.Lon, resolution))
+	if meters == 0 {
+		return cells
+	}
+
+	// Cells are narrowest in longitude at the latitude farthest from the
+	// equator, found roughly from a few samples and widened by a degree.
+	var maxLat float64
+	for i := 0; i <= 64; i++ {
+		maxLat = math.Max(maxLat, math.Abs(at(float64(i)/64).Lat))
+	}
+	var latSpan, lonSpan = curve.Geohash.Span(resolution)
+	var step float64 = math.Min(latSpan, lonSpan*math.Max(math.Cos(DegToRad(math.Min(maxLat+1, 90))), 1e-3)) / 2
+	var steps int = int(math.Min(math.Ceil(RadToDeg(meters/EARTH_RADIUS)/step), traceMaxSteps))
+
+	// refine adds the cells entered between fractions f0 and f1 of the route,
+	// in cells c0 and c1.
+	var refine func(f0 float64, c0 string, f1 float64, c1 string)
+	refine = func(f0 float64, c0 string, f1 float64, c1 string) {
+		if c0 == c1 {
+			return
+		}
+		if (f1-f0)*meters < 1e-4 {
+			add(c1)
+			return
+		}
+		var fm float64 = (f0 + f1) / 2
+		var pm Point = at(fm)
+		var cm string = GeoCell(pm.Lat, pm.Lon, resolution)
+		refine(f0, c0, fm, cm)
+		refine(fm, cm, f1, c1)
+	}
+	var f0 float64
+	var c0 string = cells[0]
+	for i := 1; i <= steps; i++ {
+		var f1 float64 = float64(i) / float64(steps)
+		var p Point = at(f1)
+		var c1 string = GeoCell(p.Lat, p.Lon, resolution)
+		refine(f0, c0, f1, c1)
+		f0, c0 = f1, c1
+	}
+	return cells
+}
@@ -0,0 +1,49 @@
+package geomodel
+
+import (
+	"testing"
+)
+
+func TestTraceGreatCircle(t *testing.T) {
+	var routes = []struct {
+		name       string
+		p1, p2     Point
+		resolution int
+	}{
+		{"Bremen to Oldenburg", Point{53.0793, 8.8017}, Point{53.1435, 8.2146}, 6},
+		{"Frankfurt to New York", Point{50.0379, 8.5622}, Point{40.6413, -73.7781}, 3},
+		{"across the antimeridian", Point{-17.7, 178.4}, Point{-13.8, -171.8}, 4},
+		{"over the pole", Point{80, 10}, Point{80, -170}, 2},
+	}
+	for _, r := range routes {
+		var cells []string = TraceGreatCircle(r.p1, r.p2, r.resolution)
+		var traced map[string]bool = make(map[string]bool)
+		for i, cell := range cells {
+			if traced[cell] {
+				t.Errorf("%s: %q traced twice", r.name, cell)
+			}
+			traced[cell] = true
+			// Neighbours across the antimeridian or a pole touch once grown.
+			if i > 0 && !ComputeBox(cells[i-1]).Expand(1).Intersects(ComputeBox(cell)) {
+				t.Errorf("%s: %q does not touch %q before it", r.name, cell, cells[i-1])
+			}
+		}
+		if cells[0] != GeoCell(r.p1.Lat, r.p1.Lon, r.resolution) || cells[len(cells)-1] != GeoCell(r.p2.Lat, r.p2.Lon, r.resolution) {
+			t.Errorf("%s: trace runs from %q to %q", r.name, cells[0], cells[len(cells)-1])
+		}
+
+		var meters float64 = Distance(r.p1.Lat, r.p1.Lon, r.p2.Lat, r.p2.Lon)
+		var bearing float64 = InitialBearing(r.p1, r.p2)
+		for i := 0; i <= 10000; i++ {
+			var p Point = Destination(r.p1, bearing, meters*float64(i)/10000)
+			if cell := GeoCell(p.Lat, p.Lon, r.resolution); !traced[cell] {
+				t.Errorf("%s: route passes %v in %q, not traced", r.name, p, cell)
+				break
+			}
+		}
+	}
+
+	if cells := TraceGreatCircle(Point{50, 8}, Point{50, 8}, 8); len(cells) != 1 {
+		t.Errorf("trace of a single point = %v", cells)
+	}
+}
@@ -0,0 +1,60 @@
+package geomodel
+
+import "time"
+
+// TrackFix is a position of a GPS trace and the time it was taken.
+type TrackFix struct {
+	Point
+	Time time.Time
+}
+
+// CellVisit is a stay of a track in one cell, from the first fix in it to
+// the first fix after it.
+type CellVisit struct {
+	Cell        string
+	Enter, Exit time.Time
+}
+
+// Dwell returns how long the track stayed in the cell.
+func (v CellVisit) Dwell() time.Duration { return v.Exit.Sub(v.Enter) }
+
+// SimplifyTrack reduces the GPS trace points to the cells of the given
+// resolution it visits, in order, with consecutive fixes in the same cell
+// collapsed into one. A cell left and entered again appears again. Cells
+// crossed between two fixes are not filled in; TraceGreatCircle finds them.
+// Invalid points are skipped.
+func SimplifyTrack(points []Point, resolution int) []string {
+	resolution = max(1, min(resolution, MAX_GEOCELL_RESOLUTION))
+	var cells []string
+	for _, p := range points {
+		var cell string = GeoCell(p.Lat, p.Lon, resolution)
+		if cell != "" && (len(cells) == 0 || cells[len(cells)-1] != cell) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+// SimplifyTimedTrack reduces fixes like SimplifyTrack, recording when the
+// track entered and left each cell. A visit is left at the time of the
+// first fix of the next one, so that the dwells add up to the duration of
+// the track; the last visit is left at the last fix. Fixes are expected in
+// chronological order.
+func SimplifyTimedTrack(fixes []TrackFix, resolution int) []CellVisit {
+	resolution = max(1, min(resolution, MAX_GEOCELL_RESOLUTION))
+	var visits []CellVisit
+	for _, fix := range fixes {
+		var cell string = GeoCell(fix.Lat, fix.Lon, resolution)
+		if cell == "" {
+			continue
+		}
+		if n := len(visits); n > 0 {
+			visits[n-1].Exit = fix.Time
+			if visits[n-1].Cell == cell {
+				continue
+			}
+		}
+		visits = append(visits, CellVisit{Cell: cell, Enter: fix.Time, Exit: fix.Time})
+	}
+	return visits
+}
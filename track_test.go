@@ -0,0 +1,52 @@
+package geomodel
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSimplifyTrack(t *testing.T) {
+	var a, b = Point{53.0793, 8.8017}, Point{53.1435, 8.2146}
+	var cellA, cellB = GeoCell(a.Lat, a.Lon, 5), GeoCell(b.Lat, b.Lon, 5)
+	var points = []Point{a, a, {Lat: a.Lat + 1e-5, Lon: a.Lon}, b, {Lat: math.NaN(), Lon: 0}, b, b, a}
+
+	var want = []string{cellA, cellB, cellA}
+	if got := SimplifyTrack(points, 5); !reflect.DeepEqual(got, want) {
+		t.Errorf("SimplifyTrack = %v, want %v", got, want)
+	}
+	if got := SimplifyTrack(nil, 5); len(got) != 0 {
+		t.Errorf("SimplifyTrack(nil) = %v, want none", got)
+	}
+}
+
+func TestSimplifyTimedTrack(t *testing.T) {
+	var start = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	var a, b = Point{53.0793, 8.8017}, Point{53.1435, 8.2146}
+	var cellA, cellB = GeoCell(a.Lat, a.Lon, 5), GeoCell(b.Lat, b.Lon, 5)
+	var fixes = []TrackFix{
+		{a, start},
+		{a, start.Add(time.Minute)},
+		{b, start.Add(3 * time.Minute)},
+		{b, start.Add(10 * time.Minute)},
+		{a, start.Add(12 * time.Minute)},
+	}
+
+	var want = []CellVisit{
+		{cellA, start, start.Add(3 * time.Minute)},
+		{cellB, start.Add(3 * time.Minute), start.Add(12 * time.Minute)},
+		{cellA, start.Add(12 * time.Minute), start.Add(12 * time.Minute)},
+	}
+	var got = SimplifyTimedTrack(fixes, 5)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SimplifyTimedTrack = %v, want %v", got, want)
+	}
+	var total time.Duration
+	for _, v := range got {
+		total += v.Dwell()
+	}
+	if total != 12*time.Minute {
+		t.Errorf("dwells add up to %v, want 12m", total)
+	}
+}
@@ -0,0 +1,225 @@
+package geomodel
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FenceEventKind is the kind of a FenceEvent.
+type FenceEventKind int
+
+const (
+	FenceEnter FenceEventKind = iota // The object entered the fence.
+	FenceExit                        // The object left the fence.
+	FenceDwell                       // The object has stayed inside for the dwell time.
+)
+
+// String returns "enter", "exit" or "dwell".
+func (k FenceEventKind) String() string {
+	switch k {
+	case FenceEnter:
+		return "enter"
+	case FenceExit:
+		return "exit"
+	case FenceDwell:
+		return "dwell"
+	}
+	return "unknown"
+}
+
+// FenceEvent is emitted by a Tracker when an object crosses or dwells in a
+// fence.
+type FenceEvent struct {
+	Kind FenceEventKind
+	// Key is the key of the object, as passed to Tracker.Update.
+	Key string
+	// Fence is the name of the fence.
+	Fence string
+	// Point and Time are those of the update that caused the event.
+	Point Point
+	Time  time.Time
+}
+
+// TrackerOption configures a Tracker.
+type TrackerOption func(*Tracker)
+
+// WithDwell makes a Tracker emit a FenceDwell event once an object has stayed
+// inside a fence for d since entering it. Dwelling is noticed on the first
+// update at least d after the FenceEnter event.
+func WithDwell(d time.Duration) TrackerOption {
+	return func(t *Tracker) {
+		t.dwell = d
+	}
+}
+
+// WithDebounce makes a Tracker emit FenceEnter and FenceExit events only once
+// an object has been observed on the new side of a fence for d, so that
+// positions jittering across the edge do not cause bursts of events.
+func WithDebounce(d time.Duration) TrackerOption {
+	return func(t *Tracker) {
+		t.debounce = d
+	}
+}
+
+// WithHysteresis makes a Tracker emit FenceEnter and FenceExit events only
+// after n consecutive updates on the new side of a fence. Combined with
+// WithDebounce, both conditions must hold.
+func WithHysteresis(n int) TrackerOption {
+	return func(t *Tracker) {
+		t.hysteresis = n
+	}
+}
+
+// Tracker follows the positions of moving objects, such as couriers or
+// vehicles, across the fences of a FenceSet, and passes a FenceEvent to
+// its handler whenever an object enters, leaves or dwells in one of them.
+// Objects start outside every fence, so that the first update of an object
+// inside a fence emits FenceEnter. Fences may be added to and removed from
+// the set while it is tracked; objects inside a removed fence exit it on
+// their next update.
+//
+// A Tracker is safe for concurrent use. Events are passed to the handler
+// after the update causing them has been recorded and outside of the
+// Tracker's lock, so the handler may call the Tracker; events of one object
+// are delivered in order as long as its updates are not concurrent. To
+// receive events on a channel, pass a handler sending to it.
+type Tracker struct {
+	fences  *FenceSet
+	handler func(FenceEvent)
+
+	dwell      time.Duration
+	debounce   time.Duration
+	hysteresis int
+
+	mu sync.Mutex
+	// The state of each object in each fence it is inside of or about to
+	// cross, by object key and fence name.
+	objects map[string]map[string]*fenceState
+}
+
+// fenceState is the state of an object with respect to a fence.
+type fenceState struct {
+	inside  bool
+	entered time.Time
+	dwelled bool
+	// A crossing observed but not yet reported, since the time of its first
+	// observation and for the number of consecutive updates given.
+	pending      bool
+	pendingSince time.Time
+	pendingCount int
+}
+
+// NewTracker returns a tracker of objects across the fences of set, passing
+// events to handler.
+func NewTracker(set *FenceSet, handler func(FenceEvent), opts ...TrackerOption) *Tracker {
+	var t *Tracker = &Tracker{
+		fences:  set,
+		handler: handler,
+		objects: make(map[string]map[string]*fenceState),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Update records that the object with the given key was at (lat, lon) at
+// time at, emitting the events this causes, ordered by fence name. Only the
+// fences found by FenceSet.Containing and those the object was inside of are
+// considered. Updates of an object are expected in chronological order.
+func (t *Tracker) Update(key string, lat, lon float64, at time.Time) {
+	var events []FenceEvent
+	var emit = func(kind FenceEventKind, fence string) {
+		events = append(events, FenceEvent{Kind: kind, Key: key, Fence: fence, Point: Point{Lat: lat, Lon: lon}, Time: at})
+	}
+
+	var containing []string = t.fences.Containing(lat, lon)
+	var contained map[string]bool = make(map[string]bool, len(containing))
+	for _, name := range containing {
+		contained[name] = true
+	}
+
+	t.mu.Lock()
+	var states map[string]*fenceState = t.objects[key]
+	var names []string = containing
+	for name := range states {
+		if !contained[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var inside bool = contained[name]
+		var state *fenceState = states[name]
+		if state == nil {
+			if !inside {
+				continue
+			}
+			state = &fenceState{}
+			if states == nil {
+				states = make(map[string]*fenceState)
+				t.objects[key] = states
+			}
+			states[name] = state
+		}
+
+		if inside == state.inside {
+			state.pending = false
+		} else {
+			if !state.pending {
+				state.pending, state.pendingSince, state.pendingCount = true, at, 0
+			}
+			state.pendingCount++
+			if at.Sub(state.pendingSince) >= t.debounce && state.pendingCount >= t.hysteresis {
+				state.inside, state.pending = inside, false
+				if inside {
+					state.entered, state.dwelled = at, false
+					emit(FenceEnter, name)
+				} else {
+					emit(FenceExit, name)
+				}
+			}
+		}
+
+		if state.inside && t.dwell > 0 && !state.dwelled && at.Sub(state.entered) >= t.dwell {
+			state.dwelled = true
+			emit(FenceDwell, name)
+		}
+		if !state.inside && !state.pending {
+			delete(states, name)
+		}
+	}
+	if states != nil && len(states) == 0 {
+		delete(t.objects, key)
+	}
+	t.mu.Unlock()
+
+	for _, event := range events {
+		t.handler(event)
+	}
+}
+
+// Inside returns the names of the fences the object with the given key is
+// inside of, as reported by the events emitted so far, in order.
+func (t *Tracker) Inside(key string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var names []string
+	for name, state := range t.objects[key] {
+		if state.inside {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Forget drops the state of the object with the given key without emitting
+// events, as when it goes offline. Its next update starts it outside every
+// fence again.
+func (t *Tracker) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.objects, key)
+}
@@ -0,0 +1,73 @@
+package geomodel
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	var fences = map[string]*Geofence{
+		"depot": NewGeofence(Circle{Center: Point{Lat: 50, Lon: 8}, Radius: 1000}, 7),
+		"city":  NewGeofence(NewBoundingBox(50.1, 8.1, 49.9, 7.9), 7),
+	}
+	var events []string
+	var tracker *Tracker = NewTracker(NewFenceSet(fences), func(e FenceEvent) {
+		events = append(events, e.Key+" "+e.Fence+" "+e.Kind.String())
+	}, WithDwell(10*time.Minute))
+
+	var start time.Time = time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	var updates = []struct {
+		lat, lon float64
+		minutes  int
+		want     []string
+	}{
+		{50.05, 8.05, 0, []string{"van city enter"}},
+		{50.001, 8, 1, []string{"van depot enter"}},
+		{50.002, 8, 5, nil},
+		{50.001, 8.001, 12, []string{"van city dwell", "van depot dwell"}},
+		{50.001, 8.001, 30, nil},
+		{50.05, 8, 31, []string{"van depot exit"}},
+		{51, 8, 40, []string{"van city exit"}},
+	}
+	for _, u := range updates {
+		events = nil
+		tracker.Update("van", u.lat, u.lon, start.Add(time.Duration(u.minutes)*time.Minute))
+		if !reflect.DeepEqual(events, u.want) {
+			t.Errorf("update at minute %d emitted %v, want %v", u.minutes, events, u.want)
+		}
+	}
+	if inside := tracker.Inside("van"); len(inside) != 0 {
+		t.Errorf("van still inside %v", inside)
+	}
+	if len(tracker.objects) != 0 {
+		t.Errorf("tracker keeps state for %d objects outside every fence", len(tracker.objects))
+	}
+}
+
+func TestTrackerDebounce(t *testing.T) {
+	var fences = map[string]*Geofence{"zone": NewGeofence(Circle{Center: Point{Lat: 50, Lon: 8}, Radius: 1000}, 7)}
+	var kinds []FenceEventKind
+	var handler = func(e FenceEvent) { kinds = append(kinds, e.Kind) }
+	var start time.Time = time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	// Jitter across the edge every 10 seconds, then settle inside.
+	var positions = []float64{50.0089, 50.0091, 50.0089, 50.0091, 50.0089, 50.0089, 50.0089, 50.0089}
+	for _, test := range []struct {
+		opts []TrackerOption
+		want []FenceEventKind
+	}{
+		{nil, []FenceEventKind{FenceEnter, FenceExit, FenceEnter, FenceExit, FenceEnter}},
+		{[]TrackerOption{WithDebounce(25 * time.Second)}, []FenceEventKind{FenceEnter}},
+		{[]TrackerOption{WithHysteresis(3)}, []FenceEventKind{FenceEnter}},
+	} {
+		kinds = nil
+		var tracker *Tracker = NewTracker(NewFenceSet(fences), handler, test.opts...)
+		for i, lat := range positions {
+			tracker.Update("bike", lat, 8, start.Add(time.Duration(i)*10*time.Second))
+		}
+		if !reflect.DeepEqual(kinds, test.want) {
+			t.Errorf("with %d options got %v, want %v", len(test.opts), kinds, test.want)
+		}
+	}
+}
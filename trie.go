@@ -0,0 +1,246 @@
+package geomodel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// ErrCorruptCellTrie is returned by CellTrie.UnmarshalBinary for input not
+// written by CellTrie.MarshalBinary.
+var ErrCorruptCellTrie = errors.New("geomodel: corrupt cell trie")
+
+// cellTrieMagic starts every encoded CellTrie, followed by the format
+// version.
+const (
+	cellTrieMagic   = "GMCT"
+	cellTrieVersion = 1
+)
+
+// CellTrie is a set of cells stored as a prefix tree, so that membership of a
+// cell or of any of its ancestors is answered in time proportional to the
+// cell's resolution rather than the size of the set. The zero value is an
+// empty set ready to use. A CellTrie is not safe for concurrent writes.
+type CellTrie struct {
+	root cellTrieNode
+	size int
+	// depth is the length of the longest cell inserted.
+	depth int
+}
+
+type cellTrieNode struct {
+	children map[byte]*cellTrieNode
+	present  bool
+}
+
+// NewCellTrie returns a trie holding cells.
+func NewCellTrie(cells ...string) *CellTrie {
+	var t *CellTrie = &CellTrie{}
+	for _, cell := range cells {
+		t.Insert(cell)
+	}
+	return t
+}
+
+// Insert adds cell to the set and reports whether it was not already present.
+func (t *CellTrie) Insert(cell string) bool {
+	var node *cellTrieNode = &t.root
+	for i := 0; i < len(cell); i++ {
+		if node.children == nil {
+			node.children = make(map[byte]*cellTrieNode)
+		}
+		var child *cellTrieNode = node.children[cell[i]]
+		if child == nil {
+			child = &cellTrieNode{}
+			node.children[cell[i]] = child
+		}
+		node = child
+	}
+	if node.present {
+		return false
+	}
+	node.present = true
+	t.size++
+	t.depth = max(t.depth, len(cell))
+	return true
+}
+
+// InsertAll adds the cells of seq to the set and returns how many were not
+// already present. It loads large sets, such as the covering of a country
+// walked with IterateCells, without collecting them in a slice first.
+func (t *CellTrie) InsertAll(seq iter.Seq[string]) int {
+	var added int
+	for cell := range seq {
+		if t.Insert(cell) {
+			added++
+		}
+	}
+	return added
+}
+
+// Contains reports whether cell itself is in the set.
+func (t *CellTrie) Contains(cell string) bool {
+	var node *cellTrieNode = &t.root
+	for i := 0; i < len(cell) && node != nil; i++ {
+		node = node.children[cell[i]]
+	}
+	return node != nil && node.present
+}
+
+// ContainsPointViaAncestors reports whether cell or any of its ancestors is
+// in the set, that is whether every point of cell is covered by the set.
+func (t *CellTrie) ContainsPointViaAncestors(cell string) bool {
+	var node *cellTrieNode = &t.root
+	for i := 0; i < len(cell); i++ {
+		if node.present {
+			return true
+		}
+		node = node.children[cell[i]]
+		if node == nil {
+			return false
+		}
+	}
+	return node.present
+}
+
+// Covers reports whether (lat, lon) lies in a cell of the set. The point is
+// encoded once, at the finest resolution in the set, and looked up in time
+// proportional to that resolution, however large the set.
+func (t *CellTrie) Covers(lat, lon float64) bool {
+	if t.size == 0 {
+		return false
+	}
+	var buf [MAX_GEOCELL_RESOLUTION]byte
+	var cell []byte = AppendGeoCell(buf[:0], lat, lon, t.depth)
+	var node *cellTrieNode = &t.root
+	for i := 0; i < len(cell) && !node.present; i++ {
+		if node = node.children[cell[i]]; node == nil {
+			return false
+		}
+	}
+	return node.present
+}
+
+// Intersects reports whether cell shares any area with the set, that is
+// whether cell, one of its ancestors or one of its descendants is in the set.
+func (t *CellTrie) Intersects(cell string) bool {
+	var node *cellTrieNode = &t.root
+	for i := 0; i < len(cell); i++ {
+		if node.present {
+			return true
+		}
+		if node = node.children[cell[i]]; node == nil {
+			return false
+		}
+	}
+	// Every node lies on the path to a cell of the set.
+	return node.present || len(node.children) > 0
+}
+
+// Len returns the number of cells in the set.
+func (t *CellTrie) Len() int {
+	return t.size
+}
+
+// All returns the cells of the set in lexicographic order.
+func (t *CellTrie) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		t.root.walk(make([]byte, 0, MAX_GEOCELL_RESOLUTION), yield)
+	}
+}
+
+func (n *cellTrieNode) walk(prefix []byte, yield func(string) bool) bool {
+	if n.present && !yield(string(prefix)) {
+		return false
+	}
+
+	var keys []byte = make([]byte, 0, len(n.children))
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	for _, k := range keys {
+		if !n.children[k].walk(append(prefix, k), yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding holds a
+// header, the cell count and the cells in lexicographic order, each stored
+// as the length of the prefix it shares with the previous cell followed by
+// the remaining characters, so that the cells of a dense covering cost
+// about three bytes each.
+func (t *CellTrie) MarshalBinary() ([]byte, error) {
+	var buf []byte = append([]byte(cellTrieMagic), cellTrieVersion)
+	buf = binary.AppendUvarint(buf, uint64(t.size))
+	var previous string
+	for cell := range t.All() {
+		var shared int
+		for shared < len(cell) && shared < len(previous) && cell[shared] == previous[shared] {
+			shared++
+		}
+		buf = binary.AppendUvarint(buf, uint64(shared))
+		buf = appendSnapshotString(buf, cell[shared:])
+		previous = cell
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the
+// cells of t with those encoded by MarshalBinary. It returns an error
+// wrapping ErrCorruptCellTrie for other input and leaves t unchanged.
+func (t *CellTrie) UnmarshalBinary(data []byte) error {
+	if len(data) < len(cellTrieMagic)+1 || string(data[:len(cellTrieMagic)]) != cellTrieMagic {
+		return ErrCorruptCellTrie
+	}
+	if version := data[len(cellTrieMagic)]; version != cellTrieVersion {
+		return fmt.Errorf("geomodel: unsupported cell trie version %d", version)
+	}
+	data = data[len(cellTrieMagic)+1:]
+
+	var next = func() (uint64, error) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, fmt.Errorf("%w: truncated", ErrCorruptCellTrie)
+		}
+		data = data[n:]
+		return v, nil
+	}
+	count, err := next()
+	if err != nil {
+		return err
+	}
+	// Every cell takes at least two bytes.
+	if count > uint64(len(data))/2 {
+		return fmt.Errorf("%w: %d cells in %d bytes", ErrCorruptCellTrie, count, len(data))
+	}
+
+	var decoded CellTrie
+	var previous string
+	for ; count > 0; count-- {
+		shared, err := next()
+		if err != nil {
+			return err
+		}
+		length, err := next()
+		if err != nil {
+			return err
+		}
+		if shared > uint64(len(previous)) || length > uint64(len(data)) {
+			return fmt.Errorf("%w: invalid cell", ErrCorruptCellTrie)
+		}
+		previous = previous[:shared] + string(data[:length])
+		data = data[length:]
+		decoded.Insert(previous)
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrCorruptCellTrie, len(data))
+	}
+	*t = decoded
+	return nil
+}
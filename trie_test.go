@@ -0,0 +1,106 @@
+package geomodel
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCellTrie(t *testing.T) {
+	var trie CellTrie
+	for _, cell := range []string{"u1", "u0q", "s", "u1"} {
+		trie.Insert(cell)
+	}
+	if trie.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", trie.Len())
+	}
+
+	var cases = []struct {
+		cell                   string
+		contains, viaAncestors bool
+	}{
+		{"u1", true, true},
+		{"u1x", false, true},
+		{"u", false, false},
+		{"u0", false, false},
+		{"u0q", true, true},
+		{"u0qz", false, true},
+		{"s00000", false, true},
+		{"t", false, false},
+	}
+	for _, c := range cases {
+		if got := trie.Contains(c.cell); got != c.contains {
+			t.Errorf("Contains(%q) = %v, want %v", c.cell, got, c.contains)
+		}
+		if got := trie.ContainsPointViaAncestors(c.cell); got != c.viaAncestors {
+			t.Errorf("ContainsPointViaAncestors(%q) = %v, want %v", c.cell, got, c.viaAncestors)
+		}
+	}
+
+	if got, want := slices.Collect(trie.All()), []string{"s", "u0q", "u1"}; !slices.Equal(got, want) {
+		t.Errorf("All() = %v, want %v", got, want)
+	}
+}
+
+func TestCellTrieCoversIntersects(t *testing.T) {
+	var trie CellTrie
+	var cell = GeoCell(53.12869, 8.18976, 7)
+	if added := trie.InsertAll(slices.Values([]string{cell, "s", cell[:3] + "0", "s"})); added != 3 {
+		t.Errorf("InsertAll added %d cells, want 3", added)
+	}
+	if trie.Covers(53.12869, 8.18976) != true || trie.Covers(0.1, 0.1) != true || trie.Covers(53.5, 8.5) {
+		t.Error("Covers disagrees with the cells of the set")
+	}
+	if (&CellTrie{}).Covers(0, 0) {
+		t.Error("empty trie covers a point")
+	}
+
+	var cases = []struct {
+		cell string
+		want bool
+	}{
+		{cell[:2], true},
+		{cell, true},
+		{cell + "0", true},
+		{cell[:6] + "x", cell[6] == 'x'},
+		{"s0", true},
+		{"t", false},
+		{"", true},
+	}
+	for _, c := range cases {
+		if got := trie.Intersects(c.cell); got != c.want {
+			t.Errorf("Intersects(%q) = %v, want %v", c.cell, got, c.want)
+		}
+	}
+}
+
+func TestCellTrieBinary(t *testing.T) {
+	var trie = NewCellTrie(slices.Collect(IterateCells(NewBoundingBox(50.5, 8.5, 50, 8), 5))...)
+	trie.Insert("s")
+	data, err := trie.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CellTrie
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := slices.Collect(decoded.All()), slices.Collect(trie.All()); !slices.Equal(got, want) {
+		t.Errorf("decoded cells %v, want %v", got, want)
+	}
+	if !decoded.Covers(50.2, 8.2) {
+		t.Error("decoded trie lost its depth")
+	}
+	if len(data) > 4*trie.Len() {
+		t.Errorf("%d cells encoded in %d bytes", trie.Len(), len(data))
+	}
+
+	for _, corrupt := range [][]byte{nil, []byte("GMIX\x01"), data[:len(data)-1], append(slices.Clone(data), 0)} {
+		if err := decoded.UnmarshalBinary(corrupt); !errors.Is(err, ErrCorruptCellTrie) {
+			t.Errorf("UnmarshalBinary(%q) = %v, want ErrCorruptCellTrie", corrupt, err)
+		}
+	}
+	if decoded.Len() != trie.Len() {
+		t.Error("failed UnmarshalBinary changed the trie")
+	}
+}
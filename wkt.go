@@ -0,0 +1,96 @@
+package geomodel
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WKB geometry type codes.
+const (
+	wkbPolygon      = 3
+	wkbMultiPolygon = 6
+)
+
+// ring returns the corners of a box not crossing the antimeridian as a
+// closed, counterclockwise ring of (lon, lat) pairs.
+func (bbox BoundingBox) ring() [5][2]float64 {
+	return [5][2]float64{
+		{bbox.lonSW, bbox.latSW},
+		{bbox.lonNE, bbox.latSW},
+		{bbox.lonNE, bbox.latNE},
+		{bbox.lonSW, bbox.latNE},
+		{bbox.lonSW, bbox.latSW},
+	}
+}
+
+// WKT returns bbox as Well-Known Text with longitude first, for use with
+// spatial SQL such as PostGIS's ST_GeomFromText: a POLYGON, or a
+// MULTIPOLYGON of its two parts if it crosses the antimeridian.
+func (bbox BoundingBox) WKT() string {
+	var parts []BoundingBox = bbox.Split()
+	var b strings.Builder
+	if len(parts) > 1 {
+		b.WriteString("MULTIPOLYGON(")
+	} else {
+		b.WriteString("POLYGON")
+	}
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("((")
+		for j, p := range part.ring() {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(strconv.FormatFloat(p[0], 'f', -1, 64))
+			b.WriteString(" ")
+			b.WriteString(strconv.FormatFloat(p[1], 'f', -1, 64))
+		}
+		b.WriteString("))")
+	}
+	if len(parts) > 1 {
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// WKB returns bbox as little-endian Well-Known Binary, the geometry WKT
+// describes, for use with functions such as PostGIS's ST_GeomFromWKB.
+func (bbox BoundingBox) WKB() []byte {
+	var parts []BoundingBox = bbox.Split()
+	if len(parts) == 1 {
+		return appendWKBPolygon(nil, parts[0])
+	}
+	var buf []byte = []byte{1}
+	buf = binary.LittleEndian.AppendUint32(buf, wkbMultiPolygon)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(parts)))
+	for _, part := range parts {
+		buf = appendWKBPolygon(buf, part)
+	}
+	return buf
+}
+
+func appendWKBPolygon(buf []byte, bbox BoundingBox) []byte {
+	buf = append(buf, 1)
+	buf = binary.LittleEndian.AppendUint32(buf, wkbPolygon)
+	buf = binary.LittleEndian.AppendUint32(buf, 1)
+	buf = binary.LittleEndian.AppendUint32(buf, 5)
+	for _, p := range bbox.ring() {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p[0]))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p[1]))
+	}
+	return buf
+}
+
+// CellToWKT returns the polygon of cell as Well-Known Text.
+func CellToWKT(cell string) string {
+	return ComputeBox(cell).WKT()
+}
+
+// CellToWKB returns the polygon of cell as Well-Known Binary.
+func CellToWKB(cell string) []byte {
+	return ComputeBox(cell).WKB()
+}
@@ -0,0 +1,39 @@
+package geomodel
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+func TestWKT(t *testing.T) {
+	if got, want := NewBoundingBox(2, 3, 1, -4.5).WKT(), "POLYGON((-4.5 1,3 1,3 2,-4.5 2,-4.5 1))"; got != want {
+		t.Errorf("WKT() = %q, want %q", got, want)
+	}
+	if got, want := NewBoundingBox(10, -170, 0, 170).WKT(), "MULTIPOLYGON(((170 0,180 0,180 10,170 10,170 0)),((-180 0,-170 0,-170 10,-180 10,-180 0)))"; got != want {
+		t.Errorf("WKT() across the antimeridian = %q, want %q", got, want)
+	}
+	if got, want := CellToWKT("s"), "POLYGON((0 0,45 0,45 45,0 45,0 0))"; got != want {
+		t.Errorf("CellToWKT(%q) = %q, want %q", "s", got, want)
+	}
+}
+
+func TestWKB(t *testing.T) {
+	// POLYGON((0 0,45 0,45 45,0 45,0 0)) as produced by PostGIS's
+	// ST_AsBinary(geom, 'NDR').
+	const want = "0103000000010000000500000000000000000000000000000000000000" +
+		"0000000000804640000000000000000000000000008046400000000000804640" +
+		"0000000000000000000000000080464000000000000000000000000000000000"
+	if got := hex.EncodeToString(CellToWKB("s")); got != want {
+		t.Errorf("CellToWKB(%q) = %s, want %s", "s", got, want)
+	}
+
+	var multi = NewBoundingBox(10, -170, 0, 170).WKB()
+	if binary.LittleEndian.Uint32(multi[1:]) != wkbMultiPolygon || binary.LittleEndian.Uint32(multi[5:]) != 2 {
+		t.Errorf("WKB() across the antimeridian has header %x", multi[:9])
+	}
+	if lon := math.Float64frombits(binary.LittleEndian.Uint64(multi[9+13:])); len(multi) != 9+2*(13+80) || lon != 170 {
+		t.Errorf("WKB() across the antimeridian has %d bytes, first lon %v", len(multi), lon)
+	}
+}
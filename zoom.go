@@ -0,0 +1,34 @@
+package geomodel
+
+import (
+	"math"
+
+	"github.com/alternaDev/geomodel/internal/curve"
+)
+
+// MAX_ZOOM is the deepest Web Mercator zoom level ZoomForResolution returns,
+// that of the finest tiles served by common slippy maps.
+const MAX_ZOOM = 24
+
+// ResolutionForZoom returns the geocell resolution matching tiles of the
+// Web Mercator zoom level zoom: the coarsest resolution whose cells are no
+// wider than a tile, so that each tile shows at least one cell across and a
+// few at most. Zoom levels below 1 map to resolution 1 and levels too deep
+// to MAX_GEOCELL_RESOLUTION.
+func ResolutionForZoom(zoom int) int {
+	var tileWidth float64 = 360 / math.Exp2(float64(zoom))
+	for resolution := 1; resolution < MAX_GEOCELL_RESOLUTION; resolution++ {
+		if _, lonSpan := curve.Geohash.Span(resolution); lonSpan <= tileWidth {
+			return resolution
+		}
+	}
+	return MAX_GEOCELL_RESOLUTION
+}
+
+// ZoomForResolution is the inverse of ResolutionForZoom: it returns the
+// deepest Web Mercator zoom level whose tiles are at least as wide as cells
+// at resolution, capped at MAX_ZOOM.
+func ZoomForResolution(resolution int) int {
+	var _, lonSpan = curve.Geohash.Span(max(1, min(resolution, MAX_GEOCELL_RESOLUTION)))
+	return min(int(math.Floor(math.Log2(360/lonSpan)+1e-9)), MAX_ZOOM)
+}
@@ -0,0 +1,22 @@
+package geomodel
+
+import "testing"
+
+func TestResolutionForZoom(t *testing.T) {
+	var tests = map[int]int{0: 1, 1: 1, 3: 1, 4: 2, 5: 2, 6: 3, 8: 3, 9: 4, 10: 4, 11: 5, 15: 6, 20: 8, 40: MAX_GEOCELL_RESOLUTION}
+	for zoom, want := range tests {
+		if got := ResolutionForZoom(zoom); got != want {
+			t.Errorf("ResolutionForZoom(%d) = %d, want %d", zoom, got, want)
+		}
+	}
+
+	for resolution := 1; resolution <= 9; resolution++ {
+		var zoom int = ZoomForResolution(resolution)
+		if got := ResolutionForZoom(zoom); got != resolution {
+			t.Errorf("ResolutionForZoom(ZoomForResolution(%d) = %d) = %d", resolution, zoom, got)
+		}
+	}
+	if zoom := ZoomForResolution(MAX_GEOCELL_RESOLUTION); zoom != MAX_ZOOM {
+		t.Errorf("ZoomForResolution(%d) = %d, want MAX_ZOOM", MAX_GEOCELL_RESOLUTION, zoom)
+	}
+}